@@ -0,0 +1,43 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"time"
+)
+
+// roller storage with daily rollups of redirects, only postgres has them.
+type roller interface {
+	RebuildRollups(ctx context.Context, from time.Time) error
+	RollupRedirects(ctx context.Context, now time.Time) (time.Time, error)
+}
+
+// backfill runs backfill subcommand: it aggregates again rolled up days
+// since optional date in time.DateOnly format and rolls up the rest.
+func backfill(r roller, args []string, out io.Writer) error {
+	var from time.Time
+	switch len(args) {
+	case 0:
+	case 1:
+		var err error
+		from, err = time.Parse(time.DateOnly, args[0])
+		if err != nil {
+			return fmt.Errorf("backfill date, format %s: %w", time.DateOnly, err)
+		}
+	default:
+		return fmt.Errorf("usage: backfill [%s]", time.DateOnly)
+	}
+	ctx := context.Background()
+
+	err := r.RebuildRollups(ctx, from)
+	if err != nil {
+		return err
+	}
+	until, err := r.RollupRedirects(ctx, time.Now())
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(out, "redirects are rolled up until %s\n", until.Format(time.DateOnly))
+	return nil
+}
@@ -2,16 +2,24 @@ package main
 
 import (
 	"errors"
+	"expvar"
 	"fmt"
 	"net"
 	"os"
 	"os/signal"
+	"shortener/internal/deadletter"
+	"shortener/internal/geo"
+	"shortener/internal/health"
+	"shortener/internal/safety"
 	"shortener/internal/service"
 	"shortener/internal/storage"
+	"shortener/internal/storage/memory"
 	"shortener/internal/storage/postgres"
 	"shortener/internal/storage/redis"
+	"shortener/internal/storage/sqlite"
 	"shortener/internal/web"
 	"strconv"
+	"strings"
 	"syscall"
 	"text/template"
 	"time"
@@ -31,6 +39,15 @@ import (
 // @description Создает алиасы для ссылок
 // @host localhost
 // @BasePath /
+// @securityDefinitions.apikey AdminToken
+// @in header
+// @name Authorization
+
+const (
+	// DefaultRequestTimeout leaves time to write response before server's
+	// WriteTimeout.
+	DefaultRequestTimeout = 9 * time.Second
+)
 
 var (
 	ConfigPath       = "../config/config.yml" // prod: os.Getenv("CONFIG_PATH")
@@ -38,6 +55,8 @@ var (
 	Templates        = "templates/*.html"     // prod: os.Getenv("templates")
 	PostgresPassword = "qqq"                  // prod: os.Getenv("POSTGRES_PASSWORD")
 	RedisPassword    = "qqq"                  // prod: os.Getenv("REDIS_PASSWORD")
+	AdminToken       = ""                     // prod: os.Getenv("ADMIN_TOKEN")
+	HashidsSalt      = ""                     // prod: os.Getenv("HASHIDS_SALT")
 )
 
 func templates(router *ginext.Engine) {
@@ -48,6 +67,94 @@ func templates(router *ginext.Engine) {
 	router.LoadHTMLGlob(Templates)
 }
 
+func cfgInt(cfg *config.Config, key string, def int) int {
+	v := cfg.GetString(key)
+	if v == "" {
+		return def
+	}
+	i, err := strconv.Atoi(v)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, key+": "+err.Error())
+		os.Exit(1)
+	}
+	return i
+}
+
+func cfgDuration(cfg *config.Config, key string, def time.Duration) time.Duration {
+	v := cfg.GetString(key)
+	if v == "" {
+		return def
+	}
+	d, err := time.ParseDuration(v)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, key+": "+err.Error())
+		os.Exit(1)
+	}
+	return d
+}
+
+func aliasGenerator(cfg *config.Config, str *storage.Storage) (service.AliasGenerator, error) {
+	length := cfgInt(cfg, "alias.length", service.AliasLen)
+	alphabet := cfg.GetString("alias.alphabet")
+
+	switch g := cfg.GetString("alias.generator"); g {
+	case "", service.GeneratorRandom:
+		return service.NewRandomGenerator(alphabet, length)
+	case service.GeneratorSequence:
+		return service.NewSequenceGenerator(str, length), nil
+	case service.GeneratorHashids:
+		return service.NewHashidsGenerator(
+			str, HashidsSalt, alphabet, length,
+		)
+	case service.GeneratorSnowflake:
+		return service.NewSnowflakeGenerator(
+			int64(cfgInt(cfg, "alias.node_id", 0)),
+		)
+	default:
+		return nil, fmt.Errorf("unknown alias generator %q", g)
+	}
+}
+
+func storageDB(cfg *config.Config) (storage.DB, error) {
+	switch b := cfg.GetString("storage.db"); b {
+	case "", "postgres":
+		var opts []postgres.Option
+		if replicas := cfg.GetString("postgres.replicas"); replicas != "" {
+			opts = append(opts, postgres.WithReplicas(
+				cfgDuration(cfg, "postgres.replica_check_interval", postgres.DefaultReplicaCheckInterval),
+				strings.Split(replicas, ",")...,
+			))
+		}
+		return postgres.New(
+			cfg.GetString("postgres.host"), cfg.GetString("postgres.port"),
+			cfg.GetString("postgres.username"), PostgresPassword,
+			cfg.GetString("postgres.dbname"), cfg.GetString("postgres.sslmode"),
+			opts...,
+		), nil
+	case "sqlite":
+		return sqlite.New(cfg.GetString("sqlite.path")), nil
+	case "memory":
+		return memory.New(), nil
+	default:
+		return nil, fmt.Errorf("unknown storage db %q", b)
+	}
+}
+
+func storageCache(cfg *config.Config) (storage.Cache, error) {
+	switch b := cfg.GetString("storage.cache"); b {
+	case "", "redis":
+		rdI, err := strconv.Atoi(cfg.GetString("redis.db"))
+		if err != nil {
+			return nil, err
+		}
+		return redis.New(cfg.GetString("redis.addr"), RedisPassword, rdI), nil
+	case "memory":
+		return memory.NewCache(), nil
+	default:
+		return nil, fmt.Errorf("unknown storage cache %q", b)
+	}
+}
+
 func init() {
 	if os.Getenv("DEBUG") == "false" {
 		gin.SetMode(gin.ReleaseMode)
@@ -58,6 +165,8 @@ func init() {
 	Templates = os.Getenv("TEMPLATES")
 	PostgresPassword = os.Getenv("POSTGRES_PASSWORD")
 	RedisPassword = os.Getenv("REDIS_PASSWORD")
+	AdminToken = os.Getenv("ADMIN_TOKEN")
+	HashidsSalt = os.Getenv("HASHIDS_SALT")
 }
 
 func main() {
@@ -73,27 +182,202 @@ func main() {
 		os.Exit(1)
 	}
 
-	rdI, err := strconv.Atoi(cfg.GetString("redis.db"))
+	// connections are made only to selected backends
+	db, err := storageDB(cfg)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+
+	// main migrate up|down|status
+	if len(os.Args) > 1 && os.Args[1] == "migrate" {
+		m, ok := db.(migrator)
+		if !ok || len(os.Args) != 3 {
+			fmt.Fprintln(os.Stderr, "usage: migrate up|down|status, storage must have migrations")
+			os.Exit(1)
+		}
+		err = migrate(m, os.Args[2], os.Stdout)
+		db.Shutdown()
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+		return
+	}
+	if m, ok := db.(migrator); ok && cfg.GetString("storage.migrate_on_start") != "false" {
+		err = migrate(m, "up", os.Stdout)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+	}
+
+	// main backfill [2006-01-02], schema must be migrated
+	if len(os.Args) > 1 && os.Args[1] == "backfill" {
+		r, ok := db.(roller)
+		if !ok {
+			fmt.Fprintln(os.Stderr, "backfill: storage has no rollups")
+			os.Exit(1)
+		}
+		err = backfill(r, os.Args[2:], os.Stdout)
+		db.Shutdown()
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+		return
+	}
+	// main replay, batches of redirects from dead letter file are saved again,
+	// schema must be migrated
+	if len(os.Args) > 1 && os.Args[1] == "replay" {
+		path := cfg.GetString("redirects.dead_letter_file")
+		if path == "" {
+			fmt.Fprintln(os.Stderr, "replay: redirects.dead_letter_file isn't set")
+			os.Exit(1)
+		}
+		err = replay(db, path, os.Stdout)
+		db.Shutdown()
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+		return
+	}
+	if pg, ok := db.(*postgres.Postgres); ok {
+		pg.MaintainPartitions(postgres.PartitionOptions{
+			Ahead:     cfgInt(cfg, "postgres.partitions_ahead", postgres.DefaultPartitionsAhead),
+			Retention: cfgDuration(cfg, "postgres.retention", 0),
+			Interval:  cfgDuration(cfg, "postgres.partitions_interval", postgres.DefaultPartitionsInterval),
+		})
+		pg.MaintainRollups(cfgDuration(cfg, "postgres.rollup_interval", postgres.DefaultRollupInterval))
+	}
+
+	c, err := storageCache(cfg)
 	if err != nil {
 		fmt.Fprintln(os.Stderr, err)
 		os.Exit(1)
 	}
-	rd := redis.New(
-		cfg.GetString("redis.addr"),
-		RedisPassword,
-		rdI,
+	str := storage.New(db, c, storage.WithTimeouts(storage.Timeouts{
+		Read:      cfgDuration(cfg, "timeouts.read", storage.DefaultReadTimeout),
+		Write:     cfgDuration(cfg, "timeouts.write", storage.DefaultWriteTimeout),
+		Analytics: cfgDuration(cfg, "timeouts.analytics", storage.DefaultAnalyticsTimeout),
+		Cache:     cfgDuration(cfg, "timeouts.cache", storage.DefaultCacheTimeout),
+	}), storage.WithNegativeTTL(
+		cfgDuration(cfg, "storage.negative_ttl", storage.DefaultNegativeTTL),
+	), storage.WithLocalCache(
+		cfgInt(cfg, "storage.local_cache_size", storage.DefaultLocalCacheSize),
+		cfgDuration(cfg, "storage.local_cache_ttl", storage.DefaultLocalCacheTTL),
+	))
+	expvar.Publish("url_cache", expvar.Func(func() any { return str.CacheStats() }))
+
+	policy, err := service.NewAliasPolicy(
+		cfgInt(cfg, "alias.min_length", service.DefaultAliasMinLen),
+		cfgInt(cfg, "alias.max_length", service.DefaultAliasMaxLen),
+		cfg.GetString("alias.charset"),
+		strings.Split(cfg.GetString("alias.reserved"), ","),
+		cfg.GetString("alias.blocklist_file"),
+		service.CaseFold(cfg.GetString("alias.case")),
 	)
-	db := postgres.New(
-		cfg.GetString("postgres.host"), cfg.GetString("postgres.port"),
-		cfg.GetString("postgres.username"), PostgresPassword,
-		cfg.GetString("postgres.dbname"), cfg.GetString("postgres.sslmode"),
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+
+	gen, err := aliasGenerator(cfg, str)
+	if err == nil {
+		err = policy.CheckGenerator(gen)
+	}
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+
+	words, err := service.NewWordsGenerator(
+		cfgInt(cfg, "alias.words.count", service.DefaultWordsCount),
+		cfgInt(cfg, "alias.words.digits", service.DefaultWordsDigits),
 	)
-	str := storage.New(db, rd)
-	srv := service.New(str, str)
+	if err == nil {
+		err = policy.CheckGenerator(words)
+	}
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+
+	srvOpts := []service.Option{
+		service.WithAliasPolicy(policy),
+		service.WithAliasGenerator(gen),
+		service.WithWordsGenerator(words),
+		service.WithDomains(
+			str,
+			cfgDuration(cfg, "domains.refresh_interval", service.DomainsRefreshInterval),
+		),
+		service.WithPublicHosts(
+			cfgInt(cfg, "routing.max_chain_depth", service.DefaultChainDepth),
+			strings.Split(cfg.GetString("routing.public_hosts"), ",")...,
+		),
+	}
+	srvOpts = append(srvOpts, service.WithRedirectsRetry(
+		cfgInt(cfg, "redirects.retry_attempts", service.DefaultRedirectsAttempts),
+		cfgDuration(cfg, "redirects.retry_backoff", service.DefaultRedirectsBackoff),
+	), service.WithRedirectsFlush(
+		cfgDuration(cfg, "redirects.flush_interval", service.DefaultRedirectsFlush),
+	))
+	if path := cfg.GetString("redirects.dead_letter_file"); path != "" {
+		srvOpts = append(srvOpts, service.WithDeadLetter(deadletter.New(path)))
+	}
+	if schemes := cfg.GetString("safety.schemes"); schemes != "" {
+		srvOpts = append(srvOpts, service.WithSchemes(strings.Split(schemes, ",")...))
+	}
+	var blocklist *safety.Blocklist
+	if path := cfg.GetString("safety.blocklist_file"); path != "" {
+		blocklist, err = safety.New(
+			path,
+			cfgDuration(cfg, "safety.reload_interval", safety.DefaultReloadInterval),
+		)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+		srvOpts = append(srvOpts, service.WithBlocklist(blocklist))
+	}
+	var geoIP *geo.GeoIP
+	if path := cfg.GetString("geoip.path"); path != "" {
+		geoIP, err = geo.New(
+			path,
+			cfgInt(cfg, "geoip.cache_size", geo.DefaultCacheSize),
+			cfgDuration(cfg, "geoip.cache_ttl", geo.DefaultCacheTTL),
+		)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+		srvOpts = append(srvOpts, service.WithGeo(geoIP))
+	}
+	var checker *health.Checker
+	if cfg.GetString("health.enabled") == "true" {
+		checker = health.New(str, health.Options{
+			Interval:     cfgDuration(cfg, "health.interval", health.DefaultInterval),
+			PollInterval: cfgDuration(cfg, "health.poll_interval", health.DefaultPollInterval),
+			BatchSize:    cfgInt(cfg, "health.batch_size", health.DefaultBatchSize),
+			Workers:      cfgInt(cfg, "health.workers", health.DefaultWorkers),
+			HostDelay:    cfgDuration(cfg, "health.host_delay", health.DefaultHostDelay),
+			Timeout:      cfgDuration(cfg, "health.timeout", health.DefaultTimeout),
+			FetchTitles:  cfg.GetString("health.fetch_titles") == "true",
+			AllowPrivate: cfg.GetString("health.allow_private") == "true",
+		})
+		checker.Run()
+		srvOpts = append(srvOpts, service.WithHealth(str))
+	}
+	srv := service.New(str, str, srvOpts...)
 
 	router := ginext.New()
 	templates(router)
-	web.SetRoutes(router, srv)
+	web.SetRoutes(router, srv, web.Options{
+		AdminToken:     AdminToken,
+		RootAliases:    cfg.GetString("routing.root_aliases") == "true",
+		RequestTimeout: cfgDuration(cfg, "timeouts.request", DefaultRequestTimeout),
+	})
 	server := &http.Server{
 		Addr:           ":" + Port,
 		Handler:        router,
@@ -123,6 +407,15 @@ func main() {
 	// start shutdown from higher layer to lower
 	// http -> service -> storage
 	server.Close()
+	if checker != nil {
+		checker.Shutdown()
+	}
 	srv.Shutdown()
 	str.Shutdown()
+	if geoIP != nil {
+		geoIP.Shutdown()
+	}
+	if blocklist != nil {
+		blocklist.Shutdown()
+	}
 }
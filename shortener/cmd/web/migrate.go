@@ -0,0 +1,60 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"path/filepath"
+	"time"
+
+	"github.com/pressly/goose/v3"
+	"github.com/wb-go/wbf/zlog"
+)
+
+// migrator storage with embedded migrations, memory one has none.
+type migrator interface {
+	Migrations() (*goose.Provider, error)
+}
+
+// migrate runs migrate subcommand: up applies all pending migrations, down
+// rolls back the last one, status prints state of every migration.
+func migrate(m migrator, cmd string, out io.Writer) error {
+	p, err := m.Migrations()
+	if err != nil {
+		return err
+	}
+	ctx := context.Background()
+
+	switch cmd {
+	case "up":
+		res, err := p.Up(ctx)
+		for _, r := range res {
+			zlog.Logger.Info().Msg("migration: " + r.String())
+		}
+		return err
+	case "down":
+		r, err := p.Down(ctx)
+		if r != nil {
+			zlog.Logger.Info().Msg("migration: " + r.String())
+		}
+		return err
+	case "status":
+		statuses, err := p.Status(ctx)
+		if err != nil {
+			return err
+		}
+		for _, s := range statuses {
+			applied := "-"
+			if !s.AppliedAt.IsZero() {
+				applied = s.AppliedAt.Format(time.DateTime)
+			}
+			fmt.Fprintf(
+				out, "%-8s %-19s %s\n",
+				s.State, applied, filepath.Base(s.Source.Path),
+			)
+		}
+		return nil
+	default:
+		return fmt.Errorf("unknown migrate command %q, expected up, down or status", cmd)
+	}
+}
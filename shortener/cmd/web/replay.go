@@ -0,0 +1,51 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"shortener/internal/deadletter"
+	"shortener/internal/entities/redirect"
+	"time"
+)
+
+// redirectsWriter storage of redirects, replay needs only db.
+type redirectsWriter interface {
+	CreateRedirects(ctx context.Context, redirects []redirect.Redirect) error
+}
+
+// replay runs replay subcommand: batches of redirects from dead letter file
+// are saved again and rolled up days which received them are aggregated
+// again.
+func replay(w redirectsWriter, path string, out io.Writer) error {
+	ctx := context.Background()
+
+	var earliest time.Time
+	n, err := deadletter.New(path).Replay(func(batch []redirect.Redirect) error {
+		err := w.CreateRedirects(ctx, batch)
+		if err != nil {
+			return err
+		}
+		for _, r := range batch {
+			if earliest.IsZero() || r.Date.Before(earliest) {
+				earliest = r.Date
+			}
+		}
+		return nil
+	})
+	fmt.Fprintf(out, "%d batches are replayed\n", n)
+	if err != nil {
+		return err
+	}
+
+	r, ok := w.(roller)
+	if !ok || earliest.IsZero() {
+		return nil
+	}
+	err = r.RebuildRollups(ctx, earliest)
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(out, "rollups are rebuilt since %s\n", earliest.Format(time.DateOnly))
+	return nil
+}
@@ -36,6 +36,12 @@ const docTemplate = `{
                         "in": "path",
                         "required": true
                     },
+                    {
+                        "type": "string",
+                        "description": "Custom domain of alias, empty for default",
+                        "name": "domain",
+                        "in": "query"
+                    },
                     {
                         "type": "string",
                         "default": "2025-01-01T00:00:00Z",
@@ -55,7 +61,7 @@ const docTemplate = `{
                     {
                         "type": "string",
                         "default": "user_agent",
-                        "description": "Column to filter by (e.g. user_agent)",
+                        "description": "Column to filter by (user_agent, utm_source, utm_medium, utm_campaign, utm_term, utm_content)",
                         "name": "filter",
                         "in": "query"
                     },
@@ -72,13 +78,580 @@ const docTemplate = `{
                         "description": "Page number",
                         "name": "page",
                         "in": "query"
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "Only total and daily clicks, without redirects",
+                        "name": "summary",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/redirect.Agrigated"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/response.Response"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/response.Response"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/response.Response"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/domains": {
+            "get": {
+                "security": [
+                    {
+                        "AdminToken": []
+                    }
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Domains"
+                ],
+                "summary": "List custom domains",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/response.Response"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "result": {
+                                            "type": "array",
+                                            "items": {
+                                                "$ref": "#/definitions/domain.Domain"
+                                            }
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/response.Response"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/response.Response"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "security": [
+                    {
+                        "AdminToken": []
+                    }
+                ],
+                "description": "Registers branded host with its own alias namespace.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Domains"
+                ],
+                "summary": "Register custom domain",
+                "parameters": [
+                    {
+                        "description": "Domain",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/request.NewDomain"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/response.Response"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "result": {
+                                            "$ref": "#/definitions/domain.Domain"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/response.Response"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/response.Response"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/response.Response"
+                        }
+                    },
+                    "503": {
+                        "description": "Service Unavailable",
+                        "schema": {
+                            "$ref": "#/definitions/response.Response"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/domains/{host}": {
+            "delete": {
+                "security": [
+                    {
+                        "AdminToken": []
+                    }
+                ],
+                "description": "Links of domain are kept, but not served until domain is registered again.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Domains"
+                ],
+                "summary": "Unregister custom domain",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Domain host",
+                        "name": "host",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/response.Response"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/response.Response"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/response.Response"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/response.Response"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/links": {
+            "get": {
+                "security": [
+                    {
+                        "AdminToken": []
+                    }
+                ],
+                "description": "Links are ordered from newest by default, filters are applied when they are set.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Links"
+                ],
+                "summary": "List and search links",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Substring of alias or original",
+                        "name": "q",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Owner",
+                        "name": "owner",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Tag",
+                        "name": "tag",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Folder",
+                        "name": "folder",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Destination health: ok, broken or unchecked",
+                        "name": "status",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "default": "created",
+                        "description": "created or clicks",
+                        "name": "sort",
+                        "in": "query"
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "Ascending order",
+                        "name": "asc",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "default": 1,
+                        "description": "Page number",
+                        "name": "page",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/response.Response"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "result": {
+                                            "type": "array",
+                                            "items": {
+                                                "$ref": "#/definitions/url.Item"
+                                            }
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/response.Response"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/response.Response"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/response.Response"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/links/broken": {
+            "get": {
+                "security": [
+                    {
+                        "AdminToken": []
+                    }
+                ],
+                "description": "Destinations are checked periodically in background, link is broken when it responded with 4xx/5xx or wasn't reachable on last check.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Links"
+                ],
+                "summary": "List links with broken destinations",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "default": 1,
+                        "description": "Page number",
+                        "name": "page",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/response.Response"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "result": {
+                                            "type": "array",
+                                            "items": {
+                                                "$ref": "#/definitions/health.Check"
+                                            }
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/response.Response"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/response.Response"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/response.Response"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/response.Response"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/links/{alias}": {
+            "patch": {
+                "security": [
+                    {
+                        "AdminToken": []
+                    }
+                ],
+                "description": "Omitted fields are left as is, tags replace all tags of link.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Links"
+                ],
+                "summary": "Update link",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Short URL alias",
+                        "name": "alias",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Custom domain of alias, empty for default",
+                        "name": "domain",
+                        "in": "query"
+                    },
+                    {
+                        "description": "Changes",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/request.UpdateLink"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/response.Response"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "result": {
+                                            "$ref": "#/definitions/url.URL"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/response.Response"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/response.Response"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/response.Response"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/response.Response"
+                        }
+                    },
+                    "503": {
+                        "description": "Service Unavailable",
+                        "schema": {
+                            "$ref": "#/definitions/response.Response"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/metrics": {
+            "get": {
+                "security": [
+                    {
+                        "AdminToken": []
+                    }
+                ],
+                "description": "Variables published with expvar: url_cache has hits, misses, negative hits and coalesced misses of link lookups with their ratios, memstats and cmdline are runtime ones.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Metrics"
+                ],
+                "summary": "Service metrics",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/response.Response"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/tags/{tag}/analytics": {
+            "get": {
+                "security": [
+                    {
+                        "AdminToken": []
+                    }
+                ],
+                "description": "Total clicks of links with tag, e.g. of campaign, and clicks of every such link.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Analytics"
+                ],
+                "summary": "Get tag analytics",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Tag",
+                        "name": "tag",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Start date, format 2006-01-02 15:04:05",
+                        "name": "start_date",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "End date, format 2006-01-02 15:04:05",
+                        "name": "end_date",
+                        "in": "query"
                     }
                 ],
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/redirect.Agrigated"
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/response.Response"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "result": {
+                                            "$ref": "#/definitions/redirect.TagStats"
+                                        }
+                                    }
+                                }
+                            ]
                         }
                     },
                     "400": {
@@ -87,6 +660,12 @@ const docTemplate = `{
                             "$ref": "#/definitions/response.Response"
                         }
                     },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/response.Response"
+                        }
+                    },
                     "404": {
                         "description": "Not Found",
                         "schema": {
@@ -104,7 +683,7 @@ const docTemplate = `{
         },
         "/s/{alias}": {
             "get": {
-                "description": "Redirects user to the original URL. On error, returns JSON.",
+                "description": "Redirects user to the original URL (or to geo target for visitor country).\nAlias is looked up in namespace of requested host when it is registered custom domain.\n/{alias} is served only when routing.root_aliases is enabled.\nQuery string is forwarded and stored UTM parameters are attached when link is configured so. On error, returns JSON.\nDestinations which became blocked after link creation get warning page instead of redirect.",
                 "tags": [
                     "URLs"
                 ],
@@ -125,6 +704,12 @@ const docTemplate = `{
                             "type": "string"
                         }
                     },
+                    "403": {
+                        "description": "Warning page, destination is blocked",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
                     "404": {
                         "description": "Not Found",
                         "schema": {
@@ -148,7 +733,7 @@ const docTemplate = `{
         },
         "/shorten": {
             "post": {
-                "description": "Creates a short alias for a given original URL.",
+                "description": "Creates a short alias for a given original URL.\nDestinations pointing at short links of this service are rejected unless routing.max_chain_depth allows them, loops are always rejected.",
                 "consumes": [
                     "application/json"
                 ],
@@ -197,15 +782,113 @@ const docTemplate = `{
                     }
                 }
             }
+        },
+        "/{alias}": {
+            "get": {
+                "description": "Redirects user to the original URL (or to geo target for visitor country).\nAlias is looked up in namespace of requested host when it is registered custom domain.\n/{alias} is served only when routing.root_aliases is enabled.\nQuery string is forwarded and stored UTM parameters are attached when link is configured so. On error, returns JSON.\nDestinations which became blocked after link creation get warning page instead of redirect.",
+                "tags": [
+                    "URLs"
+                ],
+                "summary": "Redirect by alias",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Short URL alias",
+                        "name": "alias",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "307": {
+                        "description": "Temporary redirect to original URL",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "403": {
+                        "description": "Warning page, destination is blocked",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/response.Response"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/response.Response"
+                        }
+                    },
+                    "503": {
+                        "description": "Service Unavailable",
+                        "schema": {
+                            "$ref": "#/definitions/response.Response"
+                        }
+                    }
+                }
+            }
         }
     },
     "definitions": {
+        "domain.Domain": {
+            "type": "object",
+            "properties": {
+                "created_at": {
+                    "type": "string"
+                },
+                "host": {
+                    "type": "string"
+                }
+            }
+        },
+        "health.Check": {
+            "type": "object",
+            "properties": {
+                "alias": {
+                    "type": "string"
+                },
+                "checked_at": {
+                    "type": "string"
+                },
+                "domain": {
+                    "type": "string"
+                },
+                "error": {
+                    "type": "string"
+                },
+                "latency": {
+                    "type": "integer"
+                },
+                "original": {
+                    "type": "string"
+                },
+                "status": {
+                    "description": "Status http status code, 0 when request failed",
+                    "type": "integer"
+                }
+            }
+        },
         "redirect.Agrigated": {
             "type": "object",
             "properties": {
                 "alias": {
                     "type": "string"
                 },
+                "daily": {
+                    "description": "Daily clicks of alias within dates, boundary days are counted whole and\nfilter isn't applied",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/redirect.DayClicks"
+                    }
+                },
+                "domain": {
+                    "type": "string"
+                },
                 "redirects": {
                     "type": "array",
                     "items": {
@@ -218,6 +901,42 @@ const docTemplate = `{
                 }
             }
         },
+        "redirect.DayClicks": {
+            "type": "object",
+            "properties": {
+                "clicks": {
+                    "type": "integer"
+                },
+                "day": {
+                    "type": "string"
+                },
+                "top_user_agent": {
+                    "description": "TopUserAgent and TopUTMSource most frequent values, the least one of\nequally frequent values is chosen, empty source isn't counted",
+                    "type": "string"
+                },
+                "top_utm_source": {
+                    "type": "string"
+                },
+                "uniques": {
+                    "description": "Uniques distinct user agents, visitors aren't identified otherwise",
+                    "type": "integer"
+                }
+            }
+        },
+        "redirect.LinkClicks": {
+            "type": "object",
+            "properties": {
+                "alias": {
+                    "type": "string"
+                },
+                "clicks": {
+                    "type": "integer"
+                },
+                "domain": {
+                    "type": "string"
+                }
+            }
+        },
         "redirect.Redirect": {
             "type": "object",
             "properties": {
@@ -227,12 +946,43 @@ const docTemplate = `{
                 "date": {
                     "type": "string"
                 },
+                "domain": {
+                    "type": "string"
+                },
                 "id": {
                     "type": "integer",
                     "format": "int64"
                 },
                 "userAgent": {
                     "type": "string"
+                },
+                "utm": {
+                    "$ref": "#/definitions/url.UTM"
+                }
+            }
+        },
+        "redirect.TagStats": {
+            "type": "object",
+            "properties": {
+                "links": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/redirect.LinkClicks"
+                    }
+                },
+                "tag": {
+                    "type": "string"
+                },
+                "total": {
+                    "type": "integer"
+                }
+            }
+        },
+        "request.NewDomain": {
+            "type": "object",
+            "properties": {
+                "host": {
+                    "type": "string"
                 }
             }
         },
@@ -242,8 +992,81 @@ const docTemplate = `{
                 "alias": {
                     "type": "string"
                 },
+                "alias_mode": {
+                    "description": "AliasMode how alias is generated when it's empty:\n\"\" - configured generator, \"words\" - readable one like brave-otter-42",
+                    "type": "string",
+                    "example": "words"
+                },
+                "description": {
+                    "type": "string"
+                },
+                "domain": {
+                    "description": "Domain registered custom domain, empty for default",
+                    "type": "string"
+                },
+                "folder": {
+                    "type": "string",
+                    "example": "marketing/2026"
+                },
+                "forward_query": {
+                    "description": "ForwardQuery passes visitor query string to destination",
+                    "type": "boolean"
+                },
+                "geo_targets": {
+                    "description": "GeoTargets country code -\u003e destination, e.g. {\"DE\": \"https://shop.de\"}",
+                    "type": "object",
+                    "additionalProperties": {
+                        "type": "string"
+                    }
+                },
                 "original": {
                     "type": "string"
+                },
+                "owner": {
+                    "description": "Owner identifier of link owner, e.g. team or user",
+                    "type": "string"
+                },
+                "reuse_existing": {
+                    "description": "ReuseExisting returns existing alias of owner if the same (normalized)\noriginal with the same settings was already shortened on this domain\nwith reuse_existing, edited links aren't reused",
+                    "type": "boolean"
+                },
+                "tags": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    },
+                    "example": [
+                        "spring-sale"
+                    ]
+                },
+                "title": {
+                    "type": "string",
+                    "example": "Spring sale landing"
+                },
+                "utm": {
+                    "$ref": "#/definitions/url.UTM"
+                }
+            }
+        },
+        "request.UpdateLink": {
+            "type": "object",
+            "properties": {
+                "description": {
+                    "type": "string"
+                },
+                "folder": {
+                    "description": "Folder moves link to folder, empty string moves it to root",
+                    "type": "string"
+                },
+                "tags": {
+                    "description": "Tags replace all tags of link, empty list removes them",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "title": {
+                    "type": "string"
                 }
             }
         },
@@ -258,6 +1081,172 @@ const docTemplate = `{
                     "type": "string"
                 }
             }
+        },
+        "url.Item": {
+            "type": "object",
+            "properties": {
+                "alias": {
+                    "type": "string"
+                },
+                "clicks": {
+                    "type": "integer"
+                },
+                "created_at": {
+                    "type": "string"
+                },
+                "description": {
+                    "type": "string"
+                },
+                "domain": {
+                    "description": "Domain custom host which alias belongs to, empty for default one.",
+                    "type": "string"
+                },
+                "folder": {
+                    "description": "Folder link belongs to, empty for root one.",
+                    "type": "string"
+                },
+                "forward_query": {
+                    "description": "ForwardQuery merges query string of visitor request into destination.",
+                    "type": "boolean"
+                },
+                "geo_targets": {
+                    "description": "GeoTargets maps ISO 3166-1 alpha-2 country codes to destinations,\nOriginal is used as fallback for all other countries.",
+                    "type": "object",
+                    "additionalProperties": {
+                        "type": "string"
+                    }
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "original": {
+                    "type": "string"
+                },
+                "owner": {
+                    "description": "Owner free-form identifier of whoever created the link (team, user).",
+                    "type": "string"
+                },
+                "page_title": {
+                    "description": "PageTitle title of destination page fetched by health checker.",
+                    "type": "string"
+                },
+                "tags": {
+                    "description": "Tags labels of link, e.g. campaign names.",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "title": {
+                    "type": "string"
+                },
+                "updated_at": {
+                    "type": "string"
+                },
+                "utm": {
+                    "description": "UTM parameters attached to destination on redirect.",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/url.UTM"
+                        }
+                    ]
+                }
+            }
+        },
+        "url.URL": {
+            "type": "object",
+            "properties": {
+                "alias": {
+                    "type": "string"
+                },
+                "created_at": {
+                    "type": "string"
+                },
+                "description": {
+                    "type": "string"
+                },
+                "domain": {
+                    "description": "Domain custom host which alias belongs to, empty for default one.",
+                    "type": "string"
+                },
+                "folder": {
+                    "description": "Folder link belongs to, empty for root one.",
+                    "type": "string"
+                },
+                "forward_query": {
+                    "description": "ForwardQuery merges query string of visitor request into destination.",
+                    "type": "boolean"
+                },
+                "geo_targets": {
+                    "description": "GeoTargets maps ISO 3166-1 alpha-2 country codes to destinations,\nOriginal is used as fallback for all other countries.",
+                    "type": "object",
+                    "additionalProperties": {
+                        "type": "string"
+                    }
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "original": {
+                    "type": "string"
+                },
+                "owner": {
+                    "description": "Owner free-form identifier of whoever created the link (team, user).",
+                    "type": "string"
+                },
+                "page_title": {
+                    "description": "PageTitle title of destination page fetched by health checker.",
+                    "type": "string"
+                },
+                "tags": {
+                    "description": "Tags labels of link, e.g. campaign names.",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "title": {
+                    "type": "string"
+                },
+                "updated_at": {
+                    "type": "string"
+                },
+                "utm": {
+                    "description": "UTM parameters attached to destination on redirect.",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/url.UTM"
+                        }
+                    ]
+                }
+            }
+        },
+        "url.UTM": {
+            "type": "object",
+            "properties": {
+                "campaign": {
+                    "type": "string"
+                },
+                "content": {
+                    "type": "string"
+                },
+                "medium": {
+                    "type": "string"
+                },
+                "source": {
+                    "type": "string"
+                },
+                "term": {
+                    "type": "string"
+                }
+            }
+        }
+    },
+    "securityDefinitions": {
+        "AdminToken": {
+            "type": "apiKey",
+            "name": "Authorization",
+            "in": "header"
         }
     }
 }`
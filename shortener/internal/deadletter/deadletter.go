@@ -0,0 +1,131 @@
+package deadletter
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"shortener/internal/entities/redirect"
+	"sync"
+	"time"
+)
+
+// entry line of dead letter file.
+type entry struct {
+	FailedAt  time.Time           `json:"failed_at"`
+	Error     string              `json:"error"`
+	Redirects []redirect.Redirect `json:"redirects"`
+}
+
+// File keeps batches of redirects which weren't saved to storage, every
+// batch is appended to file as JSON line, so they can be replayed later.
+type File struct {
+	path string
+	mu   *sync.Mutex
+}
+
+func New(path string) *File {
+	return &File{path: path, mu: new(sync.Mutex)}
+}
+
+// Put appends batch with cause of failure.
+func (f *File) Put(batch []redirect.Redirect, cause error) error {
+	const op = "internal.deadletter.Put"
+
+	line, err := json.Marshal(entry{
+		FailedAt: time.Now().UTC(), Error: cause.Error(), Redirects: batch,
+	})
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	// directory may be a fresh volume
+	err = os.MkdirAll(filepath.Dir(f.path), 0o755)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	file, err := os.OpenFile(f.path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o644)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	_, err = file.Write(append(line, '\n'))
+	if err != nil {
+		_ = file.Close()
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	err = file.Close()
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+// Replay passes stored batches to save, batches which fail again are put
+// back. Batches are moved to separate file first, so ones put meanwhile by
+// running service aren't lost, and file left by interrupted replay is
+// replayed next time, so batch may be saved twice. It returns number of
+// replayed batches.
+func (f *File) Replay(save func(batch []redirect.Redirect) error) (int, error) {
+	const op = "internal.deadletter.Replay"
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	replaying := f.path + ".replay"
+	if _, err := os.Stat(replaying); errors.Is(err, os.ErrNotExist) {
+		err = os.Rename(f.path, replaying)
+		if errors.Is(err, os.ErrNotExist) {
+			return 0, nil
+		} else if err != nil {
+			return 0, fmt.Errorf("%s: %w", op, err)
+		}
+	}
+
+	file, err := os.Open(replaying)
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	var failed []byte
+	replayed := 0
+	sc := bufio.NewScanner(file)
+	// batch of service is a few hundred redirects
+	sc.Buffer(nil, 64<<20)
+	for sc.Scan() {
+		// lines which can't be read are kept as they are
+		var e entry
+		if json.Unmarshal(sc.Bytes(), &e) != nil || save(e.Redirects) != nil {
+			failed = append(append(failed, sc.Bytes()...), '\n')
+			continue
+		}
+		replayed++
+	}
+	_ = file.Close()
+	if sc.Err() != nil {
+		return replayed, fmt.Errorf("%s: %w", op, sc.Err())
+	}
+
+	if len(failed) != 0 {
+		out, err := os.OpenFile(f.path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o644)
+		if err != nil {
+			return replayed, fmt.Errorf("%s: %w", op, err)
+		}
+		_, err = out.Write(failed)
+		err = errors.Join(err, out.Close())
+		if err != nil {
+			return replayed, fmt.Errorf("%s: %w", op, err)
+		}
+	}
+	err = os.Remove(replaying)
+	if err != nil {
+		return replayed, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return replayed, nil
+}
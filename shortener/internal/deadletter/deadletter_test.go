@@ -0,0 +1,87 @@
+package deadletter
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"shortener/internal/entities/redirect"
+	"testing"
+	"time"
+)
+
+func TestReplay(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "data", "dead.jsonl")
+	f := New(path)
+
+	if n, err := f.Replay(func([]redirect.Redirect) error { return nil }); n != 0 || err != nil {
+		t.Fatalf("Replay() of missing file = %d, %v", n, err)
+	}
+
+	date := time.Date(2025, 3, 1, 0, 0, 0, 0, time.UTC)
+	for _, alias := range []string{"a", "b"} {
+		err := f.Put([]redirect.Redirect{{Alias: alias, Date: date}}, errors.New("db is down"))
+		if err != nil {
+			t.Fatalf("Put() = %v", err)
+		}
+	}
+
+	var saved []string
+	n, err := f.Replay(func(batch []redirect.Redirect) error {
+		if batch[0].Alias == "b" {
+			return errors.New("still down")
+		}
+		if !batch[0].Date.Equal(date) {
+			t.Errorf("batch date = %v, want %v", batch[0].Date, date)
+		}
+		saved = append(saved, batch[0].Alias)
+		return nil
+	})
+	if n != 1 || err != nil || len(saved) != 1 || saved[0] != "a" {
+		t.Fatalf("Replay() = %d, %v, saved %v, want only a", n, err, saved)
+	}
+	if _, err := os.Stat(path + ".replay"); !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("replay file is left: %v", err)
+	}
+
+	saved = nil
+	n, err = f.Replay(func(batch []redirect.Redirect) error {
+		saved = append(saved, batch[0].Alias)
+		return nil
+	})
+	if n != 1 || err != nil || len(saved) != 1 || saved[0] != "b" {
+		t.Fatalf("second Replay() = %d, %v, saved %v, want failed b", n, err, saved)
+	}
+	if _, err := os.Stat(path); !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("file is left after replay of all batches: %v", err)
+	}
+}
+
+func TestReplayInterrupted(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "dead.jsonl")
+	f := New(path)
+
+	// left by interrupted replay, it goes before batches put after it
+	err := f.Put([]redirect.Redirect{{Alias: "old"}}, errors.New("db is down"))
+	if err != nil {
+		t.Fatalf("Put() = %v", err)
+	}
+	if err := os.Rename(path, path+".replay"); err != nil {
+		t.Fatal(err)
+	}
+	err = f.Put([]redirect.Redirect{{Alias: "new"}}, errors.New("db is down"))
+	if err != nil {
+		t.Fatalf("Put() = %v", err)
+	}
+
+	var saved []string
+	n, err := f.Replay(func(batch []redirect.Redirect) error {
+		saved = append(saved, batch[0].Alias)
+		return nil
+	})
+	if n != 1 || err != nil || len(saved) != 1 || saved[0] != "old" {
+		t.Fatalf("Replay() = %d, %v, saved %v, want old", n, err, saved)
+	}
+	if _, err := os.Stat(path); err != nil {
+		t.Errorf("new batches are lost: %v", err)
+	}
+}
@@ -0,0 +1,9 @@
+package domain
+
+import "time"
+
+// Domain branded host with its own alias namespace.
+type Domain struct {
+	Host      string    `json:"host"`
+	CreatedAt time.Time `json:"created_at"`
+}
@@ -0,0 +1,26 @@
+package health
+
+import "time"
+
+const (
+	PageSize = 20
+)
+
+// Check result of last request to link destination.
+type Check struct {
+	URLID    int64  `json:"-"`
+	Alias    string `json:"alias"`
+	Domain   string `json:"domain"`
+	Original string `json:"original"`
+
+	// Status http status code, 0 when request failed
+	Status    int           `json:"status"`
+	Latency   time.Duration `json:"latency" swaggertype:"integer"`
+	Error     string        `json:"error,omitempty"`
+	CheckedAt time.Time     `json:"checked_at"`
+}
+
+// Broken destination isn't reachable or responds with 4xx/5xx.
+func (c Check) Broken() bool {
+	return c.Status == 0 || c.Status >= 400
+}
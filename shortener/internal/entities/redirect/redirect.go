@@ -1,12 +1,18 @@
 package redirect
 
-import "time"
+import (
+	"shortener/internal/entities/url"
+	"slices"
+	"time"
+)
 
 type Redirect struct {
 	ID        int64
 	Alias     string
+	Domain    string
 	Date      time.Time
 	UserAgent string
+	UTM       url.UTM
 }
 
 const (
@@ -14,13 +20,89 @@ const (
 )
 
 var (
-	FilterUserAgent = "user_agent"
+	FilterUserAgent   = "user_agent"
+	FilterUTMSource   = "utm_source"
+	FilterUTMMedium   = "utm_medium"
+	FilterUTMCampaign = "utm_campaign"
+	FilterUTMTerm     = "utm_term"
+	FilterUTMContent  = "utm_content"
+
+	// Filters columns available for filtering analytics.
+	Filters = []string{
+		FilterUserAgent,
+		FilterUTMSource, FilterUTMMedium, FilterUTMCampaign,
+		FilterUTMTerm, FilterUTMContent,
+	}
 )
 
+func ValidFilter(column string) bool {
+	return slices.Contains(Filters, column)
+}
+
 type Agrigated struct {
 	Alias     string
+	Domain    string
 	Total     int64
 	Redirects []Redirect
+	// Daily clicks of alias within dates, boundary days are counted whole and
+	// filter isn't applied
+	Daily []DayClicks
+}
+
+// DayClicks redirects of alias during one day (UTC).
+type DayClicks struct {
+	Day    time.Time `json:"day"`
+	Clicks int64     `json:"clicks"`
+	// Uniques distinct user agents, visitors aren't identified otherwise
+	Uniques int64 `json:"uniques"`
+	// TopUserAgent and TopUTMSource most frequent values, the least one of
+	// equally frequent values is chosen, empty source isn't counted
+	TopUserAgent string `json:"top_user_agent"`
+	TopUTMSource string `json:"top_utm_source"`
+}
+
+// Daily aggregates redirects by day, days are ordered.
+func Daily(redirects []Redirect) []DayClicks {
+	type day struct {
+		clicks  int64
+		agents  map[string]int64
+		sources map[string]int64
+	}
+	days := make(map[time.Time]*day)
+	for _, r := range redirects {
+		d := r.Date.UTC().Truncate(24 * time.Hour)
+		if days[d] == nil {
+			days[d] = &day{agents: make(map[string]int64), sources: make(map[string]int64)}
+		}
+		days[d].clicks++
+		days[d].agents[r.UserAgent]++
+		if r.UTM.Source != "" {
+			days[d].sources[r.UTM.Source]++
+		}
+	}
+
+	res := make([]DayClicks, 0, len(days))
+	for d, v := range days {
+		res = append(res, DayClicks{
+			Day: d, Clicks: v.clicks, Uniques: int64(len(v.agents)),
+			TopUserAgent: top(v.agents), TopUTMSource: top(v.sources),
+		})
+	}
+	slices.SortFunc(res, func(a, b DayClicks) int {
+		return a.Day.Compare(b.Day)
+	})
+	return res
+}
+
+func top(counts map[string]int64) string {
+	var res string
+	var max int64
+	for v, c := range counts {
+		if c > max || c == max && v < res {
+			res, max = v, c
+		}
+	}
+	return res
 }
 
 // AgrigateOpts options for filtering and paginating redirect analytics.
@@ -28,6 +110,9 @@ type AgrigateOpts struct {
 	// Alias of the short URL (required, from URL path)
 	Alias string `json:"alias"`
 
+	// Domain custom domain of alias, empty for default one
+	Domain string `json:"domain" form:"domain"`
+
 	// StartDate in ISO8601 format, e.g. "2025-01-01T00:00:00Z"
 	// Required.
 	StartDate string `json:"start_date" form:"start_date" example:"2025-01-01T00:00:00Z"`
@@ -36,7 +121,7 @@ type AgrigateOpts struct {
 	// Required.
 	EndDate string `json:"end_date" form:"end_date" example:"2025-12-31T23:59:59Z"`
 
-	// FilterColumn name to filter by (e.g. "user_agent", "country")
+	// FilterColumn name to filter by (e.g. "user_agent", "utm_source")
 	FilterColumn string `json:"filter" form:"filter" example:"user_agent"`
 
 	// ValueForFilter value to match in the specified column
@@ -44,4 +129,52 @@ type AgrigateOpts struct {
 
 	// Page number for pagination (starts from 1)
 	Page int `json:"page" form:"page" example:"1" default:"1"`
+
+	// Summary returns only total and daily clicks without redirects
+	Summary bool `json:"summary" form:"summary"`
+}
+
+// DayRange returns bounds of whole days covering optional dates in
+// time.DateTime format, zero bound means there is no limit.
+func (o AgrigateOpts) DayRange() (from, to time.Time, err error) {
+	if o.StartDate != "" {
+		from, err = time.Parse(time.DateTime, o.StartDate)
+		if err != nil {
+			return
+		}
+		from = from.Truncate(24 * time.Hour)
+	}
+	if o.EndDate != "" {
+		to, err = time.Parse(time.DateTime, o.EndDate)
+		if err != nil {
+			return
+		}
+		to = to.Truncate(24 * time.Hour).Add(24 * time.Hour)
+	}
+	return
+}
+
+// TagStats clicks of all links with tag.
+type TagStats struct {
+	Tag   string       `json:"tag"`
+	Total int64        `json:"total"`
+	Links []LinkClicks `json:"links"`
+}
+
+type LinkClicks struct {
+	Alias  string `json:"alias"`
+	Domain string `json:"domain"`
+	Clicks int64  `json:"clicks"`
+}
+
+// TagOpts options of tag analytics.
+type TagOpts struct {
+	// Tag from URL path
+	Tag string `json:"tag"`
+
+	// StartDate in "2006-01-02 15:04:05" format, optional
+	StartDate string `json:"start_date" form:"start_date" example:"2025-01-01 00:00:00"`
+
+	// EndDate in "2006-01-02 15:04:05" format, optional
+	EndDate string `json:"end_date" form:"end_date" example:"2025-12-31 23:59:59"`
 }
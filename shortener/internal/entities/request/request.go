@@ -2,9 +2,39 @@ package request
 
 import "shortener/internal/entities/url"
 
+type NewDomain struct {
+	Host string `json:"host"`
+}
+
 type NewShort struct {
 	Alias    string `json:"alias"`
 	Original string `json:"original"`
+	// Domain registered custom domain, empty for default
+	Domain string `json:"domain"`
+	// Owner identifier of link owner, e.g. team or user
+	Owner string `json:"owner"`
+
+	// GeoTargets country code -> destination, e.g. {"DE": "https://shop.de"}
+	GeoTargets map[string]string `json:"geo_targets"`
+
+	// AliasMode how alias is generated when it's empty:
+	// "" - configured generator, "words" - readable one like brave-otter-42
+	AliasMode string `json:"alias_mode" example:"words"`
+
+	// ForwardQuery passes visitor query string to destination
+	ForwardQuery bool    `json:"forward_query"`
+	UTM          url.UTM `json:"utm"`
+
+	Tags   []string `json:"tags" example:"spring-sale"`
+	Folder string   `json:"folder" example:"marketing/2026"`
+
+	Title       string `json:"title" example:"Spring sale landing"`
+	Description string `json:"description"`
+
+	// ReuseExisting returns existing alias of owner if the same (normalized)
+	// original with the same settings was already shortened on this domain
+	// with reuse_existing, edited links aren't reused
+	ReuseExisting bool `json:"reuse_existing"`
 }
 
 func (ns NewShort) Validate() (url.URL, string) {
@@ -13,6 +43,40 @@ func (ns NewShort) Validate() (url.URL, string) {
 		return u, "empty original link"
 	}
 	u.Alias = ns.Alias
+	u.Domain = ns.Domain
+	u.Owner = ns.Owner
+	u.ReuseExisting = ns.ReuseExisting
+	u.AliasMode = ns.AliasMode
 	u.Original = ns.Original
+	u.GeoTargets = ns.GeoTargets
+	u.ForwardQuery = ns.ForwardQuery
+	u.UTM = ns.UTM
+	u.Tags = ns.Tags
+	u.Folder = ns.Folder
+	u.Title = ns.Title
+	u.Description = ns.Description
 	return u, ""
 }
+
+// UpdateLink changes of link, omitted fields are left as is.
+type UpdateLink struct {
+	// Tags replace all tags of link, empty list removes them
+	Tags *[]string `json:"tags"`
+	// Folder moves link to folder, empty string moves it to root
+	Folder *string `json:"folder"`
+
+	Title       *string `json:"title"`
+	Description *string `json:"description"`
+}
+
+func (ul UpdateLink) Validate() (url.Patch, string) {
+	if ul.Tags == nil && ul.Folder == nil && ul.Title == nil && ul.Description == nil {
+		return url.Patch{}, "nothing to update"
+	}
+	return url.Patch{
+		Tags:        ul.Tags,
+		Folder:      ul.Folder,
+		Title:       ul.Title,
+		Description: ul.Description,
+	}, ""
+}
@@ -1,7 +1,141 @@
 package url
 
+import (
+	parser "net/url"
+	"time"
+)
+
 type URL struct {
-	ID       int64
-	Alias    string
-	Original string
+	ID    int64  `json:"id"`
+	Alias string `json:"alias"`
+	// Domain custom host which alias belongs to, empty for default one.
+	Domain   string `json:"domain"`
+	Original string `json:"original"`
+	// Owner free-form identifier of whoever created the link (team, user).
+	Owner string `json:"owner"`
+	// OriginalHash hex sha256 of normalized Original and settings of link,
+	// it's set only for links created with ReuseExisting and dropped on
+	// update. It's unique per owner and domain.
+	OriginalHash string `json:"-"`
+
+	// GeoTargets maps ISO 3166-1 alpha-2 country codes to destinations,
+	// Original is used as fallback for all other countries.
+	GeoTargets map[string]string `json:"geo_targets"`
+
+	// ForwardQuery merges query string of visitor request into destination.
+	ForwardQuery bool `json:"forward_query"`
+	// UTM parameters attached to destination on redirect.
+	UTM UTM `json:"utm"`
+
+	// Tags labels of link, e.g. campaign names.
+	Tags []string `json:"tags"`
+	// Folder link belongs to, empty for root one.
+	Folder string `json:"folder"`
+
+	Title       string `json:"title"`
+	Description string `json:"description"`
+	// PageTitle title of destination page fetched by health checker.
+	PageTitle string    `json:"page_title"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+
+	// AliasMode generator used when Alias is empty, it isn't stored.
+	AliasMode string `json:"-"`
+	// ReuseExisting returns alias of owner's link with the same destination
+	// and settings, which was created with ReuseExisting too, instead of
+	// creating a new one, it isn't stored.
+	ReuseExisting bool `json:"-"`
+}
+
+const (
+	PageSize = 20
+)
+
+const (
+	SortCreated = "created"
+	SortClicks  = "clicks"
+
+	StatusOK        = "ok"
+	StatusBroken    = "broken"
+	StatusUnchecked = "unchecked"
+)
+
+// Filter options of links listing.
+type Filter struct {
+	Owner  string `json:"owner" form:"owner"`
+	Tag    string `json:"tag" form:"tag"`
+	Folder string `json:"folder" form:"folder"`
+	// Status of destination health check: ok, broken or unchecked
+	Status string `json:"status" form:"status" example:"broken"`
+	// Query substring of alias or original
+	Query string `json:"q" form:"q"`
+
+	// Sort created (default) or clicks
+	Sort string `json:"sort" form:"sort" example:"clicks"`
+	// Asc sorts ascending, links are sorted descending by default
+	Asc bool `json:"asc" form:"asc"`
+	// Page number for pagination (starts from 1)
+	Page int `json:"page" form:"page" example:"1" default:"1"`
+}
+
+// Item link in listing.
+type Item struct {
+	URL
+	Clicks int64 `json:"clicks"`
+}
+
+// Patch changes of link, nil fields are left as is.
+type Patch struct {
+	Tags        *[]string
+	Folder      *string
+	Title       *string
+	Description *string
+}
+
+// UTM campaign parameters.
+type UTM struct {
+	Source   string `json:"source"`
+	Medium   string `json:"medium"`
+	Campaign string `json:"campaign"`
+	Term     string `json:"term"`
+	Content  string `json:"content"`
+}
+
+// Params returns UTM as query parameters, empty values are skipped.
+func (u UTM) Params() map[string]string {
+	res := make(map[string]string, 5)
+	for k, v := range map[string]string{
+		"utm_source":   u.Source,
+		"utm_medium":   u.Medium,
+		"utm_campaign": u.Campaign,
+		"utm_term":     u.Term,
+		"utm_content":  u.Content,
+	} {
+		if v != "" {
+			res[k] = v
+		}
+	}
+	return res
+}
+
+func (u UTM) Empty() bool {
+	return u == UTM{}
+}
+
+// UTMFromQuery reads utm_* parameters from query.
+func UTMFromQuery(q parser.Values) UTM {
+	return UTM{
+		Source:   q.Get("utm_source"),
+		Medium:   q.Get("utm_medium"),
+		Campaign: q.Get("utm_campaign"),
+		Term:     q.Get("utm_term"),
+		Content:  q.Get("utm_content"),
+	}
+}
+
+// Visit describes request which follows short link.
+type Visit struct {
+	IP string
+	// Query raw query string of request
+	Query string
 }
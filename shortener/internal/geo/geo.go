@@ -0,0 +1,84 @@
+package geo
+
+import (
+	"fmt"
+	"net"
+	"time"
+
+	"github.com/hashicorp/golang-lru/v2/expirable"
+	"github.com/oschwald/maxminddb-golang"
+	"github.com/wb-go/wbf/zlog"
+)
+
+const (
+	DefaultCacheSize = 10000
+	DefaultCacheTTL  = time.Hour
+)
+
+type record struct {
+	Country struct {
+		ISOCode string `maxminddb:"iso_code"`
+	} `maxminddb:"country"`
+}
+
+// GeoIP resolves client addresses to ISO 3166-1 alpha-2 country codes
+// using a local MaxMind (GeoLite2/GeoIP2 Country or City) database.
+// Lookups are cached, so repeated visitors cost a single map access.
+type GeoIP struct {
+	db    *maxminddb.Reader
+	cache *expirable.LRU[string, string]
+}
+
+func New(path string, cacheSize int, ttl time.Duration) (*GeoIP, error) {
+	const op = "internal.geo.New"
+
+	db, err := maxminddb.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	if cacheSize <= 0 {
+		cacheSize = DefaultCacheSize
+	}
+	if ttl <= 0 {
+		ttl = DefaultCacheTTL
+	}
+
+	return &GeoIP{
+		db:    db,
+		cache: expirable.NewLRU[string, string](cacheSize, nil, ttl),
+	}, nil
+}
+
+// Country returns upper-case country code for ip or empty string if
+// it is unknown.
+func (g *GeoIP) Country(ip string) string {
+	const op = "internal.geo.Country"
+
+	if c, ok := g.cache.Get(ip); ok {
+		return c
+	}
+
+	parsed := net.ParseIP(ip)
+	if parsed == nil {
+		return ""
+	}
+
+	var r record
+	err := g.db.Lookup(parsed, &r)
+	if err != nil {
+		zlog.Logger.Error().Err(err).Msg(op)
+		return ""
+	}
+
+	g.cache.Add(ip, r.Country.ISOCode)
+	return r.Country.ISOCode
+}
+
+func (g *GeoIP) Shutdown() {
+	const op = "internal.geo.Shutdown"
+
+	err := g.db.Close()
+	if err != nil {
+		zlog.Logger.Error().AnErr("err", err).Msg(op)
+	}
+}
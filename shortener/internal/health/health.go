@@ -0,0 +1,322 @@
+package health
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	parser "net/url"
+	"shortener/internal/entities/health"
+	"shortener/internal/entities/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/wb-go/wbf/zlog"
+	"golang.org/x/net/html"
+)
+
+const (
+	DefaultInterval     = 24 * time.Hour
+	DefaultPollInterval = time.Minute
+	DefaultBatchSize    = 100
+	DefaultWorkers      = 10
+	DefaultHostDelay    = time.Second
+	DefaultTimeout      = 10 * time.Second
+
+	// MaxTitleBody how much of page is read looking for its title
+	MaxTitleBody = 256 << 10
+	MaxTitleLen  = 256
+
+	UserAgent = "shortener-health-checker/1.0"
+)
+
+type store interface {
+	// TryLockChecks takes lock of checks round shared by all instances, ok
+	// is false when another instance holds it.
+	TryLockChecks(ctx context.Context) (release func(), ok bool, err error)
+	URLsToCheck(ctx context.Context, before time.Time, limit int) ([]url.URL, error)
+	SaveCheck(ctx context.Context, c health.Check) error
+	SetPageTitle(ctx context.Context, urlID int64, title string) error
+}
+
+type Options struct {
+	// Interval how often every link is rechecked
+	Interval time.Duration
+	// PollInterval how often storage is polled for links to check
+	PollInterval time.Duration
+	// BatchSize how many links are checked per poll
+	BatchSize int
+	// Workers max number of requests in flight
+	Workers int
+	// HostDelay min delay between requests to the same host, requests to
+	// one host are never sent concurrently
+	HostDelay time.Duration
+	// Timeout of single request
+	Timeout time.Duration
+	// FetchTitles requests pages of links without title with GET and saves
+	// their <title>
+	FetchTitles bool
+	// AllowPrivate allows requests to loopback, private and link-local
+	// addresses, they are refused by default so links can't be used to
+	// reach internal services
+	AllowPrivate bool
+}
+
+func (o Options) withDefaults() Options {
+	if o.Interval <= 0 {
+		o.Interval = DefaultInterval
+	}
+	if o.PollInterval <= 0 {
+		o.PollInterval = DefaultPollInterval
+	}
+	if o.BatchSize <= 0 {
+		o.BatchSize = DefaultBatchSize
+	}
+	if o.Workers <= 0 {
+		o.Workers = DefaultWorkers
+	}
+	if o.HostDelay <= 0 {
+		o.HostDelay = DefaultHostDelay
+	}
+	if o.Timeout <= 0 {
+		o.Timeout = DefaultTimeout
+	}
+	return o
+}
+
+// Checker periodically requests destinations of stored links and records
+// status code and latency of response.
+type Checker struct {
+	store  store
+	client *http.Client
+	opts   Options
+
+	ctx    context.Context
+	cancel context.CancelFunc
+	wg     *sync.WaitGroup
+}
+
+func New(s store, opts Options) *Checker {
+	opts = opts.withDefaults()
+	ctx, cancel := context.WithCancel(context.Background())
+
+	return &Checker{
+		store:  s,
+		client: newClient(opts),
+		opts:   opts,
+		ctx:    ctx,
+		cancel: cancel,
+		wg:     new(sync.WaitGroup),
+	}
+}
+
+// Run starts checking in background until Shutdown.
+func (c *Checker) Run() {
+	c.wg.Add(1)
+	go func() {
+		defer c.wg.Done()
+
+		t := time.NewTicker(c.opts.PollInterval)
+		defer t.Stop()
+		for {
+			c.Round()
+			select {
+			case <-c.ctx.Done():
+				return
+			case <-t.C:
+			}
+		}
+	}()
+}
+
+// Round checks one batch of links which were not checked during interval.
+// Only one instance runs round at a time, so workers limit and host delay
+// hold for all of them.
+func (c *Checker) Round() {
+	const op = "internal.health.Round"
+
+	release, ok, err := c.store.TryLockChecks(c.ctx)
+	if err != nil {
+		zlog.Logger.Error().Err(err).Msg(op)
+		return
+	} else if !ok {
+		return
+	}
+	defer release()
+
+	links, err := c.store.URLsToCheck(c.ctx, time.Now().UTC().Add(-c.opts.Interval), c.opts.BatchSize)
+	if err != nil {
+		zlog.Logger.Error().Err(err).Msg(op)
+		return
+	}
+
+	// links of one host are checked sequentially with delay between them,
+	// different hosts are checked concurrently
+	byHost := make(map[string][]url.URL)
+	for _, u := range links {
+		host := hostOf(u.Original)
+		byHost[host] = append(byHost[host], u)
+	}
+
+	workers := make(chan struct{}, c.opts.Workers)
+	wg := new(sync.WaitGroup)
+	for _, hostLinks := range byHost {
+		wg.Add(1)
+		go func(hostLinks []url.URL) {
+			defer wg.Done()
+
+			for i, u := range hostLinks {
+				if i != 0 && !c.sleep(c.opts.HostDelay) {
+					return
+				}
+				select {
+				case <-c.ctx.Done():
+					return
+				case workers <- struct{}{}:
+				}
+				res, title := c.check(u, c.opts.FetchTitles && u.PageTitle == "")
+				<-workers
+				if c.ctx.Err() != nil {
+					return
+				}
+
+				err := c.store.SaveCheck(c.ctx, res)
+				if err != nil {
+					zlog.Logger.Error().Err(err).Msg(op)
+				}
+				if title != "" {
+					err = c.store.SetPageTitle(c.ctx, u.ID, title)
+					if err != nil {
+						zlog.Logger.Error().Err(err).Msg(op)
+					}
+				}
+			}
+		}(hostLinks)
+	}
+	wg.Wait()
+}
+
+// sleep returns false if checker was shut down while sleeping.
+func (c *Checker) sleep(d time.Duration) bool {
+	if d <= 0 {
+		return c.ctx.Err() == nil
+	}
+
+	t := time.NewTimer(d)
+	defer t.Stop()
+	select {
+	case <-c.ctx.Done():
+		return false
+	case <-t.C:
+		return true
+	}
+}
+
+// Check requests destination of link with HEAD, falling back to GET for
+// servers which don't support it.
+func (c *Checker) Check(u url.URL) health.Check {
+	res, _ := c.check(u, false)
+	return res
+}
+
+// check requests destination with GET when title of page is needed.
+func (c *Checker) check(u url.URL, withTitle bool) (health.Check, string) {
+	res := health.Check{
+		URLID:    u.ID,
+		Alias:    u.Alias,
+		Domain:   u.Domain,
+		Original: u.Original,
+	}
+
+	var status int
+	var title string
+	var err error
+	start := time.Now()
+	if withTitle {
+		status, title, err = c.request(http.MethodGet, u.Original, true)
+	} else {
+		status, _, err = c.request(http.MethodHead, u.Original, false)
+		if err == nil && (status == http.StatusMethodNotAllowed || status == http.StatusNotImplemented) {
+			status, _, err = c.request(http.MethodGet, u.Original, false)
+		}
+	}
+	res.Latency = time.Since(start)
+	res.CheckedAt = time.Now().UTC()
+	res.Status = status
+	if err != nil {
+		res.Status = 0
+		res.Error = err.Error()
+	}
+	if res.Broken() {
+		title = ""
+	}
+
+	return res, title
+}
+
+func (c *Checker) request(method, dst string, withTitle bool) (int, string, error) {
+	req, err := http.NewRequestWithContext(c.ctx, method, dst, nil)
+	if err != nil {
+		return 0, "", err
+	}
+	req.Header.Set("User-Agent", UserAgent)
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		var uErr *parser.Error
+		if errors.As(err, &uErr) {
+			return 0, "", fmt.Errorf("%s", uErr.Err)
+		}
+		return 0, "", err
+	}
+	defer func() {
+		_ = resp.Body.Close()
+	}()
+
+	var title string
+	if withTitle && strings.Contains(resp.Header.Get("Content-Type"), "html") {
+		title = pageTitle(io.LimitReader(resp.Body, MaxTitleBody))
+	}
+
+	return resp.StatusCode, title, nil
+}
+
+// pageTitle returns text of first <title> of html page with collapsed
+// whitespace.
+func pageTitle(r io.Reader) string {
+	z := html.NewTokenizer(r)
+	for {
+		switch z.Next() {
+		case html.ErrorToken:
+			return ""
+		case html.StartTagToken:
+			name, _ := z.TagName()
+			if string(name) != "title" {
+				continue
+			}
+			if z.Next() != html.TextToken {
+				return ""
+			}
+			title := strings.Join(strings.Fields(string(z.Text())), " ")
+			if r := []rune(title); len(r) > MaxTitleLen {
+				title = string(r[:MaxTitleLen])
+			}
+			return title
+		}
+	}
+}
+
+func (c *Checker) Shutdown() {
+	c.cancel()
+	c.wg.Wait()
+}
+
+func hostOf(dst string) string {
+	u, err := parser.Parse(dst)
+	if err != nil {
+		return ""
+	}
+	return strings.ToLower(u.Hostname())
+}
@@ -0,0 +1,293 @@
+package health
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"shortener/internal/entities/health"
+	"shortener/internal/entities/url"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+type storeMock struct {
+	links []url.URL
+	// locked round is held by another instance
+	locked bool
+
+	mu     sync.Mutex
+	checks map[int64]health.Check
+	titles map[int64]string
+}
+
+func (s *storeMock) SetPageTitle(ctx context.Context, urlID int64, title string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.titles[urlID] = title
+	return nil
+}
+
+func (s *storeMock) TryLockChecks(ctx context.Context) (func(), bool, error) {
+	return func() {}, !s.locked, nil
+}
+
+func (s *storeMock) URLsToCheck(ctx context.Context, before time.Time, limit int) ([]url.URL, error) {
+	return s.links, nil
+}
+
+func (s *storeMock) SaveCheck(ctx context.Context, c health.Check) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.checks[c.URLID] = c
+	return nil
+}
+
+func TestChecker_Round(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/ok":
+			w.WriteHeader(http.StatusOK)
+		case "/missing":
+			w.WriteHeader(http.StatusNotFound)
+		case "/error":
+			w.WriteHeader(http.StatusBadGateway)
+		case "/no-head":
+			if r.Method == http.MethodHead {
+				w.WriteHeader(http.StatusMethodNotAllowed)
+				return
+			}
+			w.WriteHeader(http.StatusOK)
+		}
+	}))
+	defer srv.Close()
+	down := httptest.NewServer(http.NotFoundHandler())
+	down.Close()
+
+	s := &storeMock{
+		links: []url.URL{
+			{ID: 1, Original: srv.URL + "/ok"},
+			{ID: 2, Original: srv.URL + "/missing"},
+			{ID: 3, Original: srv.URL + "/error"},
+			{ID: 4, Original: srv.URL + "/no-head"},
+			{ID: 5, Original: down.URL},
+		},
+		checks: make(map[int64]health.Check),
+		titles: make(map[int64]string),
+	}
+	c := New(s, Options{HostDelay: time.Millisecond, Timeout: time.Second, AllowPrivate: true})
+	c.Round()
+
+	want := map[int64]struct {
+		status int
+		broken bool
+	}{
+		1: {http.StatusOK, false},
+		2: {http.StatusNotFound, true},
+		3: {http.StatusBadGateway, true},
+		4: {http.StatusOK, false},
+		5: {0, true},
+	}
+	for id, w := range want {
+		got, ok := s.checks[id]
+		if !ok {
+			t.Errorf("link %d isn't checked", id)
+			continue
+		}
+		if got.Status != w.status || got.Broken() != w.broken {
+			t.Errorf("link %d status = %d broken = %v, want %d %v", id, got.Status, got.Broken(), w.status, w.broken)
+		}
+		if got.CheckedAt.IsZero() {
+			t.Errorf("link %d checked_at isn't set", id)
+		}
+	}
+	if s.checks[5].Error == "" {
+		t.Errorf("error of unreachable link isn't recorded")
+	}
+}
+
+func TestChecker_Politeness(t *testing.T) {
+	const delay = 20 * time.Millisecond
+
+	var inFlight, maxInFlight atomic.Int32
+	var mu sync.Mutex
+	var last time.Time
+	var minGap time.Duration = time.Hour
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := inFlight.Add(1)
+		defer inFlight.Add(-1)
+		if n > maxInFlight.Load() {
+			maxInFlight.Store(n)
+		}
+
+		mu.Lock()
+		if !last.IsZero() && time.Since(last) < minGap {
+			minGap = time.Since(last)
+		}
+		last = time.Now()
+		mu.Unlock()
+	}))
+	defer srv.Close()
+
+	s := &storeMock{checks: make(map[int64]health.Check)}
+	for i := int64(1); i <= 5; i++ {
+		s.links = append(s.links, url.URL{ID: i, Original: srv.URL})
+	}
+	c := New(s, Options{Workers: 5, HostDelay: delay, AllowPrivate: true})
+	c.Round()
+
+	if maxInFlight.Load() != 1 {
+		t.Errorf("requests to one host in flight = %d, want 1", maxInFlight.Load())
+	}
+	if minGap < delay {
+		t.Errorf("min gap between requests to one host = %v, want >= %v", minGap, delay)
+	}
+	if len(s.checks) != 5 {
+		t.Errorf("checked %d links, want 5", len(s.checks))
+	}
+}
+
+func TestChecker_Guard(t *testing.T) {
+	var requests atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+	}))
+	defer srv.Close()
+
+	s := &storeMock{
+		links:  []url.URL{{ID: 1, Original: srv.URL}},
+		checks: make(map[int64]health.Check),
+	}
+	New(s, Options{HostDelay: time.Millisecond, Timeout: time.Second}).Round()
+
+	if requests.Load() != 0 {
+		t.Errorf("loopback destination was requested")
+	}
+	if c := s.checks[1]; c.Status != 0 || !strings.Contains(c.Error, ErrForbiddenAddress.Error()) {
+		t.Errorf("check = %+v, want forbidden address error", c)
+	}
+
+	tests := []struct {
+		addr string
+		want bool
+	}{
+		{"93.184.216.34", true},
+		{"2606:2800:220:1:248:1893:25c8:1946", true},
+		{"127.0.0.1", false},
+		{"10.1.2.3", false},
+		{"172.16.0.1", false},
+		{"192.168.1.1", false},
+		{"169.254.169.254", false},
+		{"100.64.0.1", false},
+		{"0.0.0.0", false},
+		{"::1", false},
+		{"::ffff:127.0.0.1", false},
+		{"fd00:ec2::254", false},
+		{"fe80::1", false},
+	}
+	for _, tt := range tests {
+		if got := publicAddr(netip.MustParseAddr(tt.addr)); got != tt.want {
+			t.Errorf("publicAddr(%s) = %v, want %v", tt.addr, got, tt.want)
+		}
+	}
+}
+
+func TestChecker_Locked(t *testing.T) {
+	s := &storeMock{
+		links:  []url.URL{{ID: 1, Original: "http://example.com"}},
+		checks: make(map[int64]health.Check),
+		locked: true,
+	}
+	New(s, Options{}).Round()
+
+	if len(s.checks) != 0 {
+		t.Errorf("links are checked while another instance holds round")
+	}
+}
+
+func TestChecker_Shutdown(t *testing.T) {
+	s := &storeMock{checks: make(map[int64]health.Check)}
+	c := New(s, Options{PollInterval: time.Millisecond, AllowPrivate: true})
+	c.Run()
+
+	done := make(chan struct{})
+	go func() {
+		c.Shutdown()
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Errorf("Shutdown() doesn't stop checker")
+	}
+}
+
+func TestChecker_FetchTitles(t *testing.T) {
+	var heads atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodHead {
+			heads.Add(1)
+		}
+		switch r.URL.Path {
+		case "/page":
+			w.Header().Set("Content-Type", "text/html; charset=utf-8")
+			w.Write([]byte("<html><head><title>\n  Spring &amp; sale\n</title></head></html>"))
+		case "/json":
+			w.Header().Set("Content-Type", "application/json")
+			w.Write([]byte(`{"title": "no"}`))
+		case "/missing":
+			w.Header().Set("Content-Type", "text/html")
+			w.WriteHeader(http.StatusNotFound)
+			w.Write([]byte("<title>Not found</title>"))
+		}
+	}))
+	defer srv.Close()
+
+	s := &storeMock{
+		links: []url.URL{
+			{ID: 1, Original: srv.URL + "/page"},
+			{ID: 2, Original: srv.URL + "/json"},
+			{ID: 3, Original: srv.URL + "/missing"},
+			{ID: 4, Original: srv.URL + "/page", PageTitle: "known"},
+		},
+		checks: make(map[int64]health.Check),
+		titles: make(map[int64]string),
+	}
+	c := New(s, Options{HostDelay: time.Millisecond, FetchTitles: true, AllowPrivate: true})
+	c.Round()
+
+	if s.titles[1] != "Spring & sale" {
+		t.Errorf("title = %q, want %q", s.titles[1], "Spring & sale")
+	}
+	if len(s.titles) != 1 {
+		t.Errorf("titles = %v, want only title of html page", s.titles)
+	}
+	if heads.Load() != 1 {
+		t.Errorf("HEAD requests = %d, want 1 for link with known title", heads.Load())
+	}
+	if len(s.checks) != 4 {
+		t.Errorf("checked %d links, want 4", len(s.checks))
+	}
+}
+
+func TestChecker_FetchTitlesGuard(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html")
+		w.Write([]byte("<title>Internal dashboard</title>"))
+	}))
+	defer srv.Close()
+
+	s := &storeMock{
+		links:  []url.URL{{ID: 1, Original: srv.URL}},
+		checks: make(map[int64]health.Check),
+		titles: make(map[int64]string),
+	}
+	New(s, Options{HostDelay: time.Millisecond, FetchTitles: true}).Round()
+
+	if len(s.titles) != 0 {
+		t.Errorf("titles = %v, title of internal page must not be fetched", s.titles)
+	}
+}
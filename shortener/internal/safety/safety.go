@@ -0,0 +1,226 @@
+package safety
+
+import (
+	"bufio"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net"
+	parser "net/url"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/wb-go/wbf/zlog"
+)
+
+const (
+	DefaultReloadInterval = time.Minute
+
+	// MinPrefixLen and MaxPrefixLen bounds of hash prefix length in hex chars.
+	MinPrefixLen = 8
+	MaxPrefixLen = sha256.Size * 2
+)
+
+// Blocklist checks urls against local list of sha256 hash prefixes in the
+// Safe Browsing format: every line of file is hex prefix of hash of some
+// canonical host suffix/path prefix expression, e.g. "evil.example/" blocks
+// the whole domain with subdomains, "evil.example/phish/" only that path.
+// Empty lines and lines starting with # are ignored.
+//
+// File is reloaded when it's modified, so list can be updated without
+// restart.
+type Blocklist struct {
+	path string
+
+	mu       *sync.RWMutex
+	prefixes map[string]struct{}
+	lens     []int
+	modTime  time.Time
+
+	done chan struct{}
+}
+
+func New(path string, reload time.Duration) (*Blocklist, error) {
+	const op = "internal.safety.New"
+
+	b := &Blocklist{
+		path: path,
+		mu:   new(sync.RWMutex),
+		done: make(chan struct{}),
+	}
+	err := b.load()
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	if reload <= 0 {
+		reload = DefaultReloadInterval
+	}
+	go func() {
+		t := time.NewTicker(reload)
+		defer t.Stop()
+		for {
+			select {
+			case <-b.done:
+				return
+			case <-t.C:
+				b.reload()
+			}
+		}
+	}()
+
+	return b, nil
+}
+
+func (b *Blocklist) reload() {
+	const op = "internal.safety.reload"
+
+	info, err := os.Stat(b.path)
+	if err != nil {
+		zlog.Logger.Error().Err(err).Msg(op)
+		return
+	}
+	b.mu.RLock()
+	changed := !info.ModTime().Equal(b.modTime)
+	b.mu.RUnlock()
+	if !changed {
+		return
+	}
+
+	// old list is kept until file is fixed
+	err = b.load()
+	if err != nil {
+		zlog.Logger.Error().Err(err).Msg(op)
+	}
+}
+
+func (b *Blocklist) load() error {
+	f, err := os.Open(b.path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	info, err := f.Stat()
+	if err != nil {
+		return err
+	}
+
+	prefixes := make(map[string]struct{})
+	lens := make(map[int]struct{})
+	sc := bufio.NewScanner(f)
+	for n := 1; sc.Scan(); n++ {
+		line := strings.ToLower(strings.TrimSpace(sc.Text()))
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		if len(line) < MinPrefixLen || len(line) > MaxPrefixLen || len(line)%2 != 0 {
+			return fmt.Errorf("line %d: prefix must be %d-%d hex chars", n, MinPrefixLen, MaxPrefixLen)
+		}
+		if _, err := hex.DecodeString(line); err != nil {
+			return fmt.Errorf("line %d: %w", n, err)
+		}
+		prefixes[line] = struct{}{}
+		lens[len(line)] = struct{}{}
+	}
+	if err := sc.Err(); err != nil {
+		return err
+	}
+
+	l := make([]int, 0, len(lens))
+	for n := range lens {
+		l = append(l, n)
+	}
+
+	b.mu.Lock()
+	b.prefixes = prefixes
+	b.lens = l
+	b.modTime = info.ModTime()
+	b.mu.Unlock()
+
+	return nil
+}
+
+// Blocked reports whether any expression of raw url matches the list.
+func (b *Blocklist) Blocked(raw string) bool {
+	exprs := Expressions(raw)
+
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+
+	for _, e := range exprs {
+		h := Hash(e)
+		for _, l := range b.lens {
+			if _, ok := b.prefixes[h[:l]]; ok {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+func (b *Blocklist) Shutdown() {
+	close(b.done)
+}
+
+// Hash returns hex sha256 of expression, list entries are its prefixes.
+func Hash(expr string) string {
+	sum := sha256.Sum256([]byte(expr))
+	return hex.EncodeToString(sum[:])
+}
+
+// Expressions returns host suffix/path prefix expressions of url: exact
+// host and up to 4 hosts formed from last 5 components, combined with
+// exact path with and without query and up to 4 path prefixes.
+func Expressions(raw string) []string {
+	u, err := parser.Parse(strings.TrimSpace(raw))
+	if err != nil || u.Host == "" {
+		return nil
+	}
+
+	host := strings.Trim(strings.ToLower(u.Hostname()), ".")
+	path := u.EscapedPath()
+	if path == "" {
+		path = "/"
+	}
+
+	hosts := []string{host}
+	if net.ParseIP(host) == nil {
+		parts := strings.Split(host, ".")
+		if len(parts) > 5 {
+			parts = parts[len(parts)-5:]
+		}
+		for i := 0; i < len(parts)-1; i++ {
+			h := strings.Join(parts[i:], ".")
+			if h != host {
+				hosts = append(hosts, h)
+			}
+		}
+	}
+
+	paths := []string{}
+	if u.RawQuery != "" {
+		paths = append(paths, path+"?"+u.RawQuery)
+	}
+	paths = append(paths, path)
+	segments := strings.Split(strings.Trim(path, "/"), "/")
+	prefix := "/"
+	for i := 0; i < len(segments) && i < 4; i++ {
+		if prefix != path {
+			paths = append(paths, prefix)
+		}
+		prefix += segments[i] + "/"
+	}
+
+	res := make([]string, 0, len(hosts)*len(paths))
+	for _, h := range hosts {
+		for _, p := range paths {
+			res = append(res, h+p)
+		}
+	}
+
+	return res
+}
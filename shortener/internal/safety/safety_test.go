@@ -0,0 +1,107 @@
+package safety
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestExpressions(t *testing.T) {
+	got := Expressions("http://a.b.c/1/2.html?param=1")
+	want := []string{
+		"a.b.c/1/2.html?param=1", "a.b.c/1/2.html", "a.b.c/", "a.b.c/1/",
+		"b.c/1/2.html?param=1", "b.c/1/2.html", "b.c/", "b.c/1/",
+	}
+	if !slices.Equal(got, want) {
+		t.Errorf("Expressions() = %v, want %v", got, want)
+	}
+
+	got = Expressions("https://1.2.3.4:8080/")
+	if !slices.Equal(got, []string{"1.2.3.4/"}) {
+		t.Errorf("Expressions() = %v, want only ip host", got)
+	}
+
+	if got = Expressions("javascript:alert(1)"); got != nil {
+		t.Errorf("Expressions() = %v, want nil for url without host", got)
+	}
+}
+
+func writeList(t *testing.T, path string, lines ...string) {
+	t.Helper()
+	data := "# test list\n"
+	for _, l := range lines {
+		data += l + "\n"
+	}
+	err := os.WriteFile(path, []byte(data), 0644)
+	if err != nil {
+		t.Fatalf("can't write list: %v", err)
+	}
+}
+
+// touch sets modification time of file, so reload sees change even on file
+// systems with coarse timestamps.
+func touch(t *testing.T, path string, mod time.Time) {
+	t.Helper()
+	err := os.Chtimes(path, mod, mod)
+	if err != nil {
+		t.Fatalf("can't change modification time: %v", err)
+	}
+}
+
+func TestBlocklist(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "blocklist.txt")
+	writeList(t, path, Hash("evil.example/")[:8], Hash("good.example/phish/")[:16])
+	base := time.Now().Add(-time.Hour)
+	touch(t, path, base)
+
+	// reload is called explicitly, ticker never fires during test
+	b, err := New(path, time.Hour)
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	defer b.Shutdown()
+
+	tests := []struct {
+		url  string
+		want bool
+	}{
+		{"https://evil.example", true},
+		{"https://login.EVIL.example/account?id=1", true},
+		{"https://good.example/phish/login.html", true},
+		{"https://good.example/", false},
+		{"https://notevil.example/", false},
+	}
+	for _, tt := range tests {
+		if got := b.Blocked(tt.url); got != tt.want {
+			t.Errorf("Blocked(%s) = %v, want %v", tt.url, got, tt.want)
+		}
+	}
+
+	// reload picks up changes, broken file keeps previous list
+	writeList(t, path, Hash("good.example/")[:8])
+	touch(t, path, base.Add(time.Second))
+	b.reload()
+	if !b.Blocked("https://good.example/") || b.Blocked("https://evil.example/") {
+		t.Errorf("Blocked() list isn't reloaded")
+	}
+
+	writeList(t, path, "not-hex!")
+	touch(t, path, base.Add(2*time.Second))
+	b.reload()
+	if !b.Blocked("https://good.example/") {
+		t.Errorf("Blocked() broken file must keep previous list")
+	}
+}
+
+func TestNewBadFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "blocklist.txt")
+	writeList(t, path, "abc")
+	if _, err := New(path, time.Minute); err == nil {
+		t.Errorf("New() want error for short prefix")
+	}
+	if _, err := New(filepath.Join(t.TempDir(), "none"), time.Minute); err == nil {
+		t.Errorf("New() want error for missing file")
+	}
+}
@@ -0,0 +1,136 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	parser "net/url"
+	"shortener/internal/entities/url"
+	"shortener/internal/storage"
+	"strings"
+)
+
+const (
+	// DefaultChainDepth forbids destinations pointing at our own short links.
+	DefaultChainDepth = 0
+)
+
+var (
+	ErrSelfLink      = fmt.Errorf("%w: %s", ErrNotValidData, "destination is short link of this service")
+	ErrChainTooLong  = fmt.Errorf("%w: %s", ErrNotValidData, "destination redirect chain is too long")
+	ErrRedirectLoop  = fmt.Errorf("%w: %s", ErrNotValidData, "destination redirects back to this link")
+	ErrUnknownTarget = fmt.Errorf("%w: %s", ErrNotValidData, "destination is unknown short link of this service")
+)
+
+// WithPublicHosts sets hosts short links are served on, in addition to
+// registered custom domains. Destinations on them are resolved as links
+// and may pass through at most depth of them.
+func WithPublicHosts(depth int, hosts ...string) Option {
+	return func(s *Service) {
+		s.chainDepth = depth
+		s.publicHosts = make(map[string]struct{}, len(hosts))
+		for _, h := range hosts {
+			if h = normalizeHost(h); h != "" {
+				s.publicHosts[h] = struct{}{}
+			}
+		}
+	}
+}
+
+// ownLink returns namespace and alias of our short link which dst points to.
+func (s *Service) ownLink(dst string) (string, string, bool) {
+	u, err := parser.Parse(dst)
+	if err != nil {
+		return "", "", false
+	}
+
+	host := normalizeHost(u.Host)
+	_, public := s.publicHosts[host]
+	domain := s.namespace(host)
+	if !public && domain == "" {
+		return "", "", false
+	}
+
+	var alias string
+	segments := strings.Split(strings.Trim(u.Path, "/"), "/")
+	switch {
+	case len(segments) == 2 && segments[0] == "s":
+		alias = segments[1]
+	case len(segments) == 1:
+		alias = segments[0]
+	default:
+		return "", "", false
+	}
+
+	// other pages of service, e.g. /analytics or /swagger
+	alias = s.policy.Normalize(alias)
+	if s.policy.Validate(alias) != nil {
+		return "", "", false
+	}
+
+	return domain, alias, true
+}
+
+// checkChain follows destinations of link through our short links and
+// rejects loops and chains longer than configured depth.
+func (s *Service) checkChain(ctx context.Context, u url.URL) error {
+	seen := map[string]struct{}{}
+	if u.Alias != "" {
+		seen[u.Domain+"/"+u.Alias] = struct{}{}
+	}
+
+	err := s.followChain(ctx, u.Original, 0, seen)
+	if err != nil {
+		return err
+	}
+	for _, target := range u.GeoTargets {
+		err = s.followChain(ctx, target, 0, seen)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (s *Service) followChain(ctx context.Context, dst string, depth int, seen map[string]struct{}) error {
+	const op = "internal.service.url.followChain"
+
+	domain, alias, ok := s.ownLink(dst)
+	if !ok {
+		return nil
+	}
+	key := domain + "/" + alias
+	if _, ok := seen[key]; ok {
+		return ErrRedirectLoop
+	}
+	if depth >= s.chainDepth {
+		if s.chainDepth == 0 {
+			return ErrSelfLink
+		}
+		return fmt.Errorf("%w (max %d)", ErrChainTooLong, s.chainDepth)
+	}
+
+	next, err := s.urler.URL(ctx, domain, alias)
+	if errors.Is(err, storage.ErrNotFound) {
+		return ErrUnknownTarget
+	} else if err != nil {
+		return fmt.Errorf("%s: %w(%w)", op, ErrStorageInternal, err)
+	}
+
+	seen[key] = struct{}{}
+	defer delete(seen, key)
+
+	err = s.followChain(ctx, next.Original, depth+1, seen)
+	if err != nil {
+		return err
+	}
+	for _, target := range next.GeoTargets {
+		err = s.followChain(ctx, target, depth+1, seen)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
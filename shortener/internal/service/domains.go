@@ -0,0 +1,177 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"regexp"
+	"shortener/internal/entities/domain"
+	"shortener/internal/storage"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/wb-go/wbf/zlog"
+)
+
+const (
+	DomainsRefreshInterval = time.Minute
+)
+
+var hostRe = regexp.MustCompile(
+	`^[a-z0-9]([a-z0-9-]*[a-z0-9])?(\.[a-z0-9]([a-z0-9-]*[a-z0-9])?)+$`,
+)
+
+// domainsRegistry in-memory set of registered hosts, so resolving host of
+// every redirect doesn't touch storage. It's reloaded periodically to pick up
+// changes made on other instances.
+type domainsRegistry struct {
+	domainer
+
+	mu    *sync.RWMutex
+	hosts map[string]struct{}
+	done  chan struct{}
+}
+
+func newDomainsRegistry(d domainer, refresh time.Duration) *domainsRegistry {
+	r := &domainsRegistry{
+		domainer: d,
+		mu:       new(sync.RWMutex),
+		hosts:    make(map[string]struct{}),
+		done:     make(chan struct{}),
+	}
+	r.reload(context.Background())
+
+	if refresh <= 0 {
+		refresh = DomainsRefreshInterval
+	}
+	go func() {
+		t := time.NewTicker(refresh)
+		defer t.Stop()
+		for {
+			select {
+			case <-r.done:
+				return
+			case <-t.C:
+				r.reload(context.Background())
+			}
+		}
+	}()
+
+	return r
+}
+
+func (r *domainsRegistry) reload(ctx context.Context) {
+	const op = "internal.service.domains.reload"
+
+	domains, err := r.domainer.Domains(ctx)
+	if err != nil {
+		zlog.Logger.Error().Err(err).Msg(op)
+		return
+	}
+
+	hosts := make(map[string]struct{}, len(domains))
+	for _, d := range domains {
+		hosts[d.Host] = struct{}{}
+	}
+
+	r.mu.Lock()
+	r.hosts = hosts
+	r.mu.Unlock()
+}
+
+func (r *domainsRegistry) has(host string) bool {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	_, ok := r.hosts[host]
+	return ok
+}
+
+func (r *domainsRegistry) shutdown() {
+	close(r.done)
+}
+
+// normalizeHost lower cases host and strips port and trailing dot.
+func normalizeHost(host string) string {
+	host = strings.ToLower(strings.TrimSpace(host))
+	if h, _, err := net.SplitHostPort(host); err == nil {
+		host = h
+	}
+	return strings.TrimSuffix(host, ".")
+}
+
+// namespace returns domain which aliases requested on host belong to.
+// Hosts which are not registered use default namespace.
+func (s *Service) namespace(host string) string {
+	if s.domains == nil {
+		return ""
+	}
+
+	host = normalizeHost(host)
+	if s.domains.has(host) {
+		return host
+	}
+	return ""
+}
+
+func (s *Service) CreateDomain(ctx context.Context, host string) (domain.Domain, error) {
+	const op = "internal.service.domains.Create"
+
+	if s.domains == nil {
+		return domain.Domain{}, ErrDisabled
+	}
+
+	host = normalizeHost(host)
+	if !hostRe.MatchString(host) {
+		return domain.Domain{}, fmt.Errorf(
+			"%w: %s", ErrNotValidData, "host is not valid (e.g. go.example.com)",
+		)
+	}
+
+	d, err := s.domains.CreateDomain(ctx, host)
+	if errors.Is(err, storage.ErrNotUnique) {
+		return d, ErrNotUnique
+	} else if err != nil {
+		return d, fmt.Errorf("%s: %w(%w)", op, ErrStorageInternal, err)
+	}
+
+	s.domains.reload(ctx)
+	return d, nil
+}
+
+func (s *Service) Domains(ctx context.Context) ([]domain.Domain, error) {
+	const op = "internal.service.domains.Get"
+
+	if s.domains == nil {
+		return nil, ErrDisabled
+	}
+
+	res, err := s.domains.Domains(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w(%w)", op, ErrStorageInternal, err)
+	}
+
+	return res, nil
+}
+
+// DeleteDomain unregisters host. Links of domain are kept in storage, but they
+// are not reachable until domain is registered again.
+func (s *Service) DeleteDomain(ctx context.Context, host string) error {
+	const op = "internal.service.domains.Delete"
+
+	if s.domains == nil {
+		return ErrDisabled
+	}
+
+	err := s.domains.DeleteDomain(ctx, normalizeHost(host))
+	if errors.Is(err, storage.ErrNotFound) {
+		return ErrNotFound
+	} else if err != nil {
+		return fmt.Errorf("%s: %w(%w)", op, ErrStorageInternal, err)
+	}
+
+	s.domains.reload(ctx)
+	return nil
+}
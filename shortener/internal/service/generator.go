@@ -0,0 +1,248 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	"github.com/speps/go-hashids/v2"
+)
+
+const (
+	GeneratorRandom    = "random"
+	GeneratorSequence  = "sequence"
+	GeneratorHashids   = "hashids"
+	GeneratorSnowflake = "snowflake"
+
+	base62Alphabet = "0123456789abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ"
+)
+
+// AliasGenerator produces aliases for links created without custom one.
+// Generated alias still may collide with custom alias, then CreateURL asks
+// for a new one.
+type AliasGenerator interface {
+	Generate(ctx context.Context) (string, error)
+}
+
+// sequencer source of unique increasing ids, e.g. postgres sequence.
+type sequencer interface {
+	NextAliasID(ctx context.Context) (int64, error)
+}
+
+// RandomGenerator draws length cryptographically random characters from
+// alphabet.
+type RandomGenerator struct {
+	alphabet string
+	length   int
+}
+
+func NewRandomGenerator(alphabet string, length int) (*RandomGenerator, error) {
+	if alphabet == "" {
+		alphabet = DefaultAlphabet
+	}
+	if length <= 0 {
+		length = AliasLen
+	}
+	if len(alphabet) < 2 || len(alphabet) > MaxAlphabetLen {
+		return nil, fmt.Errorf(
+			"random generator: alphabet length must be in range 2-%d", MaxAlphabetLen,
+		)
+	}
+	// characters are picked by byte, repeated ones would be picked more often
+	seen := make(map[byte]struct{}, len(alphabet))
+	for i := 0; i < len(alphabet); i++ {
+		if alphabet[i] >= utf8.RuneSelf {
+			return nil, fmt.Errorf("random generator: alphabet must be ASCII")
+		}
+		if _, ok := seen[alphabet[i]]; ok {
+			return nil, fmt.Errorf("random generator: alphabet has repeated %q", alphabet[i])
+		}
+		seen[alphabet[i]] = struct{}{}
+	}
+
+	return &RandomGenerator{
+		alphabet: alphabet,
+		length:   length,
+	}, nil
+}
+
+func (g *RandomGenerator) Alphabet() string {
+	return g.alphabet
+}
+
+func (g *RandomGenerator) Generate(_ context.Context) (string, error) {
+	return generateAlias(g.alphabet, g.length), nil
+}
+
+// base62 encodes n, result is padded to minLen by offsetting n with
+// smallest minLen digits number, so encoding stays injective. Offset may
+// overflow only for ids which are never reached by sequences.
+func base62(n uint64, minLen int) string {
+	// 62^10 + any 64 bit id still fits 11 digits
+	minLen = min(minLen, 10)
+	if minLen > 1 {
+		offset := uint64(1)
+		for i := 1; i < minLen; i++ {
+			offset *= uint64(len(base62Alphabet))
+		}
+		n += offset
+	}
+
+	if n == 0 {
+		return base62Alphabet[:1]
+	}
+	var buf [11]byte
+	i := len(buf)
+	for n > 0 {
+		i--
+		buf[i] = base62Alphabet[n%uint64(len(base62Alphabet))]
+		n /= uint64(len(base62Alphabet))
+	}
+	return string(buf[i:])
+}
+
+// SequenceGenerator encodes values of storage sequence in Base62, aliases
+// never collide with each other.
+type SequenceGenerator struct {
+	seq    sequencer
+	minLen int
+}
+
+func NewSequenceGenerator(seq sequencer, minLen int) *SequenceGenerator {
+	return &SequenceGenerator{
+		seq:    seq,
+		minLen: minLen,
+	}
+}
+
+func (g *SequenceGenerator) Alphabet() string {
+	return base62Alphabet
+}
+
+func (g *SequenceGenerator) Generate(ctx context.Context) (string, error) {
+	const op = "internal.service.SequenceGenerator.Generate"
+
+	id, err := g.seq.NextAliasID(ctx)
+	if err != nil {
+		return "", fmt.Errorf("%s: %w", op, err)
+	}
+
+	return base62(uint64(id), g.minLen), nil
+}
+
+// HashidsGenerator encodes values of storage sequence with Hashids, aliases
+// don't collide with each other and don't reveal how many links exist.
+type HashidsGenerator struct {
+	seq      sequencer
+	h        *hashids.HashID
+	alphabet string
+}
+
+func NewHashidsGenerator(
+	seq sequencer, salt, alphabet string, minLen int,
+) (*HashidsGenerator, error) {
+	const op = "internal.service.NewHashidsGenerator"
+
+	hd := hashids.NewData()
+	hd.Salt = salt
+	hd.MinLength = minLen
+	if alphabet != "" {
+		hd.Alphabet = alphabet
+	}
+	h, err := hashids.NewWithData(hd)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return &HashidsGenerator{
+		seq:      seq,
+		h:        h,
+		alphabet: hd.Alphabet,
+	}, nil
+}
+
+func (g *HashidsGenerator) Alphabet() string {
+	return g.alphabet
+}
+
+func (g *HashidsGenerator) Generate(ctx context.Context) (string, error) {
+	const op = "internal.service.HashidsGenerator.Generate"
+
+	id, err := g.seq.NextAliasID(ctx)
+	if err != nil {
+		return "", fmt.Errorf("%s: %w", op, err)
+	}
+	alias, err := g.h.EncodeInt64([]int64{id})
+	if err != nil {
+		return "", fmt.Errorf("%s: %w", op, err)
+	}
+
+	return alias, nil
+}
+
+const (
+	snowflakeNodeBits = 10
+	snowflakeSeqBits  = 12
+	snowflakeMaxNode  = 1<<snowflakeNodeBits - 1
+	snowflakeMaxSeq   = 1<<snowflakeSeqBits - 1
+)
+
+// snowflakeEpoch custom epoch (2025-01-01), keeps ids short.
+var snowflakeEpoch = time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+
+// SnowflakeGenerator builds ids from milliseconds since epoch, node id and
+// per millisecond sequence, encoded in Base62. It needs no storage round trip,
+// instances must have different node ids.
+type SnowflakeGenerator struct {
+	mu   *sync.Mutex
+	node int64
+	last int64
+	seq  int64
+	now  func() time.Time
+}
+
+func NewSnowflakeGenerator(node int64) (*SnowflakeGenerator, error) {
+	if node < 0 || node > snowflakeMaxNode {
+		return nil, fmt.Errorf(
+			"snowflake generator: node id must be in range 0-%d", snowflakeMaxNode,
+		)
+	}
+
+	return &SnowflakeGenerator{
+		mu:   new(sync.Mutex),
+		node: node,
+		now:  time.Now,
+	}, nil
+}
+
+func (g *SnowflakeGenerator) Alphabet() string {
+	return base62Alphabet
+}
+
+func (g *SnowflakeGenerator) Generate(_ context.Context) (string, error) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	ms := g.now().Sub(snowflakeEpoch).Milliseconds()
+	// clock went backwards, keep using last timestamp
+	if ms < g.last {
+		ms = g.last
+	}
+	if ms == g.last {
+		g.seq = (g.seq + 1) & snowflakeMaxSeq
+		if g.seq == 0 {
+			// sequence exhausted, borrow next millisecond
+			ms++
+		}
+	} else {
+		g.seq = 0
+	}
+	g.last = ms
+
+	id := ms<<(snowflakeNodeBits+snowflakeSeqBits) |
+		g.node<<snowflakeSeqBits |
+		g.seq
+	return base62(uint64(id), 0), nil
+}
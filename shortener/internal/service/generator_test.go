@@ -0,0 +1,259 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"regexp"
+	"shortener/internal/entities/url"
+	"shortener/internal/storage"
+	"slices"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"testing/quick"
+	"time"
+)
+
+type sequencerMock struct {
+	n atomic.Int64
+}
+
+func (sm *sequencerMock) NextAliasID(ctx context.Context) (int64, error) {
+	return sm.n.Add(1), nil
+}
+
+// checkUnique generates n aliases in workers goroutines and fails on
+// duplicate, not allowed character or too short alias.
+func checkUnique(
+	t *testing.T, g AliasGenerator, n, workers int, alphabet string, minLen int,
+) {
+	t.Helper()
+
+	var mu sync.Mutex
+	seen := make(map[string]struct{}, n)
+	wg := sync.WaitGroup{}
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := 0; i < n/workers; i++ {
+				alias, err := g.Generate(t.Context())
+				if err != nil {
+					t.Errorf("Generate() error = %v", err)
+					return
+				}
+				if len(alias) < minLen {
+					t.Errorf("Generate() = %q, shorter than %d", alias, minLen)
+				}
+				if strings.Trim(alias, alphabet) != "" {
+					t.Errorf("Generate() = %q, not in alphabet %q", alias, alphabet)
+				}
+
+				mu.Lock()
+				if _, ok := seen[alias]; ok {
+					t.Errorf("Generate() duplicate %q", alias)
+				}
+				seen[alias] = struct{}{}
+				mu.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+}
+
+func TestBase62(t *testing.T) {
+	injective := func(a, b uint32, minLen uint8) bool {
+		l := int(minLen % 8)
+		return a == b || base62(uint64(a), l) != base62(uint64(b), l)
+	}
+	if err := quick.Check(injective, nil); err != nil {
+		t.Error(err)
+	}
+
+	padded := func(n uint32, minLen uint8) bool {
+		l := int(minLen % 8)
+		res := base62(uint64(n), l)
+		return len(res) >= l && strings.Trim(res, base62Alphabet) == ""
+	}
+	if err := quick.Check(padded, nil); err != nil {
+		t.Error(err)
+	}
+
+	if got := base62(^uint64(0), 0); len(got) != 11 {
+		t.Errorf("base62(max) = %q", got)
+	}
+}
+
+func TestRandomGenerator(t *testing.T) {
+	g, err := NewRandomGenerator("abcdef0123456789", 16)
+	if err != nil {
+		t.Fatalf("NewRandomGenerator() error = %v", err)
+	}
+	checkUnique(t, g, 20000, 1, "abcdef0123456789", 16)
+
+	_, err = NewRandomGenerator("a", 6)
+	if err == nil {
+		t.Errorf("NewRandomGenerator() accepted one letter alphabet")
+	}
+	_, err = NewRandomGenerator("abcdéf", 6)
+	if err == nil {
+		t.Errorf("NewRandomGenerator() accepted non-ASCII alphabet")
+	}
+	_, err = NewRandomGenerator("abca", 6)
+	if err == nil {
+		t.Errorf("NewRandomGenerator() accepted alphabet with repeated character")
+	}
+}
+
+func TestSequenceGenerator(t *testing.T) {
+	g := NewSequenceGenerator(&sequencerMock{}, 4)
+	checkUnique(t, g, 100000, 8, base62Alphabet, 4)
+}
+
+func TestHashidsGenerator(t *testing.T) {
+	alphabet := "abcdefghijklmnopqrstuvwxyz0123456789"
+	g, err := NewHashidsGenerator(&sequencerMock{}, "salt", alphabet, 5)
+	if err != nil {
+		t.Fatalf("NewHashidsGenerator() error = %v", err)
+	}
+	checkUnique(t, g, 100000, 8, alphabet, 5)
+
+	other, _ := NewHashidsGenerator(&sequencerMock{}, "pepper", alphabet, 5)
+	a, _ := NewHashidsGenerator(&sequencerMock{}, "salt", alphabet, 5)
+	x, _ := a.Generate(t.Context())
+	y, _ := other.Generate(t.Context())
+	if x == y {
+		t.Errorf("Generate() salt doesn't change aliases: %q", x)
+	}
+}
+
+func TestSnowflakeGenerator(t *testing.T) {
+	g, err := NewSnowflakeGenerator(1)
+	if err != nil {
+		t.Fatalf("NewSnowflakeGenerator() error = %v", err)
+	}
+	checkUnique(t, g, 200000, 8, base62Alphabet, 1)
+
+	// clock going backwards must not produce duplicates
+	now := time.Now()
+	g.now = func() time.Time { return now }
+	first, _ := g.Generate(t.Context())
+	g.now = func() time.Time { return now.Add(-time.Second) }
+	second, _ := g.Generate(t.Context())
+	if first == second {
+		t.Errorf("Generate() duplicate after clock moved backwards: %q", first)
+	}
+
+	// nodes generate different aliases at the same moment
+	a, _ := NewSnowflakeGenerator(2)
+	b, _ := NewSnowflakeGenerator(3)
+	a.now = func() time.Time { return now }
+	b.now = func() time.Time { return now }
+	x, _ := a.Generate(t.Context())
+	y, _ := b.Generate(t.Context())
+	if x == y {
+		t.Errorf("Generate() same alias on different nodes: %q", x)
+	}
+
+	_, err = NewSnowflakeGenerator(snowflakeMaxNode + 1)
+	if err == nil {
+		t.Errorf("NewSnowflakeGenerator() accepted node out of range")
+	}
+}
+
+type generatorMock func() (string, error)
+
+func (gm generatorMock) Generate(ctx context.Context) (string, error) {
+	return gm()
+}
+
+func TestService_CreateURLGenerator(t *testing.T) {
+	created := 0
+	s := New(&UrlerMock{
+		createF: func(u url.URL) (string, error) {
+			created++
+			return "", storage.ErrNotUnique
+		},
+	}, nil, WithAliasGenerator(generatorMock(func() (string, error) {
+		return "abcdef", nil
+	})))
+
+	_, err := s.CreateURL(t.Context(), url.URL{Original: "http://google.com"})
+	if !errors.Is(err, ErrNotUnique) || created != GenerateAttempts {
+		t.Errorf(
+			"Service.CreateURL() error = %v after %d attempts, want %v",
+			err, created, ErrNotUnique,
+		)
+	}
+
+	s = New(&UrlerMock{}, nil, WithAliasGenerator(generatorMock(func() (string, error) {
+		return "", errors.New("sequence is broken")
+	})))
+	_, err = s.CreateURL(t.Context(), url.URL{Original: "http://google.com"})
+	if !errors.Is(err, ErrGenerator) {
+		t.Errorf("Service.CreateURL() error = %v, want %v", err, ErrGenerator)
+	}
+}
+
+func TestWordsGenerator(t *testing.T) {
+	g, err := NewWordsGenerator(3, 2)
+	if err != nil {
+		t.Fatalf("NewWordsGenerator() error = %v", err)
+	}
+	re := regexp.MustCompile(`^[a-z]+-[a-z]+-[a-z]+-[2-9]{2}$`)
+	p := DefaultAliasPolicy()
+	for i := 0; i < 1000; i++ {
+		alias, err := g.Generate(t.Context())
+		if err != nil {
+			t.Fatalf("Generate() error = %v", err)
+		}
+		if !re.MatchString(alias) {
+			t.Errorf("Generate() = %q, want adjective-adjective-noun-digits", alias)
+		}
+		if err := p.Validate(alias); err != nil {
+			t.Errorf("Generate() = %q rejected by default policy: %v", alias, err)
+		}
+	}
+
+	g, _ = NewWordsGenerator(1, 0)
+	alias, _ := g.Generate(t.Context())
+	if !slices.Contains(g.nouns, alias) {
+		t.Errorf("Generate() = %q, want single noun", alias)
+	}
+
+	for _, w := range append(g.adjectives, g.nouns...) {
+		if !regexp.MustCompile(`^[a-z]{3,8}$`).MatchString(w) {
+			t.Errorf("word %q must be 3-8 lower case latin letters", w)
+		}
+	}
+
+	if _, err := NewWordsGenerator(0, 2); err == nil {
+		t.Errorf("NewWordsGenerator() accepted zero words")
+	}
+}
+
+func TestService_CreateURLAliasMode(t *testing.T) {
+	var created string
+	s := New(&UrlerMock{
+		createF: func(u url.URL) (string, error) {
+			created = u.Alias
+			return u.Alias, nil
+		},
+	}, nil, WithAliasGenerator(generatorMock(func() (string, error) {
+		return "random", nil
+	})))
+
+	_, err := s.CreateURL(t.Context(), url.URL{Original: "http://google.com"})
+	if err != nil || created != "random" {
+		t.Errorf("Service.CreateURL() alias = %q, error = %v", created, err)
+	}
+	_, err = s.CreateURL(t.Context(), url.URL{Original: "http://google.com", AliasMode: AliasModeWords})
+	if err != nil || strings.Count(created, "-") != 2 {
+		t.Errorf("Service.CreateURL() alias = %q, error = %v, want words", created, err)
+	}
+	_, err = s.CreateURL(t.Context(), url.URL{Original: "http://google.com", AliasMode: "emoji"})
+	if !errors.Is(err, ErrNotValidData) {
+		t.Errorf("Service.CreateURL() error = %v, want %v", err, ErrNotValidData)
+	}
+}
@@ -0,0 +1,52 @@
+package service
+
+import (
+	"fmt"
+	"shortener/internal/entities/url"
+	"strings"
+)
+
+func validateGeoTargets(targets map[string]string) (map[string]string, error) {
+	if len(targets) == 0 {
+		return nil, nil
+	}
+
+	res := make(map[string]string, len(targets))
+	for country, target := range targets {
+		country = strings.ToUpper(strings.TrimSpace(country))
+		if len(country) != 2 || !isLetters(country) {
+			return nil, fmt.Errorf(
+				"%w: %s", ErrNotValidData,
+				"geo target country must be ISO 3166-1 alpha-2 code",
+			)
+		}
+		if !validOriginal(target) {
+			return nil, fmt.Errorf(
+				"%w: %s", ErrNotValidData,
+				"geo target url is not valid (scheme://host/path)",
+			)
+		}
+		res[country] = target
+	}
+
+	return res, nil
+}
+
+func isLetters(s string) bool {
+	for _, r := range s {
+		if r < 'A' || r > 'Z' {
+			return false
+		}
+	}
+	return true
+}
+
+// geoTarget returns destination for visitor country or empty string when
+// link has no rule for it.
+func (s *Service) geoTarget(u url.URL, v url.Visit) string {
+	if len(u.GeoTargets) == 0 || s.geo == nil || v.IP == "" {
+		return ""
+	}
+
+	return u.GeoTargets[s.geo.Country(v.IP)]
+}
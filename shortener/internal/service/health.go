@@ -0,0 +1,27 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"shortener/internal/entities/health"
+)
+
+// BrokenLinks returns links which destination responded with 4xx/5xx or
+// wasn't reachable on last check.
+func (s *Service) BrokenLinks(ctx context.Context, page int) ([]health.Check, error) {
+	const op = "internal.service.health.Broken"
+
+	if s.health == nil {
+		return nil, ErrDisabled
+	}
+	if page < 0 {
+		return nil, fmt.Errorf("%w: %s", ErrNotValidData, "page must be positive")
+	}
+
+	res, err := s.health.BrokenLinks(ctx, page)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w(%w)", op, ErrStorageInternal, err)
+	}
+
+	return res, nil
+}
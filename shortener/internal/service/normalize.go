@@ -0,0 +1,65 @@
+package service
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"net"
+	parser "net/url"
+	"shortener/internal/entities/url"
+	"strings"
+)
+
+var defaultPorts = map[string]string{
+	"http":  "80",
+	"https": "443",
+}
+
+// normalizeOriginal brings equivalent destinations to the same form:
+// lower-case scheme and host, no default port, no trailing slash and
+// query parameters sorted by key.
+func normalizeOriginal(s string) (string, error) {
+	u, err := parser.Parse(s)
+	if err != nil {
+		return "", err
+	}
+
+	u.Scheme = strings.ToLower(u.Scheme)
+	u.Host = strings.ToLower(u.Host)
+	if host, port, err := net.SplitHostPort(u.Host); err == nil &&
+		port == defaultPorts[u.Scheme] {
+		if strings.Contains(host, ":") {
+			host = "[" + host + "]"
+		}
+		u.Host = host
+	}
+
+	u.Path = strings.TrimRight(u.Path, "/")
+	u.RawPath = strings.TrimRight(u.RawPath, "/")
+	u.RawQuery = u.Query().Encode()
+	u.ForceQuery = false
+
+	return u.String(), nil
+}
+
+// reuseHash returns hex sha256 of normalized original together with
+// settings of link, so link is reused only by requests which would create
+// the same link.
+func reuseHash(normalized string, u url.URL) string {
+	// map keys are sorted by json, tags are already sorted
+	b, _ := json.Marshal(struct {
+		Original     string            `json:"original"`
+		GeoTargets   map[string]string `json:"geo_targets"`
+		ForwardQuery bool              `json:"forward_query"`
+		UTM          url.UTM           `json:"utm"`
+		Tags         []string          `json:"tags"`
+		Folder       string            `json:"folder"`
+		Title        string            `json:"title"`
+		Description  string            `json:"description"`
+	}{
+		normalized, u.GeoTargets, u.ForwardQuery, u.UTM, u.Tags,
+		u.Folder, u.Title, u.Description,
+	})
+	sum := sha256.Sum256(b)
+	return hex.EncodeToString(sum[:])
+}
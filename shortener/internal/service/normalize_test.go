@@ -0,0 +1,121 @@
+package service
+
+import (
+	"errors"
+	"shortener/internal/entities/url"
+	"shortener/internal/storage"
+	"testing"
+)
+
+func TestNormalizeOriginal(t *testing.T) {
+	tests := []struct {
+		name string
+		in   string
+		want string
+	}{
+		{"case", "HTTP://Example.COM/Path", "http://example.com/Path"},
+		{"default http port", "http://example.com:80/a", "http://example.com/a"},
+		{"default https port", "https://example.com:443/a", "https://example.com/a"},
+		{"other port", "https://example.com:80/a", "https://example.com:80/a"},
+		{"trailing slash", "https://example.com/a/", "https://example.com/a"},
+		{"root", "https://example.com/", "https://example.com"},
+		{"sorted query", "https://example.com/?b=2&a=1", "https://example.com?a=1&b=2"},
+		{"ipv6", "http://[::1]:80/", "http://[::1]"},
+		{"fragment kept", "https://example.com/a#top", "https://example.com/a#top"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := normalizeOriginal(tt.in)
+			if err != nil {
+				t.Fatalf("normalizeOriginal() error = %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("normalizeOriginal() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestService_CreateURLReuseExisting(t *testing.T) {
+	created := 0
+	links := map[string]url.URL{}
+	um := &UrlerMock{
+		createF: func(u url.URL) (string, error) {
+			created++
+			links[u.Owner+" "+u.OriginalHash] = u
+			return u.Alias, nil
+		},
+		hashF: func(owner, domain, hash string) (url.URL, error) {
+			u, ok := links[owner+" "+hash]
+			if !ok {
+				return url.URL{}, storage.ErrNotFound
+			}
+			return u, nil
+		},
+	}
+	s := New(um, nil)
+
+	first, err := s.CreateURL(t.Context(), url.URL{
+		Original: "https://example.com/a?b=2&a=1", Owner: "team", ReuseExisting: true,
+	})
+	if err != nil {
+		t.Fatalf("Service.CreateURL() error = %v", err)
+	}
+
+	second, err := s.CreateURL(t.Context(), url.URL{
+		Original: "HTTPS://EXAMPLE.com:443/a/?a=1&b=2", Owner: "team", ReuseExisting: true,
+	})
+	if err != nil {
+		t.Fatalf("Service.CreateURL() error = %v", err)
+	}
+	if second != first || created != 1 {
+		t.Errorf("Service.CreateURL() = %v, want reused %v", second, first)
+	}
+
+	other, err := s.CreateURL(t.Context(), url.URL{
+		Original: "https://example.com/a?a=1&b=2", Owner: "other", ReuseExisting: true,
+	})
+	if err != nil {
+		t.Fatalf("Service.CreateURL() error = %v", err)
+	}
+	if other == first {
+		t.Errorf("Service.CreateURL() reused link of another owner")
+	}
+
+	_, err = s.CreateURL(t.Context(), url.URL{
+		Original: "https://example.com/a?a=1&b=2", Owner: "team",
+	})
+	if err != nil || created != 3 {
+		t.Errorf("Service.CreateURL() without reuse_existing must create link")
+	}
+
+	tagged, err := s.CreateURL(t.Context(), url.URL{
+		Original: "https://example.com/a?a=1&b=2", Owner: "team", ReuseExisting: true,
+		Tags: []string{"promo"},
+	})
+	if err != nil || tagged == first || created != 4 {
+		t.Errorf("Service.CreateURL() = %v, %v, link with other settings must be created", tagged, err)
+	}
+
+	// link created concurrently by another request is returned
+	um.createF = func(u url.URL) (string, error) {
+		links[u.Owner+" "+u.OriginalHash] = url.URL{Alias: "concurrent"}
+		return "", storage.ErrNotUnique
+	}
+	got, err := s.CreateURL(t.Context(), url.URL{
+		Original: "https://example.com/race", Owner: "team", ReuseExisting: true,
+	})
+	if err != nil || got != "concurrent" {
+		t.Errorf("Service.CreateURL() = %v, %v, want concurrently created link", got, err)
+	}
+
+	um.hashF = func(owner, domain, hash string) (url.URL, error) {
+		return url.URL{}, errors.New("db is down")
+	}
+	_, err = s.CreateURL(t.Context(), url.URL{
+		Original: "https://example.com", ReuseExisting: true,
+	})
+	if !errors.Is(err, ErrStorageInternal) {
+		t.Errorf("Service.CreateURL() error = %v, want %v", err, ErrStorageInternal)
+	}
+}
@@ -0,0 +1,214 @@
+package service
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"regexp"
+	"slices"
+	"strings"
+)
+
+type CaseFold string
+
+const (
+	// CasePreserve aliases are case sensitive and stored as given
+	CasePreserve CaseFold = "preserve"
+	// CaseLower aliases are lower cased on creation and lookup
+	CaseLower CaseFold = "lower"
+
+	DefaultAliasMinLen  = 3
+	DefaultAliasMaxLen  = 64
+	DefaultAliasCharset = `^[A-Za-z0-9_-]+$`
+)
+
+// ReservedAliases first path segments of service routes. Links can be served
+// from root path (/:alias), so these words are never accepted as aliases.
+var ReservedAliases = []string{
+	"s", "shorten", "analytics", "api", "swagger", "static",
+	"favicon.ico", "robots.txt",
+}
+
+var (
+	ErrAliasEmpty     = fmt.Errorf("%w: %s", ErrNotValidData, "alias is empty")
+	ErrAliasLength    = fmt.Errorf("%w: %s", ErrNotValidData, "alias length is out of range")
+	ErrAliasCharset   = fmt.Errorf("%w: %s", ErrNotValidData, "alias contains not allowed characters")
+	ErrAliasReserved  = fmt.Errorf("%w: %s", ErrNotValidData, "alias is reserved")
+	ErrAliasProfanity = fmt.Errorf("%w: %s", ErrNotValidData, "alias contains blocked word")
+)
+
+// leet maps look-alike characters to letters they imitate, so blocked words
+// are found in aliases like "b4d-w0rd". Only whole alias is compared after
+// folding, folded substrings match too many innocent aliases.
+var leet = strings.NewReplacer(
+	"0", "o", "1", "i", "3", "e", "4", "a", "5", "s", "7", "t",
+	"@", "a", "$", "s", "-", "", "_", "",
+)
+
+// AliasPolicy rules which both user supplied and generated aliases must
+// satisfy.
+type AliasPolicy struct {
+	MinLen  int
+	MaxLen  int
+	Charset *regexp.Regexp
+	// Reserved words in addition to ReservedAliases
+	Reserved []string
+	// Blocklist words which must not appear anywhere in alias, or be whole
+	// alias written with look-alike characters
+	Blocklist []string
+	Fold      CaseFold
+}
+
+func DefaultAliasPolicy() AliasPolicy {
+	return AliasPolicy{
+		MinLen:  DefaultAliasMinLen,
+		MaxLen:  DefaultAliasMaxLen,
+		Charset: regexp.MustCompile(DefaultAliasCharset),
+		Fold:    CasePreserve,
+	}
+}
+
+// NewAliasPolicy creates policy, blocklist is read from file with one word
+// per line, empty lines and lines started with # are skipped.
+func NewAliasPolicy(
+	minLen, maxLen int, charset string, reserved []string,
+	blocklistPath string, fold CaseFold,
+) (AliasPolicy, error) {
+	const op = "internal.service.NewAliasPolicy"
+
+	p := DefaultAliasPolicy()
+	if minLen > 0 {
+		p.MinLen = minLen
+	}
+	if maxLen > 0 {
+		p.MaxLen = maxLen
+	}
+	if p.MinLen > p.MaxLen {
+		return p, fmt.Errorf("%s: min length is greater than max length", op)
+	}
+	if charset != "" {
+		re, err := regexp.Compile(charset)
+		if err != nil {
+			return p, fmt.Errorf("%s: %w", op, err)
+		}
+		p.Charset = re
+	}
+	switch fold {
+	case "":
+	case CasePreserve, CaseLower:
+		p.Fold = fold
+	default:
+		return p, fmt.Errorf("%s: unknown case folding %q", op, fold)
+	}
+	for _, r := range reserved {
+		if r = strings.TrimSpace(r); r != "" {
+			p.Reserved = append(p.Reserved, r)
+		}
+	}
+	if blocklistPath != "" {
+		words, err := readWordlist(blocklistPath)
+		if err != nil {
+			return p, fmt.Errorf("%s: %w", op, err)
+		}
+		p.Blocklist = words
+	}
+
+	return p, nil
+}
+
+func readWordlist(path string) ([]string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		_ = f.Close()
+	}()
+
+	var res []string
+	sc := bufio.NewScanner(f)
+	for sc.Scan() {
+		w := strings.ToLower(strings.TrimSpace(sc.Text()))
+		if w == "" || strings.HasPrefix(w, "#") {
+			continue
+		}
+		res = append(res, w)
+	}
+
+	return res, sc.Err()
+}
+
+// Normalize applies case folding rules to alias.
+func (p AliasPolicy) Normalize(alias string) string {
+	alias = strings.TrimSpace(alias)
+	if p.Fold == CaseLower {
+		alias = strings.ToLower(alias)
+	}
+	return alias
+}
+
+// Validate checks normalized alias against policy.
+func (p AliasPolicy) Validate(alias string) error {
+	if alias == "" {
+		return ErrAliasEmpty
+	}
+	if l := len([]rune(alias)); l < p.MinLen || l > p.MaxLen {
+		return fmt.Errorf(
+			"%w (%d-%d)", ErrAliasLength, p.MinLen, p.MaxLen,
+		)
+	}
+	if p.Charset != nil && !p.Charset.MatchString(alias) {
+		return ErrAliasCharset
+	}
+
+	reserved := func(r string) bool {
+		return strings.EqualFold(r, alias)
+	}
+	if slices.ContainsFunc(ReservedAliases, reserved) ||
+		slices.ContainsFunc(p.Reserved, reserved) {
+		return ErrAliasReserved
+	}
+
+	lower := strings.ToLower(alias)
+	folded := leet.Replace(lower)
+	for _, w := range p.Blocklist {
+		if strings.Contains(lower, w) || folded == leet.Replace(w) {
+			return ErrAliasProfanity
+		}
+	}
+
+	return nil
+}
+
+// alphabeter generator which draws aliases from known set of characters.
+type alphabeter interface {
+	Alphabet() string
+}
+
+// CheckGenerator reports on start generators which can't satisfy policy:
+// every character of their alphabet must be allowed by charset on its own,
+// and lower case folding must not merge their aliases, which would break
+// collision-free sequence based generators.
+func (p AliasPolicy) CheckGenerator(g AliasGenerator) error {
+	const op = "internal.service.AliasPolicy.CheckGenerator"
+
+	a, ok := g.(alphabeter)
+	if !ok {
+		return nil
+	}
+	alphabet := a.Alphabet()
+	for _, r := range alphabet {
+		if p.Charset != nil && !p.Charset.MatchString(string(r)) {
+			return fmt.Errorf(
+				"%s: charset doesn't allow %q of generator alphabet", op, r,
+			)
+		}
+	}
+	if p.Fold == CaseLower && strings.ToLower(alphabet) != alphabet {
+		return fmt.Errorf(
+			"%s: lower case folding merges aliases of generator with upper case alphabet", op,
+		)
+	}
+
+	return nil
+}
@@ -0,0 +1,143 @@
+package service
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"shortener/internal/entities/url"
+	"testing"
+)
+
+func TestAliasPolicy_Validate(t *testing.T) {
+	blocklist := filepath.Join(t.TempDir(), "blocklist.txt")
+	err := os.WriteFile(blocklist, []byte("# test words\nbadword\n\nevil\n"), 0644)
+	if err != nil {
+		t.Fatalf("can't create blocklist: %v", err)
+	}
+
+	p, err := NewAliasPolicy(
+		4, 10, "", []string{"admin", " "}, blocklist, CasePreserve,
+	)
+	if err != nil {
+		t.Fatalf("NewAliasPolicy() error = %v", err)
+	}
+
+	tests := []struct {
+		name  string
+		alias string
+		want  error
+	}{
+		{name: "good", alias: "my-link_1", want: nil},
+		{name: "empty", alias: "", want: ErrAliasEmpty},
+		{name: "too short", alias: "abc", want: ErrAliasLength},
+		{name: "too long", alias: "abcdefghijk", want: ErrAliasLength},
+		{name: "slash", alias: "abc/def", want: ErrAliasCharset},
+		{name: "space", alias: "abc def", want: ErrAliasCharset},
+		{name: "cyrillic look-alike", alias: "аdmin", want: ErrAliasCharset},
+		{name: "route reserved", alias: "Static", want: ErrAliasReserved},
+		{name: "configured reserved", alias: "ADMIN", want: ErrAliasReserved},
+		{name: "blocked word", alias: "xxBadWord", want: ErrAliasProfanity},
+		{name: "blocked leet word", alias: "b4d-w0rd", want: ErrAliasProfanity},
+		{name: "blocked short word", alias: "3v1l", want: ErrAliasProfanity},
+		{name: "look-alike inside word", alias: "le-v1llage", want: nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := p.Validate(p.Normalize(tt.alias))
+			if !errors.Is(err, tt.want) {
+				t.Errorf("AliasPolicy.Validate() error = %v, want %v", err, tt.want)
+			}
+			if tt.want != nil && !errors.Is(err, ErrNotValidData) {
+				t.Errorf("AliasPolicy.Validate() error = %v is not ErrNotValidData", err)
+			}
+		})
+	}
+}
+
+func TestNewAliasPolicy(t *testing.T) {
+	_, err := NewAliasPolicy(10, 4, "", nil, "", "")
+	if err == nil {
+		t.Errorf("NewAliasPolicy() accepted min length greater than max")
+	}
+	_, err = NewAliasPolicy(0, 0, "[", nil, "", "")
+	if err == nil {
+		t.Errorf("NewAliasPolicy() accepted not valid charset")
+	}
+	_, err = NewAliasPolicy(0, 0, "", nil, "", "upper")
+	if err == nil {
+		t.Errorf("NewAliasPolicy() accepted unknown case folding")
+	}
+	_, err = NewAliasPolicy(0, 0, "", nil, "/not/exists", "")
+	if err == nil {
+		t.Errorf("NewAliasPolicy() accepted missing blocklist")
+	}
+}
+
+func TestAliasPolicy_CheckGenerator(t *testing.T) {
+	lower, err := NewAliasPolicy(0, 0, "", nil, "", CaseLower)
+	if err != nil {
+		t.Fatalf("NewAliasPolicy() error = %v", err)
+	}
+	digits, err := NewAliasPolicy(0, 0, "^[0-9]+$", nil, "", CasePreserve)
+	if err != nil {
+		t.Fatalf("NewAliasPolicy() error = %v", err)
+	}
+	random, _ := NewRandomGenerator("", AliasLen)
+	mixed, _ := NewRandomGenerator("abcABC", AliasLen)
+	words, _ := NewWordsGenerator(DefaultWordsCount, DefaultWordsDigits)
+
+	tests := []struct {
+		name    string
+		p       AliasPolicy
+		g       AliasGenerator
+		wantErr bool
+	}{
+		{name: "default", p: DefaultAliasPolicy(), g: random},
+		{name: "words", p: DefaultAliasPolicy(), g: words},
+		{name: "lower random", p: lower, g: random},
+		{name: "lower sequence", p: lower, g: NewSequenceGenerator(nil, AliasLen), wantErr: true},
+		{name: "lower mixed alphabet", p: lower, g: mixed, wantErr: true},
+		{name: "charset mismatch", p: digits, g: random, wantErr: true},
+		{name: "words charset mismatch", p: digits, g: words, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.p.CheckGenerator(tt.g)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("AliasPolicy.CheckGenerator() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestService_CreateURLPolicy(t *testing.T) {
+	p, err := NewAliasPolicy(0, 0, "", nil, "", CaseLower)
+	if err != nil {
+		t.Fatalf("NewAliasPolicy() error = %v", err)
+	}
+
+	var created, looked string
+	s := New(&UrlerMock{
+		createF: func(u url.URL) (string, error) {
+			created = u.Alias
+			return u.Alias, nil
+		},
+		getF: func(domain, alias string) (url.URL, error) {
+			looked = alias
+			return url.URL{}, nil
+		},
+	}, nil, WithAliasPolicy(p))
+
+	_, err = s.CreateURL(t.Context(), url.URL{Alias: "  MyLink ", Original: "http://google.com"})
+	if err != nil || created != "mylink" {
+		t.Errorf("Service.CreateURL() alias = %q, error = %v", created, err)
+	}
+	_, err = s.CreateURL(t.Context(), url.URL{Alias: "   ", Original: "http://google.com"})
+	if !errors.Is(err, ErrAliasEmpty) {
+		t.Errorf("Service.CreateURL() error = %v, want %v", err, ErrAliasEmpty)
+	}
+	_, _ = s.URL(t.Context(), "", "MYLINK")
+	if looked != "mylink" {
+		t.Errorf("Service.URL() looked up %q, want mylink", looked)
+	}
+}
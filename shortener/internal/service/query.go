@@ -0,0 +1,106 @@
+package service
+
+import (
+	parser "net/url"
+	"shortener/internal/entities/url"
+	"slices"
+	"strings"
+)
+
+func trimUTM(u url.UTM) url.UTM {
+	return url.UTM{
+		Source:   strings.TrimSpace(u.Source),
+		Medium:   strings.TrimSpace(u.Medium),
+		Campaign: strings.TrimSpace(u.Campaign),
+		Term:     strings.TrimSpace(u.Term),
+		Content:  strings.TrimSpace(u.Content),
+	}
+}
+
+// withQuery adds visitor query (when link forwards it) and stored UTM
+// parameters to dst. Parameters already present in dst win over forwarded
+// ones, stored UTM win over both. Query of dst is kept as is except for
+// replaced UTM parameters, so signed and order-sensitive destinations
+// aren't broken.
+func withQuery(dst string, u url.URL, v url.Visit) string {
+	if _, err := parser.Parse(dst); err != nil {
+		return dst
+	}
+
+	rest, fragment, hasFragment := strings.Cut(dst, "#")
+	base, rawQuery, _ := strings.Cut(rest, "?")
+
+	utm := u.UTM.Params()
+	pairs := make([]string, 0)
+	present := make(map[string]struct{})
+	for _, pair := range splitQuery(rawQuery) {
+		k, ok := queryKey(pair)
+		if _, stored := utm[k]; ok && stored {
+			continue
+		}
+		if ok {
+			present[k] = struct{}{}
+		}
+		pairs = append(pairs, pair)
+	}
+	if u.ForwardQuery {
+		// malformed pairs are skipped, rest of query is still forwarded
+		for _, pair := range splitQuery(v.Query) {
+			k, ok := queryKey(pair)
+			if !ok {
+				continue
+			}
+			if _, stored := utm[k]; stored {
+				continue
+			}
+			if _, ok := present[k]; ok {
+				continue
+			}
+			pairs = append(pairs, pair)
+		}
+	}
+	keys := make([]string, 0, len(utm))
+	for k := range utm {
+		keys = append(keys, k)
+	}
+	slices.Sort(keys)
+	for _, k := range keys {
+		pairs = append(pairs, k+"="+parser.QueryEscape(utm[k]))
+	}
+
+	res := base
+	if len(pairs) != 0 {
+		res += "?" + strings.Join(pairs, "&")
+	}
+	if hasFragment {
+		res += "#" + fragment
+	}
+	return res
+}
+
+func splitQuery(q string) []string {
+	res := make([]string, 0)
+	for _, pair := range strings.Split(q, "&") {
+		if pair != "" {
+			res = append(res, pair)
+		}
+	}
+	return res
+}
+
+// queryKey returns unescaped key of raw key=value pair, false for pairs
+// which aren't valid query.
+func queryKey(pair string) (string, bool) {
+	if strings.Contains(pair, ";") {
+		return "", false
+	}
+	k, val, _ := strings.Cut(pair, "=")
+	key, err := parser.QueryUnescape(k)
+	if err != nil {
+		return "", false
+	}
+	if _, err = parser.QueryUnescape(val); err != nil {
+		return "", false
+	}
+	return key, true
+}
@@ -1,11 +1,15 @@
 package service
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"shortener/internal/entities/redirect"
 	"shortener/internal/storage"
+	"sync"
 	"time"
+
+	"github.com/wb-go/wbf/zlog"
 )
 
 type redirectsService struct {
@@ -13,6 +17,19 @@ type redirectsService struct {
 
 	redirects []redirect.Redirect
 	i         int
+
+	// flush writes batch which isn't full after interval since its first
+	// redirect, batch counts started batches, so timer of batch written by
+	// size is ignored
+	flush time.Duration
+	timer *time.Timer
+	batch uint64
+
+	attempts int
+	backoff  time.Duration
+	dead     deadLetter
+	// wg of batches being written
+	wg *sync.WaitGroup
 }
 
 func NewRedirects(r redirector) *redirectsService {
@@ -20,6 +37,39 @@ func NewRedirects(r redirector) *redirectsService {
 		redirector: r,
 		redirects:  make([]redirect.Redirect, RedirectsBatchSize),
 		i:          0,
+		attempts:   DefaultRedirectsAttempts,
+		backoff:    DefaultRedirectsBackoff,
+		flush:      DefaultRedirectsFlush,
+		wg:         new(sync.WaitGroup),
+	}
+}
+
+// write saves batch retrying with exponential backoff, batch which failed
+// all attempts goes to dead letter store.
+func (rs *redirectsService) write(batch []redirect.Redirect) {
+	const op = "internal.service.redirects.write"
+
+	var err error
+	delay := rs.backoff
+	for i := 0; i < rs.attempts; i++ {
+		if i != 0 {
+			time.Sleep(delay)
+			delay *= 2
+		}
+		// batch outlives request which completed it
+		err = rs.CreateRedirects(context.Background(), batch)
+		if err == nil {
+			return
+		}
+		zlog.Logger.Warn().Err(err).Int("attempt", i+1).Msg(op)
+	}
+
+	if rs.dead == nil {
+		zlog.Logger.Error().Err(err).Int("redirects", len(batch)).Msg(op + ": batch is dropped")
+		return
+	}
+	if err := rs.dead.Put(batch, err); err != nil {
+		zlog.Logger.Error().Err(err).Int("redirects", len(batch)).Msg(op + ": dead letter failed, batch is dropped")
 	}
 }
 
@@ -27,23 +77,46 @@ func (s *Service) CreateRedirect(r redirect.Redirect) {
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
-	if s.rs.i != RedirectsBatchSize-1 {
-		s.rs.redirects[s.rs.i] = r
-		s.rs.i++
-		return
+	if s.rs.i == 0 {
+		batch := s.rs.batch
+		s.rs.timer = time.AfterFunc(s.rs.flush, func() {
+			s.mu.Lock()
+			defer s.mu.Unlock()
+			if s.rs.batch == batch && s.rs.i != 0 {
+				s.rs.writeBatch()
+			}
+		})
 	}
-
 	s.rs.redirects[s.rs.i] = r
-	tmp := make([]redirect.Redirect, len(s.rs.redirects))
-	copy(tmp, s.rs.redirects)
-	go s.rs.CreateRedirects(s.rs.redirects)
-	s.rs.i = 0
+	s.rs.i++
+	if s.rs.i == RedirectsBatchSize {
+		s.rs.writeBatch()
+	}
 }
 
-func (s *Service) Redirects(alias string) ([]redirect.Redirect, error) {
+// writeBatch writes collected redirects in background, it's called under
+// lock of service.
+func (rs *redirectsService) writeBatch() {
+	if rs.timer != nil {
+		rs.timer.Stop()
+		rs.timer = nil
+	}
+	rs.batch++
+
+	tmp := make([]redirect.Redirect, rs.i)
+	copy(tmp, rs.redirects[:rs.i])
+	rs.i = 0
+	rs.wg.Add(1)
+	go func() {
+		defer rs.wg.Done()
+		rs.write(tmp)
+	}()
+}
+
+func (s *Service) Redirects(ctx context.Context, alias string) ([]redirect.Redirect, error) {
 	const op = "internal.service.redirects.Get"
 
-	redirects, err := s.rs.redirector.Redirects(alias)
+	redirects, err := s.rs.redirector.Redirects(ctx, alias)
 	if errors.Is(err, storage.ErrNotFound) {
 		return nil, ErrNotFound
 	} else if err != nil {
@@ -55,7 +128,7 @@ func (s *Service) Redirects(alias string) ([]redirect.Redirect, error) {
 	return redirects, nil
 }
 
-func (s *Service) AgrigatedRedirects(opts redirect.AgrigateOpts) (redirect.Agrigated, error) {
+func (s *Service) AgrigatedRedirects(ctx context.Context, opts redirect.AgrigateOpts) (redirect.Agrigated, error) {
 	const op = "internal.service.redirects.AgrigatedGet"
 
 	if opts.Alias == "" {
@@ -63,6 +136,13 @@ func (s *Service) AgrigatedRedirects(opts redirect.AgrigateOpts) (redirect.Agrig
 			"%w: %s", ErrNotValidData, "empty alias",
 		)
 	}
+	opts.Domain = normalizeHost(opts.Domain)
+	opts.Alias = s.policy.Normalize(opts.Alias)
+	if opts.FilterColumn != "" && !redirect.ValidFilter(opts.FilterColumn) {
+		return redirect.Agrigated{}, fmt.Errorf(
+			"%w: %s", ErrNotValidData, "unknown filter column",
+		)
+	}
 	if opts.StartDate != "" {
 		_, err := time.Parse(time.DateTime, opts.StartDate)
 		if err != nil {
@@ -82,7 +162,7 @@ func (s *Service) AgrigatedRedirects(opts redirect.AgrigateOpts) (redirect.Agrig
 		}
 	}
 
-	res, err := s.rs.redirector.AgrigatedRedirects(opts)
+	res, err := s.rs.redirector.AgrigatedRedirects(ctx, opts)
 	if errors.Is(err, storage.ErrNotFound) && res.Total == 0 {
 		return redirect.Agrigated{}, ErrNotFound
 	} else if err != nil {
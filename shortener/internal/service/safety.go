@@ -0,0 +1,46 @@
+package service
+
+import (
+	"fmt"
+	parser "net/url"
+	"slices"
+	"strings"
+)
+
+// DefaultSchemes allowed in destinations, everything else (javascript:,
+// data:, file: and so on) is rejected.
+var DefaultSchemes = []string{"http", "https"}
+
+func normalizeSchemes(schemes []string) []string {
+	res := make([]string, 0, len(schemes))
+	for _, s := range schemes {
+		s = strings.ToLower(strings.TrimSpace(s))
+		if s != "" {
+			res = append(res, s)
+		}
+	}
+	return res
+}
+
+// checkDestination returns ErrNotValidData when destination scheme is not
+// allowed or destination is in blocklist.
+func (s *Service) checkDestination(dst string) error {
+	u, err := parser.Parse(dst)
+	if err != nil || !slices.Contains(s.schemes, strings.ToLower(u.Scheme)) {
+		return fmt.Errorf(
+			"%w: url scheme must be one of %s", ErrNotValidData,
+			strings.Join(s.schemes, ", "),
+		)
+	}
+	if s.guard != nil && s.guard.Blocked(dst) {
+		return fmt.Errorf("%w: %s", ErrNotValidData, "destination is blocked")
+	}
+
+	return nil
+}
+
+// Blocked reports whether visitor must not be redirected to dst, list can
+// change after link was created.
+func (s *Service) Blocked(dst string) bool {
+	return s.checkDestination(dst) != nil
+}
@@ -1,14 +1,22 @@
 package service
 
 import (
+	"context"
 	"errors"
+	"shortener/internal/entities/domain"
+	"shortener/internal/entities/health"
 	"shortener/internal/entities/redirect"
 	"shortener/internal/entities/url"
 	"sync"
+	"time"
 )
 
 const (
 	RedirectsBatchSize = 250
+
+	DefaultRedirectsAttempts = 3
+	DefaultRedirectsBackoff  = 200 * time.Millisecond
+	DefaultRedirectsFlush    = 5 * time.Second
 )
 
 var (
@@ -16,36 +24,186 @@ var (
 	ErrStorageInternal = errors.New("internal storage error")
 	ErrNotFound        = errors.New("not found url")
 	ErrNotValidData    = errors.New("not valid data")
+	ErrDisabled        = errors.New("feature is disabled")
+	ErrGenerator       = errors.New("alias generator error")
 )
 
 type urler interface {
-	CreateURL(u url.URL) (string, error)
-	URL(alias string) (string, error)
+	CreateURL(ctx context.Context, u url.URL) (string, error)
+	URL(ctx context.Context, domain, alias string) (url.URL, error)
+	URLByHash(ctx context.Context, owner, domain, hash string) (url.URL, error)
+	UpdateURL(ctx context.Context, u url.URL) error
+	URLs(ctx context.Context, f url.Filter) ([]url.Item, error)
 }
 
 type redirector interface {
-	CreateRedirects(redirects []redirect.Redirect)
-	Redirects(alias string) ([]redirect.Redirect, error)
-	AgrigatedRedirects(opts redirect.AgrigateOpts) (redirect.Agrigated, error)
+	CreateRedirects(ctx context.Context, redirects []redirect.Redirect) error
+	Redirects(ctx context.Context, alias string) ([]redirect.Redirect, error)
+	AgrigatedRedirects(ctx context.Context, opts redirect.AgrigateOpts) (redirect.Agrigated, error)
+	TagStats(ctx context.Context, opts redirect.TagOpts) (redirect.TagStats, error)
+}
+
+// deadLetter keeps batches of redirects which weren't saved.
+type deadLetter interface {
+	Put(batch []redirect.Redirect, cause error) error
+}
+
+type locator interface {
+	Country(ip string) string
+}
+
+type healthReporter interface {
+	BrokenLinks(ctx context.Context, page int) ([]health.Check, error)
+}
+
+type guard interface {
+	Blocked(u string) bool
+}
+
+type domainer interface {
+	CreateDomain(ctx context.Context, host string) (domain.Domain, error)
+	Domains(ctx context.Context) ([]domain.Domain, error)
+	DeleteDomain(ctx context.Context, host string) error
 }
 
 type Service struct {
 	urler
 	rs *redirectsService
 
+	geo     locator
+	domains *domainsRegistry
+	policy  AliasPolicy
+	gen     AliasGenerator
+	words   AliasGenerator
+	schemes []string
+	guard   guard
+
+	health healthReporter
+
+	publicHosts map[string]struct{}
+	chainDepth  int
+
 	mu *sync.Mutex
 }
 
-func New(u urler, r redirector) *Service {
-	return &Service{
-		urler: u,
-		rs:    NewRedirects(r),
-		mu:    new(sync.Mutex),
+type Option func(s *Service)
+
+// WithGeo enables geo targeted redirects.
+func WithGeo(l locator) Option {
+	return func(s *Service) {
+		s.geo = l
+	}
+}
+
+// WithDomains enables custom domains, registered hosts are reloaded from
+// storage every refresh interval.
+func WithDomains(d domainer, refresh time.Duration) Option {
+	return func(s *Service) {
+		s.domains = newDomainsRegistry(d, refresh)
+	}
+}
+
+// WithAliasPolicy overrides DefaultAliasPolicy.
+func WithAliasPolicy(p AliasPolicy) Option {
+	return func(s *Service) {
+		s.policy = p
+	}
+}
+
+// WithAliasGenerator overrides default random generator.
+func WithAliasGenerator(g AliasGenerator) Option {
+	return func(s *Service) {
+		s.gen = g
+	}
+}
+
+// WithWordsGenerator overrides generator of "words" alias mode.
+func WithWordsGenerator(g AliasGenerator) Option {
+	return func(s *Service) {
+		s.words = g
 	}
 }
 
+// WithSchemes overrides DefaultSchemes allowed in destinations.
+func WithSchemes(schemes ...string) Option {
+	return func(s *Service) {
+		s.schemes = normalizeSchemes(schemes)
+	}
+}
+
+// WithBlocklist enables checking of destinations against blocklist on
+// creation and redirect.
+func WithBlocklist(g guard) Option {
+	return func(s *Service) {
+		s.guard = g
+	}
+}
+
+// WithHealth enables reporting of links with broken destinations, checks
+// are made by health.Checker.
+func WithHealth(h healthReporter) Option {
+	return func(s *Service) {
+		s.health = h
+	}
+}
+
+// WithRedirectsRetry overrides DefaultRedirectsAttempts to save batch of
+// redirects, delay between attempts starts with backoff and doubles.
+func WithRedirectsRetry(attempts int, backoff time.Duration) Option {
+	return func(s *Service) {
+		if attempts > 0 {
+			s.rs.attempts = attempts
+		}
+		if backoff > 0 {
+			s.rs.backoff = backoff
+		}
+	}
+}
+
+// WithRedirectsFlush overrides DefaultRedirectsFlush, batch of redirects
+// which isn't full is written after interval since its first redirect.
+func WithRedirectsFlush(interval time.Duration) Option {
+	return func(s *Service) {
+		if interval > 0 {
+			s.rs.flush = interval
+		}
+	}
+}
+
+// WithDeadLetter keeps batches of redirects which failed all attempts,
+// otherwise they are logged and dropped.
+func WithDeadLetter(d deadLetter) Option {
+	return func(s *Service) {
+		s.rs.dead = d
+	}
+}
+
+func New(u urler, r redirector, opts ...Option) *Service {
+	gen, _ := NewRandomGenerator(DefaultAlphabet, AliasLen)
+	words, _ := NewWordsGenerator(DefaultWordsCount, DefaultWordsDigits)
+	s := &Service{
+		urler:   u,
+		rs:      NewRedirects(r),
+		policy:  DefaultAliasPolicy(),
+		gen:     gen,
+		words:   words,
+		schemes: DefaultSchemes,
+		mu:      new(sync.Mutex),
+	}
+	for _, opt := range opts {
+		opt(s)
+	}
+	return s
+}
+
 func (s *Service) Shutdown() {
+	if s.domains != nil {
+		s.domains.shutdown()
+	}
+	s.mu.Lock()
 	if s.rs.i != 0 {
-		s.rs.CreateRedirects(s.rs.redirects[:s.rs.i])
+		s.rs.writeBatch()
 	}
+	s.mu.Unlock()
+	s.rs.wg.Wait()
 }
@@ -1,28 +1,37 @@
 package service
 
 import (
+	"context"
 	"errors"
+	"shortener/internal/entities/domain"
+	"shortener/internal/entities/health"
 	"shortener/internal/entities/redirect"
 	"shortener/internal/entities/url"
 	"shortener/internal/storage"
 	"testing"
+	"time"
 )
 
 type redirectorMock struct {
-	createF func(redirects []redirect.Redirect)
+	createF func(redirects []redirect.Redirect) error
 	getF    func(alias string) ([]redirect.Redirect, error)
 	agrF    func(opts redirect.AgrigateOpts) (redirect.Agrigated, error)
+	tagF    func(opts redirect.TagOpts) (redirect.TagStats, error)
 }
 
-func (rm *redirectorMock) CreateRedirects(redirects []redirect.Redirect) {
-	rm.createF(redirects)
+func (rm *redirectorMock) TagStats(ctx context.Context, opts redirect.TagOpts) (redirect.TagStats, error) {
+	return rm.tagF(opts)
 }
 
-func (rm *redirectorMock) Redirects(alias string) ([]redirect.Redirect, error) {
+func (rm *redirectorMock) CreateRedirects(ctx context.Context, redirects []redirect.Redirect) error {
+	return rm.createF(redirects)
+}
+
+func (rm *redirectorMock) Redirects(ctx context.Context, alias string) ([]redirect.Redirect, error) {
 	return rm.getF(alias)
 }
 
-func (rm *redirectorMock) AgrigatedRedirects(opts redirect.AgrigateOpts) (redirect.Agrigated, error) {
+func (rm *redirectorMock) AgrigatedRedirects(ctx context.Context, opts redirect.AgrigateOpts) (redirect.Agrigated, error) {
 	return rm.agrF(opts)
 }
 
@@ -85,7 +94,7 @@ func TestService_Redirects(t *testing.T) {
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			s := New(nil, tt.fields.redirector)
-			_, err := s.Redirects(tt.args.alias)
+			_, err := s.Redirects(t.Context(), tt.args.alias)
 			if !errors.Is(err, tt.want) {
 				t.Errorf("Service.CreateRedirect() error = %v, wantErr %v", err, tt.want)
 				return
@@ -171,6 +180,23 @@ func TestService_AgrigatedRedirects(t *testing.T) {
 			},
 			want: ErrStorageInternal,
 		},
+		{
+			name: "unknown filter",
+			fields: fields{
+				rs: &redirectorMock{
+					agrF: func(opts redirect.AgrigateOpts) (redirect.Agrigated, error) {
+						return redirect.Agrigated{}, nil
+					},
+				},
+			},
+			args: args{
+				opts: redirect.AgrigateOpts{
+					Alias:        "asd",
+					FilterColumn: "id; drop table urls",
+				},
+			},
+			want: ErrNotValidData,
+		},
 		{
 			name: "wrong start date",
 			fields: fields{
@@ -209,7 +235,7 @@ func TestService_AgrigatedRedirects(t *testing.T) {
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			s := New(nil, tt.fields.rs)
-			_, err := s.AgrigatedRedirects(tt.args.opts)
+			_, err := s.AgrigatedRedirects(t.Context(), tt.args.opts)
 			if !errors.Is(err, tt.want) {
 				t.Errorf("Service.AgrigatedRedirects() error = %v, wantErr %v", err, tt.want)
 				return
@@ -220,7 +246,18 @@ func TestService_AgrigatedRedirects(t *testing.T) {
 
 type UrlerMock struct {
 	createF func(u url.URL) (string, error)
-	getF    func(alias string) (string, error)
+	getF    func(domain, alias string) (url.URL, error)
+	hashF   func(owner, domain, hash string) (url.URL, error)
+	updateF func(u url.URL) error
+	listF   func(f url.Filter) ([]url.Item, error)
+}
+
+func (um *UrlerMock) UpdateURL(ctx context.Context, u url.URL) error {
+	return um.updateF(u)
+}
+
+func (um *UrlerMock) URLs(ctx context.Context, f url.Filter) ([]url.Item, error) {
+	return um.listF(f)
 }
 
 func RegenerationMock() func(u url.URL) (string, error) {
@@ -235,12 +272,16 @@ func RegenerationMock() func(u url.URL) (string, error) {
 	}
 }
 
-func (um *UrlerMock) CreateURL(u url.URL) (string, error) {
+func (um *UrlerMock) CreateURL(ctx context.Context, u url.URL) (string, error) {
 	return um.createF(u)
 }
 
-func (um *UrlerMock) URL(alias string) (string, error) {
-	return um.getF(alias)
+func (um *UrlerMock) URL(ctx context.Context, domain, alias string) (url.URL, error) {
+	return um.getF(domain, alias)
+}
+
+func (um *UrlerMock) URLByHash(ctx context.Context, owner, domain, hash string) (url.URL, error) {
+	return um.hashF(owner, domain, hash)
 }
 
 func TestService_CreateURL(t *testing.T) {
@@ -336,6 +377,57 @@ func TestService_CreateURL(t *testing.T) {
 			},
 			want: ErrNotUnique,
 		},
+		{
+			name: "reserved alias",
+			fields: fields{
+				urler: &UrlerMock{
+					createF: func(u url.URL) (string, error) {
+						return u.Alias, nil
+					},
+				},
+			},
+			args: args{
+				u: url.URL{
+					Alias:    "Swagger",
+					Original: "http://google.com/test",
+				},
+			},
+			want: ErrNotValidData,
+		},
+		{
+			name: "not valid geo country",
+			fields: fields{
+				urler: &UrlerMock{
+					createF: func(u url.URL) (string, error) {
+						return "test", nil
+					},
+				},
+			},
+			args: args{
+				u: url.URL{
+					Original:   "http://google.com/test",
+					GeoTargets: map[string]string{"Germany": "http://google.de"},
+				},
+			},
+			want: ErrNotValidData,
+		},
+		{
+			name: "not valid geo target",
+			fields: fields{
+				urler: &UrlerMock{
+					createF: func(u url.URL) (string, error) {
+						return "test", nil
+					},
+				},
+			},
+			args: args{
+				u: url.URL{
+					Original:   "http://google.com/test",
+					GeoTargets: map[string]string{"de": "google.de"},
+				},
+			},
+			want: ErrNotValidData,
+		},
 		{
 			name: "good alias generating",
 			fields: fields{
@@ -354,7 +446,7 @@ func TestService_CreateURL(t *testing.T) {
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			s := New(tt.fields.urler, nil)
-			_, err := s.CreateURL(tt.args.u)
+			_, err := s.CreateURL(t.Context(), tt.args.u)
 			if !errors.Is(err, tt.want) {
 				t.Errorf("Service.CreateURL() error = %v, wantErr %v", err, tt.want)
 				return
@@ -380,8 +472,8 @@ func TestService_URL(t *testing.T) {
 			name: "good",
 			fields: fields{
 				urler: &UrlerMock{
-					getF: func(alias string) (string, error) {
-						return "", nil
+					getF: func(domain, alias string) (url.URL, error) {
+						return url.URL{}, nil
 					},
 				},
 			},
@@ -394,8 +486,8 @@ func TestService_URL(t *testing.T) {
 			name: "good",
 			fields: fields{
 				urler: &UrlerMock{
-					getF: func(alias string) (string, error) {
-						return "", nil
+					getF: func(domain, alias string) (url.URL, error) {
+						return url.URL{}, nil
 					},
 				},
 			},
@@ -408,8 +500,8 @@ func TestService_URL(t *testing.T) {
 			name: "good",
 			fields: fields{
 				urler: &UrlerMock{
-					getF: func(alias string) (string, error) {
-						return "", storage.ErrNotFound
+					getF: func(domain, alias string) (url.URL, error) {
+						return url.URL{}, storage.ErrNotFound
 					},
 				},
 			},
@@ -422,8 +514,8 @@ func TestService_URL(t *testing.T) {
 			name: "good",
 			fields: fields{
 				urler: &UrlerMock{
-					getF: func(alias string) (string, error) {
-						return "", errors.New("unknown")
+					getF: func(domain, alias string) (url.URL, error) {
+						return url.URL{}, errors.New("unknown")
 					},
 				},
 			},
@@ -436,7 +528,7 @@ func TestService_URL(t *testing.T) {
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			s := New(tt.fields.urler, nil)
-			_, err := s.URL(tt.args.alias)
+			_, err := s.URL(t.Context(), "", tt.args.alias)
 			if !errors.Is(err, tt.want) {
 				t.Errorf("Service.URL() error = %v, wantErr %v", err, tt)
 				return
@@ -444,3 +536,455 @@ func TestService_URL(t *testing.T) {
 		})
 	}
 }
+
+type locatorMock map[string]string
+
+func (lm locatorMock) Country(ip string) string {
+	return lm[ip]
+}
+
+func TestService_Destination(t *testing.T) {
+	u := url.URL{
+		Alias:    "test",
+		Original: "http://shop.com",
+		GeoTargets: map[string]string{
+			"DE": "http://shop.de",
+		},
+	}
+	tests := []struct {
+		name string
+		opts []Option
+		u    url.URL
+		v    url.Visit
+		want string
+	}{
+		{
+			name: "geo target",
+			opts: []Option{WithGeo(locatorMock{"1.1.1.1": "DE"})},
+			u:    u,
+			v:    url.Visit{IP: "1.1.1.1"},
+			want: "http://shop.de",
+		},
+		{
+			name: "fallback for other country",
+			opts: []Option{WithGeo(locatorMock{"1.1.1.1": "FR"})},
+			u:    u,
+			v:    url.Visit{IP: "1.1.1.1"},
+			want: "http://shop.com",
+		},
+		{
+			name: "unknown ip",
+			opts: []Option{WithGeo(locatorMock{})},
+			u:    u,
+			v:    url.Visit{IP: "1.1.1.1"},
+			want: "http://shop.com",
+		},
+		{
+			name: "geo disabled",
+			u:    u,
+			v:    url.Visit{IP: "1.1.1.1"},
+			want: "http://shop.com",
+		},
+		{
+			name: "forward query",
+			u: url.URL{
+				Original:     "http://shop.com/?a=1",
+				ForwardQuery: true,
+			},
+			v:    url.Visit{Query: "a=2&b=3"},
+			want: "http://shop.com/?a=1&b=3",
+		},
+		{
+			name: "query not forwarded",
+			u: url.URL{
+				Original: "http://shop.com/?a=1",
+			},
+			v:    url.Visit{Query: "b=3"},
+			want: "http://shop.com/?a=1",
+		},
+		{
+			name: "utm",
+			u: url.URL{
+				Original:     "http://shop.com/?a=1",
+				ForwardQuery: true,
+				UTM: url.UTM{
+					Source:   "podcast",
+					Campaign: "autumn",
+				},
+			},
+			v:    url.Visit{Query: "utm_source=other&b=3"},
+			want: "http://shop.com/?a=1&b=3&utm_campaign=autumn&utm_source=podcast",
+		},
+		{
+			name: "query of destination kept as is",
+			u: url.URL{
+				Original:     "http://shop.com/?z=1&a=%2f+b&sig=AbC#top",
+				ForwardQuery: true,
+				UTM:          url.UTM{Source: "podcast"},
+			},
+			v:    url.Visit{Query: "sig=forged&b=%zz&c=4"},
+			want: "http://shop.com/?z=1&a=%2f+b&sig=AbC&c=4&utm_source=podcast#top",
+		},
+		{
+			name: "stored utm replaces destination one",
+			u: url.URL{
+				Original: "http://shop.com/?utm_source=old&a=1",
+				UTM:      url.UTM{Source: "podcast"},
+			},
+			want: "http://shop.com/?a=1&utm_source=podcast",
+		},
+		{
+			name: "utm with geo target",
+			opts: []Option{WithGeo(locatorMock{"1.1.1.1": "DE"})},
+			u: url.URL{
+				Original:   "http://shop.com",
+				GeoTargets: map[string]string{"DE": "http://shop.de/sale"},
+				UTM:        url.UTM{Medium: "poster"},
+			},
+			v:    url.Visit{IP: "1.1.1.1"},
+			want: "http://shop.de/sale?utm_medium=poster",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := New(nil, nil, tt.opts...)
+			if got := s.Destination(tt.u, tt.v); got != tt.want {
+				t.Errorf("Service.Destination() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+type domainerMock struct {
+	hosts map[string]domain.Domain
+}
+
+func (dm *domainerMock) CreateDomain(ctx context.Context, host string) (domain.Domain, error) {
+	if _, ok := dm.hosts[host]; ok {
+		return domain.Domain{}, storage.ErrNotUnique
+	}
+	dm.hosts[host] = domain.Domain{Host: host}
+	return dm.hosts[host], nil
+}
+
+func (dm *domainerMock) Domains(ctx context.Context) ([]domain.Domain, error) {
+	res := make([]domain.Domain, 0, len(dm.hosts))
+	for _, d := range dm.hosts {
+		res = append(res, d)
+	}
+	return res, nil
+}
+
+func (dm *domainerMock) DeleteDomain(ctx context.Context, host string) error {
+	if _, ok := dm.hosts[host]; !ok {
+		return storage.ErrNotFound
+	}
+	delete(dm.hosts, host)
+	return nil
+}
+
+func TestService_Domains(t *testing.T) {
+	var gotDomain string
+	um := &UrlerMock{
+		getF: func(domain, alias string) (url.URL, error) {
+			gotDomain = domain
+			return url.URL{}, nil
+		},
+		createF: func(u url.URL) (string, error) {
+			return u.Alias, nil
+		},
+	}
+	dm := &domainerMock{hosts: map[string]domain.Domain{
+		"go.brand-a.com": {Host: "go.brand-a.com"},
+	}}
+	s := New(um, nil, WithDomains(dm, time.Hour))
+	defer s.Shutdown()
+
+	hosts := []struct {
+		host string
+		want string
+	}{
+		{host: "go.brand-a.com", want: "go.brand-a.com"},
+		{host: "GO.Brand-A.com:8080", want: "go.brand-a.com"},
+		{host: "localhost", want: ""},
+		{host: "go.brand-b.com", want: ""},
+	}
+	for _, h := range hosts {
+		_, err := s.URL(t.Context(), h.host, "x")
+		if err != nil || gotDomain != h.want {
+			t.Errorf("Service.URL(%s) domain = %s, want %s", h.host, gotDomain, h.want)
+		}
+	}
+
+	_, err := s.CreateDomain(t.Context(), "https://go.brand-b.com/")
+	if !errors.Is(err, ErrNotValidData) {
+		t.Errorf("Service.CreateDomain() error = %v, want %v", err, ErrNotValidData)
+	}
+	_, err = s.CreateDomain(t.Context(), "go.brand-a.com")
+	if !errors.Is(err, ErrNotUnique) {
+		t.Errorf("Service.CreateDomain() error = %v, want %v", err, ErrNotUnique)
+	}
+	_, err = s.CreateURL(t.Context(), url.URL{
+		Alias: "xyz", Domain: "go.brand-b.com", Original: "http://google.com",
+	})
+	if !errors.Is(err, ErrNotValidData) {
+		t.Errorf("Service.CreateURL() error = %v, want %v", err, ErrNotValidData)
+	}
+
+	_, err = s.CreateDomain(t.Context(), "Go.Brand-B.com")
+	if err != nil {
+		t.Errorf("Service.CreateDomain() error = %v", err)
+	}
+	_, err = s.URL(t.Context(), "go.brand-b.com", "x")
+	if err != nil || gotDomain != "go.brand-b.com" {
+		t.Errorf("Service.URL() domain = %s, want go.brand-b.com", gotDomain)
+	}
+	_, err = s.CreateURL(t.Context(), url.URL{
+		Alias: "xyz", Domain: "go.brand-b.com", Original: "http://google.com",
+	})
+	if err != nil {
+		t.Errorf("Service.CreateURL() error = %v", err)
+	}
+
+	err = s.DeleteDomain(t.Context(), "go.brand-b.com")
+	if err != nil {
+		t.Errorf("Service.DeleteDomain() error = %v", err)
+	}
+	err = s.DeleteDomain(t.Context(), "go.brand-b.com")
+	if !errors.Is(err, ErrNotFound) {
+		t.Errorf("Service.DeleteDomain() error = %v, want %v", err, ErrNotFound)
+	}
+	_, _ = s.URL(t.Context(), "go.brand-b.com", "x")
+	if gotDomain != "" {
+		t.Errorf("Service.URL() domain = %s, want default", gotDomain)
+	}
+}
+
+type guardMock struct {
+	blocked map[string]bool
+}
+
+func (g guardMock) Blocked(u string) bool {
+	return g.blocked[u]
+}
+
+func TestService_CreateURLSafety(t *testing.T) {
+	um := &UrlerMock{createF: func(u url.URL) (string, error) { return u.Alias, nil }}
+	s := New(um, nil, WithBlocklist(guardMock{blocked: map[string]bool{
+		"https://evil.example": true,
+	}}))
+
+	tests := []struct {
+		name string
+		u    url.URL
+		want error
+	}{
+		{"http", url.URL{Original: "http://google.com"}, nil},
+		{"javascript", url.URL{Original: "javascript://alert(1)"}, ErrNotValidData},
+		{"file", url.URL{Original: "file://host/etc/passwd"}, ErrNotValidData},
+		{"blocked", url.URL{Original: "https://evil.example"}, ErrNotValidData},
+		{"blocked geo target", url.URL{
+			Original:   "https://google.com",
+			GeoTargets: map[string]string{"DE": "https://evil.example"},
+		}, ErrNotValidData},
+		{"blocked geo target scheme", url.URL{
+			Original:   "https://google.com",
+			GeoTargets: map[string]string{"DE": "ftp://files.example"},
+		}, ErrNotValidData},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := s.CreateURL(t.Context(), tt.u)
+			if !errors.Is(err, tt.want) {
+				t.Errorf("Service.CreateURL() error = %v, want %v", err, tt.want)
+			}
+		})
+	}
+
+	if !s.Blocked("https://evil.example") || s.Blocked("https://google.com") {
+		t.Errorf("Service.Blocked() is wrong")
+	}
+	s = New(um, nil, WithSchemes("FTP"))
+	if s.Blocked("ftp://files.example") || !s.Blocked("https://google.com") {
+		t.Errorf("Service.Blocked() doesn't use configured schemes")
+	}
+}
+
+func TestService_CreateURLChain(t *testing.T) {
+	links := map[string]url.URL{
+		"/ext":   {Alias: "ext", Original: "https://google.com"},
+		"/one":   {Alias: "one", Original: "http://sho.rt/s/ext"},
+		"/two":   {Alias: "two", Original: "http://sho.rt/one"},
+		"/loop":  {Alias: "loop", Original: "https://sho.rt/s/self"},
+		"/geo":   {Alias: "geo", Original: "https://google.com", GeoTargets: map[string]string{"DE": "http://sho.rt/new"}},
+		"/other": {Alias: "other", Original: "http://sho.rt:8080/s/loop"},
+	}
+	um := &UrlerMock{
+		createF: func(u url.URL) (string, error) { return u.Alias, nil },
+		getF: func(domain, alias string) (url.URL, error) {
+			u, ok := links[domain+"/"+alias]
+			if !ok {
+				return u, storage.ErrNotFound
+			}
+			return u, nil
+		},
+	}
+
+	tests := []struct {
+		name  string
+		depth int
+		u     url.URL
+		want  error
+	}{
+		{"external", 0, url.URL{Original: "https://google.com/s/ext"}, nil},
+		{"own page", 0, url.URL{Original: "http://sho.rt/analytics/ext"}, nil},
+		{"self link", 0, url.URL{Original: "http://SHO.RT/s/ext"}, ErrSelfLink},
+		{"self link in geo target", 0, url.URL{
+			Original: "https://google.com", GeoTargets: map[string]string{"DE": "http://sho.rt/ext"},
+		}, ErrSelfLink},
+		{"chain in limit", 2, url.URL{Original: "http://sho.rt/s/one"}, nil},
+		{"chain too long", 2, url.URL{Original: "http://sho.rt/two"}, ErrChainTooLong},
+		{"unknown link", 2, url.URL{Original: "http://sho.rt/s/nothing"}, ErrUnknownTarget},
+		{"loop to itself", 5, url.URL{Alias: "self", Original: "http://sho.rt/s/self"}, ErrRedirectLoop},
+		{"loop", 5, url.URL{Alias: "self", Original: "http://sho.rt/s/other"}, ErrRedirectLoop},
+		{"loop through geo target", 5, url.URL{Alias: "new", Original: "http://sho.rt/s/geo"}, ErrRedirectLoop},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := New(um, nil, WithPublicHosts(tt.depth, "sho.rt", ""))
+			_, err := s.CreateURL(t.Context(), tt.u)
+			if !errors.Is(err, tt.want) {
+				t.Errorf("Service.CreateURL() error = %v, want %v", err, tt.want)
+			}
+			if tt.want != nil && !errors.Is(err, ErrNotValidData) {
+				t.Errorf("Service.CreateURL() error = %v must be %v", err, ErrNotValidData)
+			}
+		})
+	}
+}
+
+type healthMock struct {
+	brokenF func(page int) ([]health.Check, error)
+}
+
+func (h healthMock) BrokenLinks(ctx context.Context, page int) ([]health.Check, error) {
+	return h.brokenF(page)
+}
+
+func TestService_BrokenLinks(t *testing.T) {
+	s := New(&UrlerMock{}, nil)
+	if _, err := s.BrokenLinks(t.Context(), 1); !errors.Is(err, ErrDisabled) {
+		t.Errorf("Service.BrokenLinks() error = %v, want %v", err, ErrDisabled)
+	}
+
+	s = New(&UrlerMock{}, nil, WithHealth(healthMock{
+		brokenF: func(page int) ([]health.Check, error) {
+			return nil, errors.New("db is down")
+		},
+	}))
+	if _, err := s.BrokenLinks(t.Context(), 1); !errors.Is(err, ErrStorageInternal) {
+		t.Errorf("Service.BrokenLinks() error = %v, want %v", err, ErrStorageInternal)
+	}
+	if _, err := s.BrokenLinks(t.Context(), -1); !errors.Is(err, ErrNotValidData) {
+		t.Errorf("Service.BrokenLinks() error = %v, want %v", err, ErrNotValidData)
+	}
+}
+
+type deadLetterMock struct {
+	batches [][]redirect.Redirect
+}
+
+func (dm *deadLetterMock) Put(batch []redirect.Redirect, cause error) error {
+	dm.batches = append(dm.batches, batch)
+	return nil
+}
+
+func TestService_CreateRedirectRetry(t *testing.T) {
+	tests := []struct {
+		name     string
+		failures int
+		wantDead bool
+	}{
+		{name: "saved", failures: 0},
+		{name: "saved after retries", failures: 2},
+		{name: "dead letter", failures: 3, wantDead: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			calls := 0
+			rm := &redirectorMock{
+				createF: func(redirects []redirect.Redirect) error {
+					calls++
+					if calls <= tt.failures {
+						return errors.New("db is down")
+					}
+					return nil
+				},
+			}
+			dead := &deadLetterMock{}
+			s := New(nil, rm, WithRedirectsRetry(3, time.Millisecond), WithDeadLetter(dead))
+
+			// batch which isn't full is written on shutdown
+			s.CreateRedirect(redirect.Redirect{Alias: "test"})
+			s.Shutdown()
+
+			if want := min(tt.failures+1, 3); calls != want {
+				t.Errorf("attempts = %d, want %d", calls, want)
+			}
+			if got := len(dead.batches) == 1 && dead.batches[0][0].Alias == "test"; got != tt.wantDead {
+				t.Errorf("dead letter batches = %v, want dead %v", dead.batches, tt.wantDead)
+			}
+		})
+	}
+}
+
+func TestService_CreateRedirectBatch(t *testing.T) {
+	saved := make(chan int, 1)
+	rm := &redirectorMock{
+		createF: func(redirects []redirect.Redirect) error {
+			saved <- len(redirects)
+			return nil
+		},
+	}
+	s := New(nil, rm)
+
+	for i := 0; i < RedirectsBatchSize; i++ {
+		s.CreateRedirect(redirect.Redirect{Alias: "test"})
+	}
+	// shutdown waits for batch written in background
+	s.Shutdown()
+
+	select {
+	case n := <-saved:
+		if n != RedirectsBatchSize {
+			t.Errorf("batch size = %d, want %d", n, RedirectsBatchSize)
+		}
+	default:
+		t.Error("full batch isn't written before shutdown returns")
+	}
+}
+
+func TestService_CreateRedirectFlush(t *testing.T) {
+	saved := make(chan int, 1)
+	rm := &redirectorMock{
+		createF: func(redirects []redirect.Redirect) error {
+			saved <- len(redirects)
+			return nil
+		},
+	}
+	s := New(nil, rm, WithRedirectsFlush(10*time.Millisecond))
+	defer s.Shutdown()
+
+	s.CreateRedirect(redirect.Redirect{Alias: "test"})
+	s.CreateRedirect(redirect.Redirect{Alias: "test"})
+
+	select {
+	case n := <-saved:
+		if n != 2 {
+			t.Errorf("batch size = %d, want 2", n)
+		}
+	case <-time.After(time.Second):
+		t.Error("batch which isn't full isn't written after flush interval")
+	}
+}
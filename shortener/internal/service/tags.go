@@ -0,0 +1,91 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"shortener/internal/entities/redirect"
+	"shortener/internal/storage"
+	"slices"
+	"strings"
+	"time"
+	"unicode"
+)
+
+const (
+	MaxTags      = 20
+	MaxFolderLen = 128
+)
+
+var tagRe = regexp.MustCompile(`^[\p{Ll}\p{N}][\p{Ll}\p{N}_-]{0,31}$`)
+
+// normalizeTags lower cases tags and drops duplicates.
+func normalizeTags(tags []string) ([]string, error) {
+	if len(tags) == 0 {
+		return nil, nil
+	}
+	if len(tags) > MaxTags {
+		return nil, fmt.Errorf("%w: at most %d tags per link", ErrNotValidData, MaxTags)
+	}
+
+	res := make([]string, 0, len(tags))
+	for _, t := range tags {
+		t = strings.ToLower(strings.TrimSpace(t))
+		if !tagRe.MatchString(t) {
+			return nil, fmt.Errorf(
+				"%w: tag %q must be 1-32 letters, digits, - or _", ErrNotValidData, t,
+			)
+		}
+		if !slices.Contains(res, t) {
+			res = append(res, t)
+		}
+	}
+	slices.Sort(res)
+
+	return res, nil
+}
+
+// normalizeFolder trims spaces and slashes around folder path, e.g.
+// " /marketing/2026/ " is stored as "marketing/2026".
+func normalizeFolder(folder string) (string, error) {
+	folder = strings.Trim(strings.TrimSpace(folder), "/")
+	if len(folder) > MaxFolderLen {
+		return "", fmt.Errorf(
+			"%w: folder is longer than %d bytes", ErrNotValidData, MaxFolderLen,
+		)
+	}
+	if strings.IndexFunc(folder, unicode.IsControl) != -1 {
+		return "", fmt.Errorf("%w: %s", ErrNotValidData, "folder has control characters")
+	}
+	return folder, nil
+}
+
+// TagStats aggregates clicks of all links with tag.
+func (s *Service) TagStats(ctx context.Context, opts redirect.TagOpts) (redirect.TagStats, error) {
+	const op = "internal.service.redirects.TagStats"
+
+	opts.Tag = strings.ToLower(strings.TrimSpace(opts.Tag))
+	if opts.Tag == "" {
+		return redirect.TagStats{}, fmt.Errorf("%w: %s", ErrNotValidData, "empty tag")
+	}
+	for _, d := range []string{opts.StartDate, opts.EndDate} {
+		if d == "" {
+			continue
+		}
+		if _, err := time.Parse(time.DateTime, d); err != nil {
+			return redirect.TagStats{}, fmt.Errorf(
+				"%w: %s", ErrNotValidData, "date format is 2006-01-02 15:04:05",
+			)
+		}
+	}
+
+	res, err := s.rs.redirector.TagStats(ctx, opts)
+	if errors.Is(err, storage.ErrNotFound) {
+		return res, ErrNotFound
+	} else if err != nil {
+		return res, fmt.Errorf("%s: %w(%w)", op, ErrStorageInternal, err)
+	}
+
+	return res, nil
+}
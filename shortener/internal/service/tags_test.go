@@ -0,0 +1,184 @@
+package service
+
+import (
+	"errors"
+	"shortener/internal/entities/redirect"
+	"shortener/internal/entities/url"
+	"shortener/internal/storage"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestNormalizeTags(t *testing.T) {
+	tests := []struct {
+		name string
+		tags []string
+		want []string
+		err  error
+	}{
+		{"empty", nil, nil, nil},
+		{"case and duplicates", []string{" Spring-Sale", "spring-sale", "ads"}, []string{"ads", "spring-sale"}, nil},
+		{"unicode", []string{"Весна_2026"}, []string{"весна_2026"}, nil},
+		{"space", []string{"spring sale"}, nil, ErrNotValidData},
+		{"empty tag", []string{""}, nil, ErrNotValidData},
+		{"too long", []string{strings.Repeat("a", 33)}, nil, ErrNotValidData},
+		{"too many", slices.Repeat([]string{"a"}, MaxTags+1), nil, ErrNotValidData},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := normalizeTags(tt.tags)
+			if !errors.Is(err, tt.err) {
+				t.Fatalf("normalizeTags() error = %v, want %v", err, tt.err)
+			}
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("normalizeTags() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNormalizeFolder(t *testing.T) {
+	got, err := normalizeFolder(" /marketing/2026/ ")
+	if err != nil || got != "marketing/2026" {
+		t.Errorf("normalizeFolder() = %v, %v", got, err)
+	}
+	if _, err = normalizeFolder("a\nb"); !errors.Is(err, ErrNotValidData) {
+		t.Errorf("normalizeFolder() error = %v, want %v", err, ErrNotValidData)
+	}
+	if _, err = normalizeFolder(strings.Repeat("a", MaxFolderLen+1)); !errors.Is(err, ErrNotValidData) {
+		t.Errorf("normalizeFolder() error = %v, want %v", err, ErrNotValidData)
+	}
+}
+
+func TestService_UpdateURL(t *testing.T) {
+	stored := url.URL{Alias: "abc", Original: "https://google.com", Tags: []string{"old"}, Folder: "f"}
+	var saved url.URL
+	um := &UrlerMock{
+		getF: func(domain, alias string) (url.URL, error) {
+			if alias != stored.Alias {
+				return url.URL{}, storage.ErrNotFound
+			}
+			return stored, nil
+		},
+		updateF: func(u url.URL) error {
+			saved = u
+			return nil
+		},
+	}
+	s := New(um, nil)
+
+	tags := []string{"New", "ads"}
+	u, err := s.UpdateURL(t.Context(), "", "abc", url.Patch{Tags: &tags})
+	if err != nil {
+		t.Fatalf("Service.UpdateURL() error = %v", err)
+	}
+	if !slices.Equal(saved.Tags, []string{"ads", "new"}) || saved.Folder != "f" || u.Folder != "f" {
+		t.Errorf("Service.UpdateURL() saved = %+v", saved)
+	}
+
+	root := ""
+	_, err = s.UpdateURL(t.Context(), "", "abc", url.Patch{Folder: &root})
+	if err != nil || saved.Folder != "" || !slices.Equal(saved.Tags, []string{"old"}) {
+		t.Errorf("Service.UpdateURL() saved = %+v, error = %v", saved, err)
+	}
+
+	bad := []string{"no spaces"}
+	if _, err = s.UpdateURL(t.Context(), "", "abc", url.Patch{Tags: &bad}); !errors.Is(err, ErrNotValidData) {
+		t.Errorf("Service.UpdateURL() error = %v, want %v", err, ErrNotValidData)
+	}
+	if _, err = s.UpdateURL(t.Context(), "", "nope", url.Patch{Tags: &tags}); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Service.UpdateURL() error = %v, want %v", err, ErrNotFound)
+	}
+
+	// link of default domain isn't edited through unknown domain
+	saved = url.URL{}
+	if _, err = s.UpdateURL(t.Context(), "typo.example", "abc", url.Patch{Tags: &tags}); !errors.Is(err, ErrNotValidData) {
+		t.Errorf("Service.UpdateURL() error = %v, want %v", err, ErrNotValidData)
+	}
+	if saved.Alias != "" {
+		t.Errorf("Service.UpdateURL() saved = %+v through unknown domain", saved)
+	}
+}
+
+func TestService_TagStats(t *testing.T) {
+	var got redirect.TagOpts
+	rm := &redirectorMock{
+		tagF: func(opts redirect.TagOpts) (redirect.TagStats, error) {
+			got = opts
+			if opts.Tag == "missing" {
+				return redirect.TagStats{}, storage.ErrNotFound
+			}
+			return redirect.TagStats{Tag: opts.Tag, Total: 3}, nil
+		},
+	}
+	s := New(&UrlerMock{}, rm)
+
+	res, err := s.TagStats(t.Context(), redirect.TagOpts{Tag: " Spring-Sale "})
+	if err != nil || res.Total != 3 || got.Tag != "spring-sale" {
+		t.Errorf("Service.TagStats() = %+v, %v", res, err)
+	}
+	if _, err = s.TagStats(t.Context(), redirect.TagOpts{Tag: "missing"}); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Service.TagStats() error = %v, want %v", err, ErrNotFound)
+	}
+	if _, err = s.TagStats(t.Context(), redirect.TagOpts{Tag: "a", StartDate: "yesterday"}); !errors.Is(err, ErrNotValidData) {
+		t.Errorf("Service.TagStats() error = %v, want %v", err, ErrNotValidData)
+	}
+	if _, err = s.TagStats(t.Context(), redirect.TagOpts{}); !errors.Is(err, ErrNotValidData) {
+		t.Errorf("Service.TagStats() error = %v, want %v", err, ErrNotValidData)
+	}
+}
+
+func TestService_URLs(t *testing.T) {
+	var got url.Filter
+	um := &UrlerMock{
+		listF: func(f url.Filter) ([]url.Item, error) {
+			got = f
+			return nil, nil
+		},
+	}
+	s := New(um, nil)
+
+	_, err := s.URLs(t.Context(), url.Filter{Tag: " Ads ", Folder: "/m/", Query: " sale ", Sort: url.SortClicks})
+	if err != nil || got.Tag != "ads" || got.Folder != "m" || got.Query != "sale" {
+		t.Errorf("Service.URLs() filter = %+v, error = %v", got, err)
+	}
+
+	for _, f := range []url.Filter{{Sort: "name"}, {Status: "dead"}, {Page: -1}} {
+		if _, err = s.URLs(t.Context(), f); !errors.Is(err, ErrNotValidData) {
+			t.Errorf("Service.URLs(%+v) error = %v, want %v", f, err, ErrNotValidData)
+		}
+	}
+}
+
+func TestService_UpdateURLMeta(t *testing.T) {
+	var saved url.URL
+	um := &UrlerMock{
+		getF: func(domain, alias string) (url.URL, error) {
+			return url.URL{Alias: alias, Original: "https://google.com", Title: "old", Description: "desc"}, nil
+		},
+		updateF: func(u url.URL) error {
+			saved = u
+			return nil
+		},
+	}
+	s := New(um, nil)
+
+	title := "  Spring sale "
+	u, err := s.UpdateURL(t.Context(), "", "abc", url.Patch{Title: &title})
+	if err != nil || saved.Title != "Spring sale" || saved.Description != "desc" {
+		t.Errorf("Service.UpdateURL() saved = %+v, error = %v", saved, err)
+	}
+	if u.UpdatedAt.IsZero() {
+		t.Errorf("Service.UpdateURL() updated_at isn't set")
+	}
+
+	long := strings.Repeat("я", MaxTitleLen+1)
+	if _, err = s.UpdateURL(t.Context(), "", "abc", url.Patch{Title: &long}); !errors.Is(err, ErrNotValidData) {
+		t.Errorf("Service.UpdateURL() error = %v, want %v", err, ErrNotValidData)
+	}
+	_, err = s.CreateURL(t.Context(), url.URL{Original: "https://google.com", Description: strings.Repeat("a", MaxDescriptionLen+1)})
+	if !errors.Is(err, ErrNotValidData) {
+		t.Errorf("Service.CreateURL() error = %v, want %v", err, ErrNotValidData)
+	}
+}
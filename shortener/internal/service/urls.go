@@ -1,18 +1,68 @@
 package service
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	parser "net/url"
 	"shortener/internal/entities/url"
 	"shortener/internal/storage"
+	"strings"
+	"time"
+	"unicode/utf8"
 )
 
 const (
+	MaxTitleLen       = 256
+	MaxDescriptionLen = 1024
+
 	AliasLen = 6
+	// GenerateAttempts how many generated aliases are tried before giving up
+	GenerateAttempts = 10
+	// PolicyAttempts how many generated aliases may be rejected by alias
+	// policy in a row
+	PolicyAttempts = 100
 )
 
-func (s *Service) CreateURL(u url.URL) (string, error) {
+// newAlias generates alias with gen which satisfies alias policy.
+func (s *Service) newAlias(ctx context.Context, gen AliasGenerator) (string, error) {
+	for i := 0; i < PolicyAttempts; i++ {
+		alias, err := gen.Generate(ctx)
+		if err != nil {
+			return "", err
+		}
+		alias = s.policy.Normalize(alias)
+		if s.policy.Validate(alias) == nil {
+			return alias, nil
+		}
+	}
+
+	return "", errors.New("alias policy rejects generated aliases")
+}
+
+func validOriginal(s string) bool {
+	original, err := parser.Parse(s)
+	return err == nil && original.Host != "" && original.Scheme != ""
+}
+
+// normalizeMeta trims title and description of link and checks their length.
+func normalizeMeta(title, description string) (string, string, error) {
+	title = strings.TrimSpace(title)
+	description = strings.TrimSpace(description)
+	if utf8.RuneCountInString(title) > MaxTitleLen {
+		return "", "", fmt.Errorf(
+			"%w: title is longer than %d characters", ErrNotValidData, MaxTitleLen,
+		)
+	}
+	if utf8.RuneCountInString(description) > MaxDescriptionLen {
+		return "", "", fmt.Errorf(
+			"%w: description is longer than %d characters", ErrNotValidData, MaxDescriptionLen,
+		)
+	}
+	return title, description, nil
+}
+
+func (s *Service) CreateURL(ctx context.Context, u url.URL) (string, error) {
 	const op = "internal.service.url.Create"
 
 	var err error
@@ -20,22 +70,107 @@ func (s *Service) CreateURL(u url.URL) (string, error) {
 	if u.Original == "" {
 		return "", fmt.Errorf("%w: %s", ErrNotValidData, "empty original link")
 	}
-	original, err := parser.Parse(u.Original)
-	if err != nil || original.Host == "" || original.Scheme == "" {
+	if !validOriginal(u.Original) {
+		return "", fmt.Errorf("%w: %s", ErrNotValidData, "url is not valid (scheme://host/path)")
+	}
+	u.GeoTargets, err = validateGeoTargets(u.GeoTargets)
+	if err != nil {
+		return "", err
+	}
+	err = s.checkDestination(u.Original)
+	if err != nil {
+		return "", err
+	}
+	for _, target := range u.GeoTargets {
+		err = s.checkDestination(target)
+		if err != nil {
+			return "", err
+		}
+	}
+	u.UTM = trimUTM(u.UTM)
+	u.Tags, err = normalizeTags(u.Tags)
+	if err != nil {
+		return "", err
+	}
+	u.Folder, err = normalizeFolder(u.Folder)
+	if err != nil {
+		return "", err
+	}
+	u.Title, u.Description, err = normalizeMeta(u.Title, u.Description)
+	if err != nil {
+		return "", err
+	}
+	if u.Domain != "" {
+		u.Domain = normalizeHost(u.Domain)
+		if s.namespace(u.Domain) != u.Domain {
+			return "", fmt.Errorf("%w: %s", ErrNotValidData, "unknown domain")
+		}
+	}
+
+	normalized, err := normalizeOriginal(u.Original)
+	if err != nil {
 		return "", fmt.Errorf("%w: %s", ErrNotValidData, "url is not valid (scheme://host/path)")
 	}
 
+	var gen AliasGenerator
+	switch u.AliasMode {
+	case "":
+		gen = s.gen
+	case AliasModeWords:
+		gen = s.words
+	default:
+		return "", fmt.Errorf("%w: %s", ErrNotValidData, "unknown alias mode")
+	}
+
+	// explicitly requested alias always gets its own link, only links with
+	// hash are reused and hash is unique per owner and domain
+	u.OriginalHash = ""
+	if u.ReuseExisting && u.Alias == "" {
+		u.OriginalHash = reuseHash(normalized, u)
+		existing, err := s.urler.URLByHash(ctx, u.Owner, u.Domain, u.OriginalHash)
+		if err == nil {
+			return existing.Alias, nil
+		} else if !errors.Is(err, storage.ErrNotFound) {
+			return "", fmt.Errorf("%s: %w(%w)", op, ErrStorageInternal, err)
+		}
+	}
+
 	genAlias := false
 	if u.Alias == "" {
-		u.Alias = generateAlias(AliasLen)
+		u.Alias, err = s.newAlias(ctx, gen)
+		if err != nil {
+			return "", fmt.Errorf("%s: %w(%w)", op, ErrGenerator, err)
+		}
 		genAlias = true
+	} else {
+		u.Alias = s.policy.Normalize(u.Alias)
+		err = s.policy.Validate(u.Alias)
+		if err != nil {
+			return "", err
+		}
+	}
+
+	err = s.checkChain(ctx, u)
+	if err != nil {
+		return "", err
 	}
 
-	var alias string
-	for i := 0; i < 10; i++ {
-		alias, err = s.urler.CreateURL(u)
+	for i := 0; i < GenerateAttempts; i++ {
+		alias, err := s.urler.CreateURL(ctx, u)
+		if errors.Is(err, storage.ErrNotUnique) && u.OriginalHash != "" {
+			// the same link may have been created concurrently
+			existing, err := s.urler.URLByHash(ctx, u.Owner, u.Domain, u.OriginalHash)
+			if err == nil {
+				return existing.Alias, nil
+			} else if !errors.Is(err, storage.ErrNotFound) {
+				return "", fmt.Errorf("%s: %w(%w)", op, ErrStorageInternal, err)
+			}
+		}
 		if errors.Is(err, storage.ErrNotUnique) && genAlias {
-			u.Alias = generateAlias(AliasLen + i)
+			u.Alias, err = s.newAlias(ctx, gen)
+			if err != nil {
+				return "", fmt.Errorf("%s: %w(%w)", op, ErrGenerator, err)
+			}
 			continue
 		} else if errors.Is(err, storage.ErrNotUnique) {
 			return alias, ErrNotUnique
@@ -43,22 +178,75 @@ func (s *Service) CreateURL(u url.URL) (string, error) {
 			return alias, fmt.Errorf("%s: %w(%w)", op, ErrStorageInternal, err)
 		}
 
-		break
+		return alias, nil
+	}
+
+	return "", fmt.Errorf(
+		"%s: %w: no free alias after %d attempts", op, ErrNotUnique, GenerateAttempts,
+	)
+}
+
+// UpdateURL applies patch to link requested on host, unknown host isn't
+// treated as default domain.
+func (s *Service) UpdateURL(ctx context.Context, host, alias string, p url.Patch) (url.URL, error) {
+	const op = "internal.service.url.Update"
+
+	if host != "" && s.namespace(host) != normalizeHost(host) {
+		return url.URL{}, fmt.Errorf("%w: %s", ErrNotValidData, "unknown domain")
+	}
+	u, err := s.URL(ctx, host, alias)
+	if err != nil {
+		return u, err
+	}
+
+	if p.Tags != nil {
+		u.Tags, err = normalizeTags(*p.Tags)
+		if err != nil {
+			return u, err
+		}
+	}
+	if p.Folder != nil {
+		u.Folder, err = normalizeFolder(*p.Folder)
+		if err != nil {
+			return u, err
+		}
+	}
+	if p.Title != nil {
+		u.Title = *p.Title
+	}
+	if p.Description != nil {
+		u.Description = *p.Description
+	}
+	u.Title, u.Description, err = normalizeMeta(u.Title, u.Description)
+	if err != nil {
+		return u, err
+	}
+	// settings of link differ from requested on creation now
+	u.OriginalHash = ""
+
+	err = s.urler.UpdateURL(ctx, u)
+	if errors.Is(err, storage.ErrNotFound) {
+		return u, ErrNotFound
+	} else if err != nil {
+		return u, fmt.Errorf("%s: %w(%w)", op, ErrStorageInternal, err)
 	}
+	u.UpdatedAt = time.Now().UTC()
 
-	return alias, nil
+	return u, nil
 }
 
-func (s *Service) URL(alias string) (string, error) {
+// URL returns link by alias requested on host, aliases of registered custom
+// domains are looked up in namespace of that domain.
+func (s *Service) URL(ctx context.Context, host, alias string) (url.URL, error) {
 	const op = "internal.service.url.Get"
 
 	if alias == "" {
-		return "", fmt.Errorf(
+		return url.URL{}, fmt.Errorf(
 			"%w: %s", ErrNotValidData, "empty alias",
 		)
 	}
 
-	u, err := s.urler.URL(alias)
+	u, err := s.urler.URL(ctx, s.namespace(host), s.policy.Normalize(alias))
 	if errors.Is(err, storage.ErrNotFound) {
 		return u, ErrNotFound
 	} else if err != nil {
@@ -67,3 +255,45 @@ func (s *Service) URL(alias string) (string, error) {
 
 	return u, nil
 }
+
+// Destination returns url where visitor of short link must be redirected.
+func (s *Service) Destination(u url.URL, v url.Visit) string {
+	dst := u.Original
+	if target := s.geoTarget(u, v); target != "" {
+		dst = target
+	}
+	if !u.ForwardQuery && u.UTM.Empty() {
+		return dst
+	}
+
+	return withQuery(dst, u, v)
+}
+
+// URLs returns page of links matching filter with their click counts.
+func (s *Service) URLs(ctx context.Context, f url.Filter) ([]url.Item, error) {
+	const op = "internal.service.url.List"
+
+	if f.Page < 0 {
+		return nil, fmt.Errorf("%w: %s", ErrNotValidData, "page must be positive")
+	}
+	switch f.Sort {
+	case "", url.SortCreated, url.SortClicks:
+	default:
+		return nil, fmt.Errorf("%w: %s", ErrNotValidData, "sort must be created or clicks")
+	}
+	switch f.Status {
+	case "", url.StatusOK, url.StatusBroken, url.StatusUnchecked:
+	default:
+		return nil, fmt.Errorf("%w: %s", ErrNotValidData, "status must be ok, broken or unchecked")
+	}
+	f.Tag = strings.ToLower(strings.TrimSpace(f.Tag))
+	f.Folder = strings.Trim(strings.TrimSpace(f.Folder), "/")
+	f.Query = strings.TrimSpace(f.Query)
+
+	res, err := s.urler.URLs(ctx, f)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w(%w)", op, ErrStorageInternal, err)
+	}
+
+	return res, nil
+}
@@ -1,28 +1,41 @@
 package service
 
 import (
-	"math/rand/v2"
-	"strings"
-	"time"
+	"crypto/rand"
 )
 
-var (
-	seed = rand.NewPCG(
-		uint64(time.Now().UnixMicro()),
-		uint64(time.Now().Add(time.Millisecond*100).UnixMicro()),
-	)
+const (
+	DefaultAlphabet = "qwertyuiopasdfghjklzxcvbnm1234567890"
+
+	// MaxAlphabetLen every character must be addressable by one random byte
+	MaxAlphabetLen = 256
 )
 
-func generateAlias(l int) string {
-	res := strings.Builder{}
-	res.Grow(l)
-	rd := rand.New(
-		seed,
-	)
+// generateAlias draws l characters from alphabet using crypto/rand, so
+// aliases can't be predicted from previously issued ones. Bytes which would
+// make modulo biased towards first characters are rejected. Alphabet is
+// indexed by byte, so it must be ASCII. Safe for concurrent use.
+func generateAlias(alphabet string, l int) string {
+	n := len(alphabet)
+	// largest multiple of n which fits in byte, bytes above are rejected
+	limit := MaxAlphabetLen - MaxAlphabetLen%n
 
-	alphabet := "qwertyuiopasdfghjklzxcvbnm1234567890"
-	for i := 0; i < l; i++ {
-		res.WriteByte(alphabet[rd.IntN(len(alphabet))])
+	res := make([]byte, 0, l)
+	// rejection rate is below 50%, so twice the length is usually enough
+	buf := make([]byte, l*2)
+	for len(res) < l {
+		// never returns error, see crypto/rand.Read
+		_, _ = rand.Read(buf)
+		for _, b := range buf {
+			if int(b) >= limit {
+				continue
+			}
+			res = append(res, alphabet[int(b)%n])
+			if len(res) == l {
+				break
+			}
+		}
 	}
-	return res.String()
+
+	return string(res)
 }
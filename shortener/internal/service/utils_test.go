@@ -0,0 +1,99 @@
+package service
+
+import (
+	"math/rand/v2"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// legacyGenerateAlias previous math/rand implementation, kept for benchmark.
+func legacyGenerateAlias(seed *rand.PCG, alphabet string, l int) string {
+	res := strings.Builder{}
+	res.Grow(l)
+	rd := rand.New(seed)
+	for i := 0; i < l; i++ {
+		res.WriteByte(alphabet[rd.IntN(len(alphabet))])
+	}
+	return res.String()
+}
+
+// TestGenerateAliasUniform chi-squared test of characters distribution.
+// Alphabet of 36 characters doesn't divide 256, so plain modulo of random
+// byte would favour first 4 characters by ~14% and fail the test.
+func TestGenerateAliasUniform(t *testing.T) {
+	const (
+		perChar = 10000
+		// chi-squared critical value for 35 degrees of freedom, p = 0.001
+		critical = 66.62
+	)
+
+	alphabet := DefaultAlphabet
+	counts := make(map[byte]int, len(alphabet))
+	total := perChar * len(alphabet)
+	for _, c := range []byte(generateAlias(alphabet, total)) {
+		counts[c]++
+	}
+
+	if len(counts) != len(alphabet) {
+		t.Fatalf("generateAlias() used %d characters, want %d", len(counts), len(alphabet))
+	}
+	chi := 0.0
+	for _, c := range []byte(alphabet) {
+		d := float64(counts[c] - perChar)
+		chi += d * d / perChar
+	}
+	if chi > critical {
+		t.Errorf("generateAlias() distribution is not uniform, chi2 = %.2f > %.2f", chi, critical)
+	}
+}
+
+func TestGenerateAliasConcurrent(t *testing.T) {
+	const workers, perWorker = 16, 2000
+
+	var mu sync.Mutex
+	seen := make(map[string]struct{}, workers*perWorker)
+	wg := sync.WaitGroup{}
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := 0; i < perWorker; i++ {
+				alias := generateAlias(DefaultAlphabet, 12)
+				mu.Lock()
+				seen[alias] = struct{}{}
+				mu.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+
+	if len(seen) != workers*perWorker {
+		t.Errorf("generateAlias() produced %d duplicates", workers*perWorker-len(seen))
+	}
+}
+
+func BenchmarkGenerateAlias(b *testing.B) {
+	for i := 0; i < b.N; i++ {
+		_ = generateAlias(DefaultAlphabet, AliasLen)
+	}
+}
+
+func BenchmarkGenerateAliasParallel(b *testing.B) {
+	b.RunParallel(func(pb *testing.PB) {
+		for pb.Next() {
+			_ = generateAlias(DefaultAlphabet, AliasLen)
+		}
+	})
+}
+
+func BenchmarkLegacyGenerateAlias(b *testing.B) {
+	seed := rand.NewPCG(
+		uint64(time.Now().UnixMicro()),
+		uint64(time.Now().Add(time.Millisecond*100).UnixMicro()),
+	)
+	for i := 0; i < b.N; i++ {
+		_ = legacyGenerateAlias(seed, DefaultAlphabet, AliasLen)
+	}
+}
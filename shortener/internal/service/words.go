@@ -0,0 +1,101 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	_ "embed"
+	"fmt"
+	"math/big"
+	"strings"
+)
+
+const (
+	AliasModeWords = "words"
+
+	DefaultWordsCount  = 2
+	DefaultWordsDigits = 2
+
+	// wordsDigits without 0 and 1 which are read as "o" and "l"
+	wordsDigits    = "23456789"
+	wordsSeparator = "-"
+)
+
+var (
+	//go:embed words/adjectives.txt
+	adjectivesList string
+	//go:embed words/nouns.txt
+	nounsList string
+)
+
+// WordsGenerator builds readable aliases like "brave-otter-42" for links
+// which are read aloud or printed. Last word is noun, previous ones are
+// adjectives, digits are optional.
+type WordsGenerator struct {
+	adjectives []string
+	nouns      []string
+	words      int
+	digits     int
+}
+
+func NewWordsGenerator(words, digits int) (*WordsGenerator, error) {
+	if words < 1 {
+		return nil, fmt.Errorf("words generator: at least one word is required")
+	}
+	if digits < 0 {
+		return nil, fmt.Errorf("words generator: negative digits count")
+	}
+
+	return &WordsGenerator{
+		adjectives: strings.Fields(adjectivesList),
+		nouns:      strings.Fields(nounsList),
+		words:      words,
+		digits:     digits,
+	}, nil
+}
+
+// Alphabet returns all characters of words, digits and separator.
+func (g *WordsGenerator) Alphabet() string {
+	seen := make(map[rune]struct{})
+	var b strings.Builder
+	for _, w := range append(append([]string{wordsDigits, wordsSeparator}, g.adjectives...), g.nouns...) {
+		for _, r := range w {
+			if _, ok := seen[r]; !ok {
+				seen[r] = struct{}{}
+				b.WriteRune(r)
+			}
+		}
+	}
+	return b.String()
+}
+
+func (g *WordsGenerator) Generate(_ context.Context) (string, error) {
+	const op = "internal.service.WordsGenerator.Generate"
+
+	parts := make([]string, 0, g.words+1)
+	for i := 0; i < g.words-1; i++ {
+		w, err := pick(g.adjectives)
+		if err != nil {
+			return "", fmt.Errorf("%s: %w", op, err)
+		}
+		parts = append(parts, w)
+	}
+	w, err := pick(g.nouns)
+	if err != nil {
+		return "", fmt.Errorf("%s: %w", op, err)
+	}
+	parts = append(parts, w)
+
+	if g.digits > 0 {
+		parts = append(parts, generateAlias(wordsDigits, g.digits))
+	}
+
+	return strings.Join(parts, wordsSeparator), nil
+}
+
+func pick(words []string) (string, error) {
+	i, err := rand.Int(rand.Reader, big.NewInt(int64(len(words))))
+	if err != nil {
+		return "", err
+	}
+	return words[i.Int64()], nil
+}
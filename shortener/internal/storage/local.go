@@ -0,0 +1,75 @@
+package storage
+
+import (
+	"context"
+	"shortener/internal/entities/url"
+	"time"
+
+	"github.com/hashicorp/golang-lru/v2/expirable"
+	"github.com/wb-go/wbf/zlog"
+)
+
+const (
+	DefaultLocalCacheSize = 1000
+	DefaultLocalCacheTTL  = 10 * time.Second
+)
+
+// Invalidator broadcasts changes of links to all instances of service, so
+// they drop their local copies.
+type Invalidator interface {
+	PublishInvalidation(ctx context.Context, domain, alias string) error
+	// Invalidations calls drop for every change published by any instance
+	// until ctx is done. Messages may be lost while connection is broken,
+	// purge is called once it's restored.
+	Invalidations(ctx context.Context, drop func(domain, alias string), purge func())
+}
+
+// WithLocalCache keeps up to size found links in process for ttl in front
+// of cache. Changes made by other instances are received if cache is
+// Invalidator, otherwise local copies are stale until ttl expires.
+func WithLocalCache(size int, ttl time.Duration) Option {
+	return func(s *Storage) {
+		if size <= 0 || ttl <= 0 {
+			s.local = nil
+			return
+		}
+		s.local = expirable.NewLRU[string, url.URL](size, nil, ttl)
+	}
+}
+
+// runInvalidations drops local copies of links changed by any instance.
+func (s *Storage) runInvalidations(inv Invalidator) {
+	s.wg.Add(1)
+	go func() {
+		defer s.wg.Done()
+		inv.Invalidations(s.ctx, func(domain, alias string) {
+			s.local.Remove(localKey(domain, alias))
+		}, s.local.Purge)
+	}()
+}
+
+// invalidate drops link from both cache tiers of all instances.
+func (s *Storage) invalidate(ctx context.Context, op, domain, alias string) {
+	cacheCtx, cancel := withTimeout(ctx, s.timeouts.Cache)
+	defer cancel()
+
+	err := s.c.DeleteURL(cacheCtx, domain, alias)
+	if err != nil {
+		zlog.Logger.Error().Err(err).Fields(map[string]any{"op": op}).Send()
+	}
+	if s.local == nil {
+		return
+	}
+
+	s.local.Remove(localKey(domain, alias))
+	if inv, ok := s.c.(Invalidator); ok {
+		err = inv.PublishInvalidation(cacheCtx, domain, alias)
+		if err != nil {
+			zlog.Logger.Error().Err(err).Fields(map[string]any{"op": op}).Send()
+		}
+	}
+}
+
+func localKey(domain, alias string) string {
+	return domain + "/" + alias
+}
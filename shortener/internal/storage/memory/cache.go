@@ -0,0 +1,105 @@
+package memory
+
+import (
+	"context"
+	"shortener/internal/entities/url"
+	"shortener/internal/storage"
+	"sync"
+	"time"
+)
+
+// Cache in-process cache of links, it's used instead of redis when there is
+// a single instance of service.
+type Cache struct {
+	mu   *sync.RWMutex
+	urls map[string]url.URL
+	// missing expiration times of missing marks
+	missing map[string]time.Time
+	// sweepAt number of marks which triggers drop of expired ones, it grows
+	// with live marks, so sweeps stay cheap per added mark
+	sweepAt int
+}
+
+// minSweep marks kept before the first sweep of expired ones.
+const minSweep = 1024
+
+func NewCache() *Cache {
+	return &Cache{
+		mu:      new(sync.RWMutex),
+		urls:    make(map[string]url.URL),
+		missing: make(map[string]time.Time),
+		sweepAt: minSweep,
+	}
+}
+
+func (c *Cache) AddURL(_ context.Context, u url.URL) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	k := key(u.Domain, u.Alias)
+	c.urls[k] = clone(u)
+	delete(c.missing, k)
+	return nil
+}
+
+// AddMissing remembers missing link for ttl, expired marks are dropped on
+// lookup and swept once they pile up, e.g. from scans of random aliases.
+func (c *Cache) AddMissing(_ context.Context, domain, alias string, ttl time.Duration) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	now := time.Now()
+	if len(c.missing) >= c.sweepAt {
+		for k, expires := range c.missing {
+			if !now.Before(expires) {
+				delete(c.missing, k)
+			}
+		}
+		c.sweepAt = max(2*len(c.missing), minSweep)
+	}
+
+	k := key(domain, alias)
+	delete(c.urls, k)
+	c.missing[k] = now.Add(ttl)
+	return nil
+}
+
+// URL returns cached link or empty one on miss.
+func (c *Cache) URL(_ context.Context, domain, alias string) (url.URL, error) {
+	k := key(domain, alias)
+
+	c.mu.RLock()
+	u, ok := c.urls[k]
+	expires, missing := c.missing[k]
+	c.mu.RUnlock()
+
+	if ok {
+		return clone(u), nil
+	}
+	if !missing {
+		return url.URL{}, nil
+	}
+	if time.Now().Before(expires) {
+		return url.URL{}, storage.ErrNotFound
+	}
+
+	c.mu.Lock()
+	if c.missing[k] == expires {
+		delete(c.missing, k)
+	}
+	c.mu.Unlock()
+	return url.URL{}, nil
+}
+
+// DeleteURL drops cached link, so changes are read from db on next lookup.
+func (c *Cache) DeleteURL(_ context.Context, domain, alias string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	k := key(domain, alias)
+	delete(c.urls, k)
+	delete(c.missing, k)
+	return nil
+}
+
+func (c *Cache) Shutdown() {}
@@ -0,0 +1,37 @@
+package memory
+
+import (
+	"errors"
+	"fmt"
+	"shortener/internal/storage"
+	"testing"
+	"time"
+)
+
+func TestCache_SweepMissing(t *testing.T) {
+	c := NewCache()
+	ctx := t.Context()
+
+	// scan of random aliases which are never looked up again
+	for i := 0; i < 10*minSweep; i++ {
+		err := c.AddMissing(ctx, "", fmt.Sprintf("scan%d", i), time.Nanosecond)
+		if err != nil {
+			t.Fatalf("AddMissing() = %v", err)
+		}
+	}
+	if n := len(c.missing); n > 2*minSweep {
+		t.Errorf("expired marks kept = %d, want at most %d", n, 2*minSweep)
+	}
+
+	// live marks survive sweeps
+	err := c.AddMissing(ctx, "", "live", time.Hour)
+	if err != nil {
+		t.Fatalf("AddMissing() = %v", err)
+	}
+	for i := 0; i < 2*minSweep; i++ {
+		_ = c.AddMissing(ctx, "", fmt.Sprintf("more%d", i), time.Nanosecond)
+	}
+	if _, err := c.URL(ctx, "", "live"); !errors.Is(err, storage.ErrNotFound) {
+		t.Errorf("URL() of live mark = %v, want %v", err, storage.ErrNotFound)
+	}
+}
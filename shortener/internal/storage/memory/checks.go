@@ -0,0 +1,114 @@
+package memory
+
+import (
+	"context"
+	"fmt"
+	"shortener/internal/entities/health"
+	"shortener/internal/entities/url"
+	"slices"
+	"time"
+)
+
+// TryLockChecks always succeeds, storage serves single instance.
+func (m *Memory) TryLockChecks(_ context.Context) (func(), bool, error) {
+	return func() {}, true, nil
+}
+
+// URLsToCheck returns links never checked or checked before given time,
+// least recently checked first.
+func (m *Memory) URLsToCheck(_ context.Context, before time.Time, limit int) ([]url.URL, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	type candidate struct {
+		u         url.URL
+		checkedAt time.Time
+	}
+	var candidates []candidate
+	for _, u := range m.urls {
+		c, checked := m.checks[u.ID]
+		if checked && !c.CheckedAt.Before(before) {
+			continue
+		}
+		candidates = append(candidates, candidate{
+			u: url.URL{
+				ID: u.ID, Alias: u.Alias, Domain: u.Domain,
+				Original: u.Original, PageTitle: u.PageTitle,
+			},
+			checkedAt: c.CheckedAt,
+		})
+	}
+
+	// never checked links have zero time, so they go first
+	slices.SortStableFunc(candidates, func(a, b candidate) int {
+		return a.checkedAt.Compare(b.checkedAt)
+	})
+
+	res := make([]url.URL, 0, min(limit, len(candidates)))
+	for i := 0; i < len(candidates) && i < limit; i++ {
+		res = append(res, candidates[i].u)
+	}
+
+	return res, nil
+}
+
+func (m *Memory) SaveCheck(_ context.Context, c health.Check) error {
+	const op = "internal.storage.memory.checks.Save"
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if c.URLID < 1 || int(c.URLID) > len(m.urls) {
+		return fmt.Errorf("%s: %w", op, ErrNoURL)
+	}
+	m.checks[c.URLID] = health.Check{
+		URLID:     c.URLID,
+		Status:    c.Status,
+		Latency:   c.Latency.Truncate(time.Millisecond),
+		Error:     c.Error,
+		CheckedAt: c.CheckedAt,
+	}
+
+	return nil
+}
+
+// SetPageTitle saves title of destination page fetched by health checker.
+func (m *Memory) SetPageTitle(_ context.Context, urlID int64, title string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if urlID >= 1 && int(urlID) <= len(m.urls) {
+		m.urls[urlID-1].PageTitle = title
+	}
+
+	return nil
+}
+
+// BrokenLinks returns links which destination failed last check, recently
+// checked first.
+func (m *Memory) BrokenLinks(_ context.Context, p int) ([]health.Check, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	if p < 1 {
+		p = 1
+	}
+
+	res := make([]health.Check, 0)
+	for _, c := range m.checks {
+		if !c.Broken() {
+			continue
+		}
+		u := m.urls[c.URLID-1]
+		c.Alias, c.Domain, c.Original = u.Alias, u.Domain, u.Original
+		res = append(res, c)
+	}
+	slices.SortFunc(res, func(a, b health.Check) int {
+		if c := b.CheckedAt.Compare(a.CheckedAt); c != 0 {
+			return c
+		}
+		return compare(a.URLID, b.URLID)
+	})
+
+	return page(res, p, health.PageSize), nil
+}
@@ -0,0 +1,52 @@
+package memory
+
+import (
+	"context"
+	"fmt"
+	"maps"
+	"shortener/internal/entities/domain"
+	"slices"
+	"strings"
+	"time"
+)
+
+func (m *Memory) CreateDomain(_ context.Context, host string) (domain.Domain, error) {
+	const op = "internal.storage.memory.domain.Create"
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if _, ok := m.domains[host]; ok {
+		return domain.Domain{Host: host}, fmt.Errorf("%s: %w", op, ErrNotUnique)
+	}
+
+	d := domain.Domain{Host: host, CreatedAt: time.Now().UTC()}
+	m.domains[host] = d
+
+	return d, nil
+}
+
+func (m *Memory) Domains(_ context.Context) ([]domain.Domain, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	res := slices.Collect(maps.Values(m.domains))
+	slices.SortFunc(res, func(a, b domain.Domain) int {
+		return strings.Compare(a.Host, b.Host)
+	})
+	if res == nil {
+		res = make([]domain.Domain, 0)
+	}
+
+	return res, nil
+}
+
+func (m *Memory) DeleteDomain(_ context.Context, host string) (bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	_, ok := m.domains[host]
+	delete(m.domains, host)
+
+	return ok, nil
+}
@@ -0,0 +1,62 @@
+package memory
+
+import (
+	"context"
+	"errors"
+	"shortener/internal/entities/domain"
+	"shortener/internal/entities/health"
+	"shortener/internal/entities/redirect"
+	"shortener/internal/entities/url"
+	"shortener/internal/storage/postgres"
+	"sync"
+)
+
+var (
+	ErrNotUnique = errors.New("not unique field value")
+	ErrNoURL     = errors.New("link doesn't exist")
+)
+
+// Memory keeps everything in process memory, it is meant for tests and
+// single-binary deployments where losing data on restart is acceptable.
+// Filters, ordering and pagination follow postgres.Postgres.
+type Memory struct {
+	mu *sync.RWMutex
+
+	urls      []url.URL
+	byAlias   map[string]int
+	aliasSeq  int64
+	redirects []redirect.Redirect
+	domains   map[string]domain.Domain
+	checks    map[int64]health.Check
+}
+
+func New() *Memory {
+	return &Memory{
+		mu:      new(sync.RWMutex),
+		byAlias: make(map[string]int),
+		domains: make(map[string]domain.Domain),
+		checks:  make(map[int64]health.Check),
+	}
+}
+
+func key(domain, alias string) string {
+	return domain + "/" + alias
+}
+
+// NextAliasID returns next value of sequence used by alias generators.
+func (m *Memory) NextAliasID(_ context.Context) (int64, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.aliasSeq++
+	return m.aliasSeq, nil
+}
+
+func (m *Memory) HandleError(err error) error {
+	if errors.Is(err, ErrNotUnique) {
+		return postgres.ErrNotUnique
+	}
+	return err
+}
+
+func (m *Memory) Shutdown() {}
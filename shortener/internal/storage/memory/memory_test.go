@@ -0,0 +1,12 @@
+package memory_test
+
+import (
+	"shortener/internal/storage"
+	"shortener/internal/storage/memory"
+	"shortener/internal/storage/storagetest"
+	"testing"
+)
+
+func TestMemory(t *testing.T) {
+	storagetest.Run(t, storage.New(memory.New(), memory.NewCache()))
+}
@@ -0,0 +1,173 @@
+package memory
+
+import (
+	"context"
+	"shortener/internal/entities/redirect"
+	"slices"
+	"strings"
+	"time"
+)
+
+func (m *Memory) CreateRedirects(_ context.Context, redirects []redirect.Redirect) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for _, r := range redirects {
+		r.ID = int64(len(m.redirects) + 1)
+		m.redirects = append(m.redirects, r)
+	}
+
+	return nil
+}
+
+func (m *Memory) Redirects(_ context.Context, alias string) ([]redirect.Redirect, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	var res []redirect.Redirect
+	for _, r := range m.redirects {
+		if r.Alias == alias {
+			res = append(res, r)
+		}
+	}
+
+	return res, nil
+}
+
+// filterValue returns value of column which is allowed in analytics filter.
+func filterValue(r redirect.Redirect, column string) string {
+	switch column {
+	case redirect.FilterUserAgent:
+		return r.UserAgent
+	case redirect.FilterUTMSource:
+		return r.UTM.Source
+	case redirect.FilterUTMMedium:
+		return r.UTM.Medium
+	case redirect.FilterUTMCampaign:
+		return r.UTM.Campaign
+	case redirect.FilterUTMTerm:
+		return r.UTM.Term
+	case redirect.FilterUTMContent:
+		return r.UTM.Content
+	}
+	return ""
+}
+
+// inRange reports whether date is within optional inclusive bounds in
+// time.DateTime format.
+func inRange(date time.Time, start, end string) (bool, error) {
+	if start != "" {
+		s, err := time.Parse(time.DateTime, start)
+		if err != nil {
+			return false, err
+		}
+		if date.Before(s) {
+			return false, nil
+		}
+	}
+	if end != "" {
+		e, err := time.Parse(time.DateTime, end)
+		if err != nil {
+			return false, err
+		}
+		if date.After(e) {
+			return false, nil
+		}
+	}
+	return true, nil
+}
+
+// AgrigatedRedirects returns page of redirects of alias ordered by date,
+// Total counts all redirects of alias regardless of filters.
+func (m *Memory) AgrigatedRedirects(_ context.Context, opts redirect.AgrigateOpts) (redirect.Agrigated, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	from, to, err := opts.DayRange()
+	if err != nil {
+		return redirect.Agrigated{}, err
+	}
+
+	res := redirect.Agrigated{Alias: opts.Alias, Domain: opts.Domain}
+	var matched, days []redirect.Redirect
+	for _, r := range m.redirects {
+		if r.Alias != opts.Alias || r.Domain != opts.Domain {
+			continue
+		}
+		res.Total++
+		if (from.IsZero() || !r.Date.Before(from)) && (to.IsZero() || r.Date.Before(to)) {
+			days = append(days, r)
+		}
+		if opts.Summary {
+			continue
+		}
+
+		ok, err := inRange(r.Date, opts.StartDate, opts.EndDate)
+		if err != nil {
+			return redirect.Agrigated{}, err
+		}
+		if !ok {
+			continue
+		}
+		if redirect.ValidFilter(opts.FilterColumn) && opts.ValueForFilter != "" &&
+			!strings.Contains(filterValue(r, opts.FilterColumn), opts.ValueForFilter) {
+			continue
+		}
+		matched = append(matched, r)
+	}
+
+	slices.SortStableFunc(matched, func(a, b redirect.Redirect) int {
+		return a.Date.Compare(b.Date)
+	})
+	res.Redirects = page(matched, opts.Page, redirect.PageSize)
+	if len(res.Redirects) == 0 {
+		res.Redirects = nil
+	}
+	res.Daily = redirect.Daily(days)
+
+	return res, nil
+}
+
+// TagStats counts redirects of every link with tag, links without redirects
+// are included with zero clicks.
+func (m *Memory) TagStats(_ context.Context, opts redirect.TagOpts) (redirect.TagStats, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	res := redirect.TagStats{Tag: opts.Tag, Links: make([]redirect.LinkClicks, 0)}
+	ids := make(map[string]int64)
+	for _, u := range m.urls {
+		if slices.Contains(u.Tags, opts.Tag) {
+			res.Links = append(res.Links, redirect.LinkClicks{Alias: u.Alias, Domain: u.Domain})
+			ids[key(u.Domain, u.Alias)] = u.ID
+		}
+	}
+
+	clicks := make(map[string]int64)
+	for _, r := range m.redirects {
+		k := key(r.Domain, r.Alias)
+		if _, ok := ids[k]; !ok {
+			continue
+		}
+		ok, err := inRange(r.Date, opts.StartDate, opts.EndDate)
+		if err != nil {
+			return res, err
+		}
+		if ok {
+			clicks[k]++
+		}
+	}
+
+	for i := range res.Links {
+		res.Links[i].Clicks = clicks[key(res.Links[i].Domain, res.Links[i].Alias)]
+		res.Total += res.Links[i].Clicks
+	}
+	slices.SortStableFunc(res.Links, func(a, b redirect.LinkClicks) int {
+		if c := compare(b.Clicks, a.Clicks); c != 0 {
+			return c
+		}
+		return compare(ids[key(a.Domain, a.Alias)], ids[key(b.Domain, b.Alias)])
+	})
+
+	return res, nil
+}
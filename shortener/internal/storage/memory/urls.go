@@ -0,0 +1,230 @@
+package memory
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"maps"
+	"shortener/internal/entities/url"
+	"slices"
+	"strings"
+	"time"
+)
+
+// clone copies maps and slices of link, so stored links can't be changed
+// by callers.
+func clone(u url.URL) url.URL {
+	u.GeoTargets = maps.Clone(u.GeoTargets)
+	if u.GeoTargets == nil {
+		u.GeoTargets = map[string]string{}
+	}
+	u.Tags = slices.Clone(u.Tags)
+	if u.Tags == nil {
+		u.Tags = []string{}
+	}
+	return u
+}
+
+func sortedTags(tags []string) []string {
+	res := slices.Clone(tags)
+	slices.Sort(res)
+	return slices.Compact(res)
+}
+
+func (m *Memory) CreateURL(_ context.Context, u url.URL) (string, error) {
+	const op = "internal.storage.memory.url.Create"
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	k := key(u.Domain, u.Alias)
+	if _, ok := m.byAlias[k]; ok {
+		return "", fmt.Errorf("%s: %w", op, ErrNotUnique)
+	}
+	if m.hashTaken(u, -1) {
+		return "", fmt.Errorf("%s: %w", op, ErrNotUnique)
+	}
+
+	now := time.Now().UTC()
+	u = clone(u)
+	u.ID = int64(len(m.urls) + 1)
+	u.Tags = sortedTags(u.Tags)
+	u.PageTitle = ""
+	u.CreatedAt = now
+	u.UpdatedAt = now
+	u.AliasMode = ""
+	u.ReuseExisting = false
+
+	m.urls = append(m.urls, u)
+	m.byAlias[k] = len(m.urls) - 1
+
+	return u.Alias, nil
+}
+
+func (m *Memory) URL(_ context.Context, domain, alias string) (url.URL, error) {
+	const op = "internal.storage.memory.url.Get"
+
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	i, ok := m.byAlias[key(domain, alias)]
+	if !ok {
+		return url.URL{}, fmt.Errorf("%s: %w", op, sql.ErrNoRows)
+	}
+
+	return clone(m.urls[i]), nil
+}
+
+// URLByHash returns owner's link on domain with given hash of normalized
+// original and settings.
+func (m *Memory) URLByHash(_ context.Context, owner, domain, hash string) (url.URL, error) {
+	const op = "internal.storage.memory.url.GetByHash"
+
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	for _, u := range m.urls {
+		if u.OriginalHash == hash && u.Owner == owner && u.Domain == domain {
+			return clone(u), nil
+		}
+	}
+
+	return url.URL{}, fmt.Errorf("%s: %w", op, sql.ErrNoRows)
+}
+
+// UpdateURL saves editable fields, hash and tags of link, it returns false
+// when there is no such link.
+func (m *Memory) UpdateURL(_ context.Context, u url.URL) (bool, error) {
+	const op = "internal.storage.memory.url.Update"
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	i, ok := m.byAlias[key(u.Domain, u.Alias)]
+	if !ok {
+		return false, nil
+	}
+	stored := &m.urls[i]
+	if m.hashTaken(url.URL{Owner: stored.Owner, Domain: u.Domain, OriginalHash: u.OriginalHash}, i) {
+		return false, fmt.Errorf("%s: %w", op, ErrNotUnique)
+	}
+
+	stored.OriginalHash = u.OriginalHash
+	stored.Folder = u.Folder
+	stored.Title = u.Title
+	stored.Description = u.Description
+	stored.Tags = sortedTags(u.Tags)
+	stored.UpdatedAt = time.Now().UTC()
+
+	return true, nil
+}
+
+// hashTaken reports whether other link than i has the same non-empty hash
+// of owner and domain, it must be called under lock.
+func (m *Memory) hashTaken(u url.URL, i int) bool {
+	if u.OriginalHash == "" {
+		return false
+	}
+	for j, stored := range m.urls {
+		if j != i && stored.OriginalHash == u.OriginalHash &&
+			stored.Owner == u.Owner && stored.Domain == u.Domain {
+			return true
+		}
+	}
+	return false
+}
+
+// clicks counts redirects of every link, it must be called under lock.
+func (m *Memory) clicks() map[string]int64 {
+	res := make(map[string]int64)
+	for _, r := range m.redirects {
+		res[key(r.Domain, r.Alias)]++
+	}
+	return res
+}
+
+func (m *Memory) matches(u url.URL, f url.Filter) bool {
+	if f.Owner != "" && u.Owner != f.Owner {
+		return false
+	}
+	if f.Folder != "" && u.Folder != f.Folder {
+		return false
+	}
+	if f.Tag != "" && !slices.Contains(u.Tags, f.Tag) {
+		return false
+	}
+	if f.Query != "" {
+		q := strings.ToLower(f.Query)
+		if !strings.Contains(strings.ToLower(u.Alias), q) &&
+			!strings.Contains(strings.ToLower(u.Original), q) {
+			return false
+		}
+	}
+
+	c, checked := m.checks[u.ID]
+	switch f.Status {
+	case url.StatusOK:
+		return checked && !c.Broken()
+	case url.StatusBroken:
+		return checked && c.Broken()
+	case url.StatusUnchecked:
+		return !checked
+	}
+
+	return true
+}
+
+// URLs returns page of links matching filter with their click counts.
+func (m *Memory) URLs(_ context.Context, f url.Filter) ([]url.Item, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	clicks := m.clicks()
+	res := make([]url.Item, 0)
+	for _, u := range m.urls {
+		if m.matches(u, f) {
+			res = append(res, url.Item{URL: clone(u), Clicks: clicks[key(u.Domain, u.Alias)]})
+		}
+	}
+
+	slices.SortStableFunc(res, func(a, b url.Item) int {
+		var c int
+		if f.Sort == url.SortClicks {
+			c = compare(a.Clicks, b.Clicks)
+		} else {
+			c = a.CreatedAt.Compare(b.CreatedAt)
+		}
+		if c == 0 {
+			c = compare(a.ID, b.ID)
+		}
+		if !f.Asc {
+			c = -c
+		}
+		return c
+	})
+
+	return page(res, f.Page, url.PageSize), nil
+}
+
+func compare(a, b int64) int {
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	}
+	return 0
+}
+
+// page returns n-th page of items, pages start from 1, 0 is the first one
+// too.
+func page[T any](items []T, n, size int) []T {
+	if n == 0 {
+		n++
+	}
+	from := (n - 1) * size
+	if from < 0 || from >= len(items) {
+		return items[:0]
+	}
+	return items[from:min(from+size, len(items))]
+}
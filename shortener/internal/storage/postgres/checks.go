@@ -0,0 +1,178 @@
+package postgres
+
+import (
+	"context"
+	"database/sql/driver"
+	"fmt"
+	"shortener/internal/entities/health"
+	"shortener/internal/entities/url"
+	"time"
+
+	"github.com/wb-go/wbf/zlog"
+)
+
+// TryLockChecks takes session advisory lock of health checks round on
+// dedicated connection, it's held until release.
+func (p *Postgres) TryLockChecks(ctx context.Context) (func(), bool, error) {
+	p.semaphore <- struct{}{}
+	defer func() { <-p.semaphore }()
+
+	const op = "internal.storage.postgres.checks.TryLock"
+
+	conn, err := p.db.Master.Conn(ctx)
+	if err != nil {
+		return nil, false, fmt.Errorf("%s: %w", op, err)
+	}
+	var ok bool
+	err = conn.QueryRowContext(
+		ctx, "select pg_try_advisory_lock(hashtext($1));", ChecksTable,
+	).Scan(&ok)
+	if err != nil || !ok {
+		_ = conn.Close()
+		if err != nil {
+			return nil, false, fmt.Errorf("%s: %w", op, err)
+		}
+		return nil, false, nil
+	}
+
+	release := func() {
+		_, err := conn.ExecContext(
+			context.Background(), "select pg_advisory_unlock(hashtext($1));", ChecksTable,
+		)
+		if err != nil {
+			zlog.Logger.Error().Err(err).Msg(op)
+			// connection still holding lock must not return to pool
+			_ = conn.Raw(func(any) error { return driver.ErrBadConn })
+		}
+		_ = conn.Close()
+	}
+	return release, true, nil
+}
+
+// URLsToCheck returns links never checked or checked before given time,
+// least recently checked first.
+func (p *Postgres) URLsToCheck(ctx context.Context, before time.Time, limit int) ([]url.URL, error) {
+	p.semaphore <- struct{}{}
+	defer func() { <-p.semaphore }()
+
+	const op = "internal.storage.postgres.checks.URLsToCheck"
+
+	q := fmt.Sprintf(
+		`select u.id, u.alias, u.domain, u.original, u.page_title
+		from %s u left join %s c on c.url_id = u.id
+		where c.checked_at is null or c.checked_at < $1
+		order by c.checked_at nulls first, u.id
+		limit $2;`,
+		URLTable, ChecksTable,
+	)
+	rows, err := p.db.Master.QueryContext(ctx, q, before, limit)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	defer func() {
+		_ = rows.Close()
+	}()
+
+	res := make([]url.URL, 0, limit)
+	for rows.Next() {
+		var u url.URL
+		err := rows.Scan(&u.ID, &u.Alias, &u.Domain, &u.Original, &u.PageTitle)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+		res = append(res, u)
+	}
+
+	return res, rows.Err()
+}
+
+func (p *Postgres) SaveCheck(ctx context.Context, c health.Check) error {
+	p.semaphore <- struct{}{}
+	defer func() { <-p.semaphore }()
+
+	const op = "internal.storage.postgres.checks.Save"
+
+	q := fmt.Sprintf(
+		`insert into %s (url_id, status, latency_ms, error, checked_at)
+		values ($1, $2, $3, $4, $5)
+		on conflict (url_id) do update set
+			status = excluded.status, latency_ms = excluded.latency_ms,
+			error = excluded.error, checked_at = excluded.checked_at;`,
+		ChecksTable,
+	)
+	_, err := p.db.ExecContext(
+		ctx, q,
+		c.URLID, c.Status, c.Latency.Milliseconds(), c.Error, c.CheckedAt,
+	)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+// SetPageTitle saves title of destination page fetched by health checker.
+func (p *Postgres) SetPageTitle(ctx context.Context, urlID int64, title string) error {
+	p.semaphore <- struct{}{}
+	defer func() { <-p.semaphore }()
+
+	const op = "internal.storage.postgres.checks.SetPageTitle"
+
+	q := fmt.Sprintf("update %s set page_title = $1 where id = $2;", URLTable)
+	_, err := p.db.ExecContext(ctx, q, title, urlID)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+// BrokenLinks returns links which destination failed last check, recently
+// checked first.
+func (p *Postgres) BrokenLinks(ctx context.Context, page int) ([]health.Check, error) {
+	p.semaphore <- struct{}{}
+	defer func() { <-p.semaphore }()
+
+	const op = "internal.storage.postgres.checks.Broken"
+
+	if page < 1 {
+		page = 1
+	}
+	q := fmt.Sprintf(
+		`select u.id, u.alias, u.domain, u.original,
+			c.status, c.latency_ms, c.error, c.checked_at
+		from %s c join %s u on u.id = c.url_id
+		where c.status = 0 or c.status >= 400
+		order by c.checked_at desc
+		limit $1 offset $2;`,
+		ChecksTable, URLTable,
+	)
+	rows, err := p.db.Master.QueryContext(
+		ctx, q, health.PageSize, (page-1)*health.PageSize,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	defer func() {
+		_ = rows.Close()
+	}()
+
+	res := make([]health.Check, 0)
+	for rows.Next() {
+		var c health.Check
+		var latency int64
+		err := rows.Scan(
+			&c.URLID, &c.Alias, &c.Domain, &c.Original,
+			&c.Status, &latency, &c.Error, &c.CheckedAt,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+		c.Latency = time.Duration(latency) * time.Millisecond
+		res = append(res, c)
+	}
+
+	return res, rows.Err()
+}
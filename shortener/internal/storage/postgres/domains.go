@@ -0,0 +1,75 @@
+package postgres
+
+import (
+	"context"
+	"fmt"
+	"shortener/internal/entities/domain"
+)
+
+func (p *Postgres) CreateDomain(ctx context.Context, host string) (domain.Domain, error) {
+	p.semaphore <- struct{}{}
+	defer func() { <-p.semaphore }()
+
+	const op = "internal.storage.postgres.domain.Create"
+
+	d := domain.Domain{Host: host}
+	q := fmt.Sprintf(
+		"insert into %s (host) values ($1) returning created_at;", DomainsTable,
+	)
+	err := p.db.Master.QueryRowContext(ctx, q, host).Scan(
+		&d.CreatedAt,
+	)
+	if err != nil {
+		return d, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return d, nil
+}
+
+func (p *Postgres) Domains(ctx context.Context) ([]domain.Domain, error) {
+	p.semaphore <- struct{}{}
+	defer func() { <-p.semaphore }()
+
+	const op = "internal.storage.postgres.domain.Get"
+
+	q := fmt.Sprintf("select host, created_at from %s order by host;", DomainsTable)
+	rows, err := p.db.Master.QueryContext(ctx, q)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	defer func() {
+		_ = rows.Close()
+	}()
+
+	res := make([]domain.Domain, 0)
+	for rows.Next() {
+		var d domain.Domain
+		err := rows.Scan(&d.Host, &d.CreatedAt)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+		res = append(res, d)
+	}
+
+	return res, rows.Err()
+}
+
+func (p *Postgres) DeleteDomain(ctx context.Context, host string) (bool, error) {
+	p.semaphore <- struct{}{}
+	defer func() { <-p.semaphore }()
+
+	const op = "internal.storage.postgres.domain.Delete"
+
+	q := fmt.Sprintf("delete from %s where host = $1;", DomainsTable)
+	res, err := p.db.ExecContext(ctx, q, host)
+	if err != nil {
+		return false, fmt.Errorf("%s: %w", op, err)
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return n != 0, nil
+}
@@ -0,0 +1,22 @@
+package postgres
+
+import (
+	"shortener/migrations"
+
+	"github.com/pressly/goose/v3"
+	"github.com/pressly/goose/v3/lock"
+)
+
+// Migrations returns provider of embedded migrations, runs of several
+// instances are serialized by advisory lock held for the whole run.
+func (p *Postgres) Migrations() (*goose.Provider, error) {
+	locker, err := lock.NewPostgresSessionLocker()
+	if err != nil {
+		return nil, err
+	}
+
+	return goose.NewProvider(
+		goose.DialectPostgres, p.db.Master, migrations.Postgres,
+		goose.WithSessionLocker(locker),
+	)
+}
@@ -0,0 +1,176 @@
+package postgres
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/lib/pq"
+	"github.com/wb-go/wbf/zlog"
+)
+
+const (
+	DefaultPartitionsAhead    = 3
+	DefaultPartitionsInterval = time.Hour
+
+	// partitions of redirects are named by their month
+	partitionPrefix  = RedirectsTable + "_p"
+	partitionMonth   = "200601"
+	defaultPartition = RedirectsTable + "_default"
+
+	// partitionsQuery selects names of partitions of table $1
+	partitionsQuery = `select c.relname from pg_inherits i join pg_class c on c.oid = i.inhrelid
+		where i.inhparent = $1::regclass;`
+)
+
+type PartitionOptions struct {
+	// Ahead how many partitions of next months are created in advance
+	Ahead int
+	// Retention age after which redirects are dropped with their partitions,
+	// zero keeps them forever
+	Retention time.Duration
+	// Interval how often partitions are created and dropped
+	Interval time.Duration
+}
+
+// CreatePartitions creates monthly partitions of redirects for month of now
+// and ahead next months, existing partitions are skipped.
+func (p *Postgres) CreatePartitions(ctx context.Context, now time.Time, ahead int) error {
+	p.semaphore <- struct{}{}
+	defer func() { <-p.semaphore }()
+
+	const op = "internal.storage.postgres.partitions.Create"
+
+	now = now.UTC()
+	month := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.UTC)
+	for i := 0; i <= ahead; i++ {
+		_, err := p.db.Master.ExecContext(
+			ctx, "select create_redirects_partition($1::date);",
+			month.AddDate(0, i, 0).Format(time.DateOnly),
+		)
+		if err != nil {
+			return fmt.Errorf("%s: %w", op, err)
+		}
+	}
+
+	return nil
+}
+
+// DropPartitions drops partitions of redirects which months ended before
+// given time and deletes such redirects from default partition, it returns
+// names of dropped partitions.
+func (p *Postgres) DropPartitions(ctx context.Context, before time.Time) ([]string, error) {
+	p.semaphore <- struct{}{}
+	defer func() { <-p.semaphore }()
+
+	const op = "internal.storage.postgres.partitions.Drop"
+
+	before = before.UTC()
+	rows, err := p.db.Master.QueryContext(
+		ctx, partitionsQuery, RedirectsTable,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	var expired []string
+	for rows.Next() {
+		var name string
+		err = rows.Scan(&name)
+		if err != nil {
+			_ = rows.Close()
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+		if month, ok := parsePartition(name); ok && !month.AddDate(0, 1, 0).After(before) {
+			expired = append(expired, name)
+		}
+	}
+	_ = rows.Close()
+	if rows.Err() != nil {
+		return nil, fmt.Errorf("%s: %w", op, rows.Err())
+	}
+
+	dropped := make([]string, 0, len(expired))
+	for _, name := range expired {
+		_, err = p.db.Master.ExecContext(
+			ctx, fmt.Sprintf("drop table if exists %s;", pq.QuoteIdentifier(name)),
+		)
+		if err != nil {
+			return dropped, fmt.Errorf("%s: %w", op, err)
+		}
+		dropped = append(dropped, name)
+	}
+
+	_, err = p.db.Master.ExecContext(
+		ctx, fmt.Sprintf("delete from %s where dt < $1;", defaultPartition), before,
+	)
+	if err != nil {
+		return dropped, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return dropped, nil
+}
+
+// parsePartition returns month of partition by its name, default partition
+// and unknown tables are not parsed.
+func parsePartition(name string) (time.Time, bool) {
+	s, ok := strings.CutPrefix(name, partitionPrefix)
+	if !ok {
+		return time.Time{}, false
+	}
+	month, err := time.Parse(partitionMonth, s)
+	if err != nil {
+		return time.Time{}, false
+	}
+	return month, true
+}
+
+// MaintainPartitions creates future partitions of redirects and drops
+// expired ones right away and then every interval until Shutdown.
+func (p *Postgres) MaintainPartitions(opts PartitionOptions) {
+	if opts.Ahead <= 0 {
+		opts.Ahead = DefaultPartitionsAhead
+	}
+	if opts.Interval <= 0 {
+		opts.Interval = DefaultPartitionsInterval
+	}
+
+	p.wg.Add(1)
+	go func() {
+		defer p.wg.Done()
+
+		t := time.NewTicker(opts.Interval)
+		defer t.Stop()
+		for {
+			p.maintainPartitions(opts)
+			select {
+			case <-p.ctx.Done():
+				return
+			case <-t.C:
+			}
+		}
+	}()
+}
+
+func (p *Postgres) maintainPartitions(opts PartitionOptions) {
+	const op = "internal.storage.postgres.maintainPartitions"
+
+	ctx, cancel := context.WithTimeout(p.ctx, opts.Interval)
+	defer cancel()
+
+	now := time.Now()
+	err := p.CreatePartitions(ctx, now, opts.Ahead)
+	if err != nil {
+		zlog.Logger.Error().Err(err).Msg(op)
+	}
+	if opts.Retention <= 0 {
+		return
+	}
+	dropped, err := p.DropPartitions(ctx, now.Add(-opts.Retention))
+	if err != nil {
+		zlog.Logger.Error().Err(err).Msg(op)
+	}
+	if len(dropped) != 0 {
+		zlog.Logger.Info().Strs("partitions", dropped).Msg(op + ": expired redirects dropped")
+	}
+}
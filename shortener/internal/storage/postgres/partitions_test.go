@@ -0,0 +1,30 @@
+package postgres
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParsePartition(t *testing.T) {
+	tests := []struct {
+		name   string
+		want   time.Time
+		wantOk bool
+	}{
+		{name: "redirects_p202601", want: time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC), wantOk: true},
+		{name: "redirects_p199912", want: time.Date(1999, 12, 1, 0, 0, 0, 0, time.UTC), wantOk: true},
+		{name: "redirects_default"},
+		{name: "redirects_p2026"},
+		{name: "redirects_p202613"},
+		{name: "urls_p202601"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := parsePartition(tt.name)
+			if ok != tt.wantOk || !got.Equal(tt.want) {
+				t.Errorf("got %v, %v, want %v, %v", got, ok, tt.want, tt.wantOk)
+			}
+		})
+	}
+}
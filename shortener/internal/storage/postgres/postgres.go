@@ -1,9 +1,12 @@
 package postgres
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"strings"
+	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/lib/pq"
@@ -15,6 +18,15 @@ import (
 const (
 	URLTable       = "urls"
 	RedirectsTable = "redirects"
+	DomainsTable   = "domains"
+	AliasSequence  = "alias_seq"
+	ChecksTable    = "link_checks"
+	TagsTable      = "tags"
+	URLTagsTable   = "url_tags"
+
+	DailyTable       = "redirects_daily"
+	RollupStateTable = "redirects_rollup_state"
+	LateDaysTable    = "redirects_late_days"
 )
 
 var (
@@ -24,16 +36,41 @@ var (
 type Postgres struct {
 	db *dbpg.DB
 
+	replicaDSNs  []string
+	replicaCheck time.Duration
+	replicas     []*replica
+	next         atomic.Uint64
+	// ctx of background jobs, it's canceled on Shutdown
+	ctx    context.Context
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+
 	semaphore chan struct{}
 }
 
-func New(host, port, username, password, dbname, sslmode string) *Postgres {
+func New(host, port, username, password, dbname, sslmode string, opts ...Option) *Postgres {
 	connStr := fmt.Sprintf(
 		"host=%s port=%s user=%s password=%s dbname=%s sslmode=disable",
 		host, port, username, password, dbname,
 	)
 
-	db, err := dbpg.New(connStr, []string{}, &dbpg.Options{
+	ctx, cancel := context.WithCancel(context.Background())
+	p := &Postgres{
+		replicaCheck: DefaultReplicaCheckInterval,
+		ctx:          ctx,
+		cancel:       cancel,
+		semaphore:    make(chan struct{}, 100),
+	}
+	for _, opt := range opts {
+		opt(p)
+	}
+
+	// later keys win, so dsn may override password
+	replicaDSNs := make([]string, 0, len(p.replicaDSNs))
+	for _, dsn := range p.replicaDSNs {
+		replicaDSNs = append(replicaDSNs, fmt.Sprintf("password=%s %s", password, dsn))
+	}
+	db, err := dbpg.New(connStr, replicaDSNs, &dbpg.Options{
 		MaxOpenConns:    100,
 		MaxIdleConns:    20,
 		ConnMaxLifetime: 2 * time.Hour,
@@ -45,10 +82,16 @@ func New(host, port, username, password, dbname, sslmode string) *Postgres {
 	if err != nil {
 		panic(err)
 	}
+	p.db = db
 
-	p := &Postgres{
-		db:        db,
-		semaphore: make(chan struct{}, 100),
+	// unreachable replicas don't prevent start, they are picked up by checks
+	for _, slave := range db.Slaves {
+		p.replicas = append(p.replicas, &replica{db: slave})
+	}
+	if len(p.replicas) != 0 {
+		p.checkReplicas()
+		p.wg.Add(1)
+		go p.runReplicasCheck()
 	}
 
 	return p
@@ -57,6 +100,8 @@ func New(host, port, username, password, dbname, sslmode string) *Postgres {
 func (p *Postgres) Shutdown() {
 	const op = "internal.storage.redis.shutdown"
 
+	p.cancel()
+	p.wg.Wait()
 	for {
 		if len(p.semaphore) == 0 {
 			close(p.semaphore)
@@ -69,6 +114,12 @@ func (p *Postgres) Shutdown() {
 	if err != nil {
 		zlog.Logger.Error().AnErr("err", err).Msg(op)
 	}
+	for _, r := range p.replicas {
+		err = r.db.Close()
+		if err != nil {
+			zlog.Logger.Error().AnErr("err", err).Msg(op)
+		}
+	}
 }
 
 func (p *Postgres) HandleError(err error) error {
@@ -2,54 +2,100 @@ package postgres
 
 import (
 	"context"
+	"database/sql"
 	"fmt"
 	"shortener/internal/entities/redirect"
-	"strings"
 	"sync"
+	"time"
 
-	"github.com/wb-go/wbf/zlog"
+	"github.com/lib/pq"
 )
 
-func (p *Postgres) CreateRedirects(tmp []redirect.Redirect) {
+const (
+	utmColumns      = "utm_source, utm_medium, utm_campaign, utm_term, utm_content"
+	redirectColumns = "id, alias, domain, dt, user_agent, " + utmColumns
+)
+
+func scanRedirect(rows *sql.Rows) (redirect.Redirect, error) {
+	var r redirect.Redirect
+	err := rows.Scan(
+		&r.ID, &r.Alias, &r.Domain, &r.Date, &r.UserAgent,
+		&r.UTM.Source, &r.UTM.Medium, &r.UTM.Campaign, &r.UTM.Term, &r.UTM.Content,
+	)
+	return r, err
+}
+
+// CreateRedirects writes batch with COPY, so its size isn't limited by
+// number of bind parameters.
+func (p *Postgres) CreateRedirects(ctx context.Context, tmp []redirect.Redirect) error {
 	p.semaphore <- struct{}{}
 	defer func() { <-p.semaphore }()
 
 	const op = "internal.storage.postgres.redirect.CreateBatch"
 
-	vals := make([]any, 0, len(tmp)*3)
-	q := strings.Builder{}
+	if len(tmp) == 0 {
+		return nil
+	}
+
+	tx, err := p.db.Master.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	defer func() {
+		_ = tx.Rollback()
+	}()
+
+	stmt, err := tx.PrepareContext(ctx, pq.CopyIn(
+		RedirectsTable, "alias", "domain", "dt", "user_agent",
+		"utm_source", "utm_medium", "utm_campaign", "utm_term", "utm_content",
+	))
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	defer func() {
+		_ = stmt.Close()
+	}()
 
-	q.Grow(len(tmp)*12 + 52)
-	q.WriteString(
-		fmt.Sprintf("insert into %s (alias, dt, user_agent) values",
-			RedirectsTable,
-		),
-	)
-	counter := 1
 	for _, r := range tmp {
-		q.WriteString(
-			fmt.Sprintf(" ($%d, $%d, $%d), ", counter, counter+1, counter+2),
+		_, err = stmt.ExecContext(
+			ctx, r.Alias, r.Domain, r.Date, r.UserAgent,
+			r.UTM.Source, r.UTM.Medium, r.UTM.Campaign, r.UTM.Term, r.UTM.Content,
 		)
-		counter += 3
-		vals = append(vals, r.Alias, r.Date, r.UserAgent)
+		if err != nil {
+			return fmt.Errorf("%s: %w", op, err)
+		}
 	}
-	s := q.String()
-	_, err := p.db.ExecContext(
-		context.Background(), s[:len(s)-2], vals...,
-	)
+	// exec without arguments flushes buffered rows
+	_, err = stmt.ExecContext(ctx)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	err = stmt.Close()
 	if err != nil {
-		zlog.Logger.Error().Err(err).Msg(op)
+		return fmt.Errorf("%s: %w", op, err)
 	}
+	err = markLateDays(ctx, tx, tmp)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	err = tx.Commit()
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
 }
 
-func (p *Postgres) Redirects(alias string) ([]redirect.Redirect, error) {
+func (p *Postgres) Redirects(ctx context.Context, alias string) ([]redirect.Redirect, error) {
 	p.semaphore <- struct{}{}
 	defer func() { <-p.semaphore }()
 
 	const op = "internal.storage.postgres.redirect.Get"
 
-	q := fmt.Sprintf("select * from %s where alias = $1;", RedirectsTable)
-	rows, err := p.db.Master.Query(q, alias)
+	q := fmt.Sprintf(
+		"select %s from %s where alias = $1;", redirectColumns, RedirectsTable,
+	)
+	rows, err := p.queryRead(ctx, q, alias)
 	if err != nil {
 		return nil, fmt.Errorf("%s: %w", op, err)
 	}
@@ -60,8 +106,7 @@ func (p *Postgres) Redirects(alias string) ([]redirect.Redirect, error) {
 
 	var res []redirect.Redirect
 	for rows.Next() {
-		var tmp redirect.Redirect
-		err := rows.Scan(&tmp.ID, &tmp.Alias, &tmp.Date, &tmp.UserAgent)
+		tmp, err := scanRedirect(rows)
 		if err != nil {
 			return nil, fmt.Errorf("%s: %w", op, err)
 		}
@@ -72,11 +117,14 @@ func (p *Postgres) Redirects(alias string) ([]redirect.Redirect, error) {
 }
 
 func (p *Postgres) generateAgrigatedReq(opts redirect.AgrigateOpts) (q string, args []any) {
-	q = fmt.Sprintf("select * from %s where alias = $1", RedirectsTable)
+	q = fmt.Sprintf(
+		"select %s from %s where alias = $1 and domain = $2",
+		redirectColumns, RedirectsTable,
+	)
 
-	i := 2
-	args = make([]any, 0, 4)
-	args = append(args, opts.Alias)
+	i := 3
+	args = make([]any, 0, 5)
+	args = append(args, opts.Alias, opts.Domain)
 	if opts.StartDate != "" {
 		q += fmt.Sprintf(" and dt >= $%d", i)
 		i++
@@ -91,8 +139,9 @@ func (p *Postgres) generateAgrigatedReq(opts redirect.AgrigateOpts) (q string, a
 	} else {
 		q += " and dt <= '100000-10-22 00:00:00'"
 	}
-	if opts.FilterColumn == redirect.FilterUserAgent && opts.ValueForFilter != "" {
-		q += fmt.Sprintf(" and STRPOS(%s, $%d) > 0", redirect.FilterUserAgent, i)
+	// column name is safe to format, it is checked against known filters
+	if redirect.ValidFilter(opts.FilterColumn) && opts.ValueForFilter != "" {
+		q += fmt.Sprintf(" and STRPOS(%s, $%d) > 0", opts.FilterColumn, i)
 		i++
 		args = append(args, opts.ValueForFilter)
 	}
@@ -106,59 +155,75 @@ func (p *Postgres) generateAgrigatedReq(opts redirect.AgrigateOpts) (q string, a
 	return
 }
 
-func (p *Postgres) AgrigatedRedirects(opts redirect.AgrigateOpts) (redirect.Agrigated, error) {
+// AgrigatedRedirects returns page of redirects, total and daily clicks of
+// alias. Total and days are served by rollups and redirects after them.
+func (p *Postgres) AgrigatedRedirects(ctx context.Context, opts redirect.AgrigateOpts) (redirect.Agrigated, error) {
 	p.semaphore <- struct{}{}
 	defer func() { <-p.semaphore }()
-	
+
 	const op = "internal.storage.postgres.agrigatedRedirects"
 
+	from, to, err := opts.DayRange()
+	if err != nil {
+		return redirect.Agrigated{}, fmt.Errorf("%s: %w", op, err)
+	}
+
 	wg := sync.WaitGroup{}
 	wg.Add(2)
-	errC := make(chan error, 2)
-
-	q, args := p.generateAgrigatedReq(opts)
-	var res []redirect.Redirect
-	var r redirect.Agrigated
-	go func() {
-		p.semaphore <- struct{}{}
-		defer func() { <-p.semaphore }()
+	errC := make(chan error, 3)
 
-		defer wg.Done()
-
-		rows, err := p.db.Master.Query(q, args...)
-		if err != nil {
-			errC <- err
-			return
-		}
+	r := redirect.Agrigated{Alias: opts.Alias, Domain: opts.Domain}
+	if !opts.Summary {
+		q, args := p.generateAgrigatedReq(opts)
+		wg.Add(1)
+		go func() {
+			p.semaphore <- struct{}{}
+			defer func() { <-p.semaphore }()
 
-		defer func() {
-			_ = rows.Close()
-		}()
+			defer wg.Done()
 
-		for rows.Next() {
-			var tmp redirect.Redirect
-			err := rows.Scan(&tmp.ID, &tmp.Alias, &tmp.Date, &tmp.UserAgent)
+			rows, err := p.queryRead(ctx, q, args...)
 			if err != nil {
 				errC <- err
 				return
 			}
-			res = append(res, tmp)
-		}
 
-		r.Alias = opts.Alias
-		r.Redirects = res
-	}()
+			defer func() {
+				_ = rows.Close()
+			}()
+
+			var res []redirect.Redirect
+			for rows.Next() {
+				tmp, err := scanRedirect(rows)
+				if err != nil {
+					errC <- err
+					return
+				}
+				res = append(res, tmp)
+			}
 
-	countQ := fmt.Sprintf("select count(*) from %s where alias = $1", RedirectsTable)
-	var total int64
+			r.Redirects = res
+		}()
+	}
 
+	countQ := fmt.Sprintf(
+		`select coalesce((
+			select sum(clicks) from %[1]s
+			where domain = $1 and alias = $2 and day < %[3]s
+		), 0)::bigint + (
+			select count(*) from %[2]s
+			where domain = $1 and alias = $2 and dt >= %[3]s
+		);`,
+		DailyTable, RedirectsTable, rolledUntil,
+	)
 	go func() {
 		p.semaphore <- struct{}{}
 		defer func() { <-p.semaphore }()
 
 		defer wg.Done()
 
-		row := p.db.Master.QueryRow(countQ, opts.Alias)
+		var total int64
+		row := p.queryRowRead(ctx, countQ, opts.Domain, opts.Alias)
 		if row.Err() != nil {
 			errC <- row.Err()
 			return
@@ -170,6 +235,40 @@ func (p *Postgres) AgrigatedRedirects(opts redirect.AgrigateOpts) (redirect.Agri
 		}
 		r.Total = total
 	}()
+
+	dailyQ, dailyArgs := generateDailyReq(opts.Domain, opts.Alias, from, to)
+	go func() {
+		p.semaphore <- struct{}{}
+		defer func() { <-p.semaphore }()
+
+		defer wg.Done()
+
+		rows, err := p.queryRead(ctx, dailyQ, dailyArgs...)
+		if err != nil {
+			errC <- err
+			return
+		}
+
+		defer func() {
+			_ = rows.Close()
+		}()
+
+		var res []redirect.DayClicks
+		for rows.Next() {
+			var d redirect.DayClicks
+			err := rows.Scan(&d.Day, &d.Clicks, &d.Uniques, &d.TopUserAgent, &d.TopUTMSource)
+			if err != nil {
+				errC <- err
+				return
+			}
+			res = append(res, d)
+		}
+		if rows.Err() != nil {
+			errC <- rows.Err()
+			return
+		}
+		r.Daily = res
+	}()
 	wg.Wait()
 
 	if len(errC) != 0 {
@@ -179,3 +278,34 @@ func (p *Postgres) AgrigatedRedirects(opts redirect.AgrigateOpts) (redirect.Agri
 
 	return r, nil
 }
+
+// generateDailyReq builds query of days within [from, to), zero bound means
+// there is no limit. Rolled up days are read from rollups, others are
+// aggregated from redirects.
+func generateDailyReq(domain, alias string, from, to time.Time) (q string, args []any) {
+	args = []any{domain, alias}
+	rollups, raw := "", ""
+	// bounds are passed twice, parameter can't be both date and timestamp
+	if !from.IsZero() {
+		args = append(args, from, from)
+		rollups += fmt.Sprintf(" and day >= $%d::date", len(args)-1)
+		raw += fmt.Sprintf(" and dt >= $%d", len(args))
+	}
+	if !to.IsZero() {
+		args = append(args, to, to)
+		rollups += fmt.Sprintf(" and day < $%d::date", len(args)-1)
+		raw += fmt.Sprintf(" and dt < $%d", len(args))
+	}
+
+	q = fmt.Sprintf(
+		`select day, clicks, uniques, top_user_agent, top_utm_source from %[1]s
+		where domain = $1 and alias = $2 and day < %[3]s%[4]s
+		union all
+		select %[5]s from %[2]s
+		where domain = $1 and alias = $2 and dt >= %[3]s%[6]s
+		group by date_trunc('day', dt)::date
+		order by 1;`,
+		DailyTable, RedirectsTable, rolledUntil, rollups, dailyColumns, raw,
+	)
+	return
+}
@@ -0,0 +1,117 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"sync/atomic"
+	"time"
+
+	"github.com/wb-go/wbf/zlog"
+)
+
+const (
+	DefaultReplicaCheckInterval = 5 * time.Second
+)
+
+// replica read-only copy of database, it's skipped while it fails pings.
+type replica struct {
+	db      *sql.DB
+	healthy atomic.Bool
+}
+
+type Option func(p *Postgres)
+
+// WithReplicas routes analytics queries and link lookups to replicas,
+// dsns are in key=value format, password of master is used unless dsn has
+// its own. Replicas are pinged every check interval.
+func WithReplicas(check time.Duration, dsns ...string) Option {
+	return func(p *Postgres) {
+		p.replicaDSNs = dsns
+		p.replicaCheck = check
+	}
+}
+
+// reader returns next healthy replica in round-robin order, nil means that
+// master must be used.
+func (p *Postgres) reader() *replica {
+	n := uint64(len(p.replicas))
+	if n == 0 {
+		return nil
+	}
+
+	start := p.next.Add(1)
+	for i := uint64(0); i < n; i++ {
+		r := p.replicas[(start+i)%n]
+		if r.healthy.Load() {
+			return r
+		}
+	}
+	return nil
+}
+
+// replicaFailed takes replica out of rotation until next successful check
+// when it is unreachable, query errors don't affect it.
+func (p *Postgres) replicaFailed(ctx context.Context, r *replica, err error) {
+	const op = "internal.storage.postgres.replicaFailed"
+
+	if r.db.PingContext(ctx) != nil && r.healthy.Swap(false) {
+		zlog.Logger.Warn().Err(err).Msg(op + ": replica is down, reading from master")
+	}
+}
+
+// queryRead runs query on replica, it is repeated on master when replica
+// fails.
+func (p *Postgres) queryRead(ctx context.Context, q string, args ...any) (*sql.Rows, error) {
+	if r := p.reader(); r != nil {
+		rows, err := r.db.QueryContext(ctx, q, args...)
+		if err == nil || ctx.Err() != nil {
+			return rows, err
+		}
+		p.replicaFailed(ctx, r, err)
+	}
+
+	return p.db.Master.QueryContext(ctx, q, args...)
+}
+
+// queryRowRead is queryRead for single row.
+func (p *Postgres) queryRowRead(ctx context.Context, q string, args ...any) *sql.Row {
+	if r := p.reader(); r != nil {
+		row := r.db.QueryRowContext(ctx, q, args...)
+		if row.Err() == nil || ctx.Err() != nil {
+			return row
+		}
+		p.replicaFailed(ctx, r, row.Err())
+	}
+
+	return p.db.Master.QueryRowContext(ctx, q, args...)
+}
+
+func (p *Postgres) checkReplicas() {
+	const op = "internal.storage.postgres.checkReplicas"
+
+	for _, r := range p.replicas {
+		ctx, cancel := context.WithTimeout(p.ctx, p.replicaCheck)
+		healthy := r.db.PingContext(ctx) == nil
+		cancel()
+
+		if r.healthy.Swap(healthy) != healthy {
+			zlog.Logger.Info().Bool("healthy", healthy).Msg(op + ": replica state changed")
+		}
+	}
+}
+
+func (p *Postgres) runReplicasCheck() {
+	defer p.wg.Done()
+
+	t := time.NewTicker(p.replicaCheck)
+	defer t.Stop()
+
+	for {
+		select {
+		case <-p.ctx.Done():
+			return
+		case <-t.C:
+			p.checkReplicas()
+		}
+	}
+}
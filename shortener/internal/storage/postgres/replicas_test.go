@@ -0,0 +1,45 @@
+package postgres
+
+import "testing"
+
+func TestReader(t *testing.T) {
+	replicas := func(healthy ...bool) *Postgres {
+		p := &Postgres{}
+		for _, h := range healthy {
+			r := &replica{}
+			r.healthy.Store(h)
+			p.replicas = append(p.replicas, r)
+		}
+		return p
+	}
+
+	tests := []struct {
+		name    string
+		healthy []bool
+		want    []int
+	}{
+		{name: "no replicas", want: []int{-1, -1}},
+		{name: "all down", healthy: []bool{false, false}, want: []int{-1, -1}},
+		{name: "round robin", healthy: []bool{true, true, true}, want: []int{1, 2, 0, 1}},
+		{name: "skips down", healthy: []bool{true, false, true}, want: []int{2, 2, 0, 2}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := replicas(tt.healthy...)
+			for i, want := range tt.want {
+				got := -1
+				if r := p.reader(); r != nil {
+					for j := range p.replicas {
+						if p.replicas[j] == r {
+							got = j
+						}
+					}
+				}
+				if got != want {
+					t.Errorf("call %d: got replica %d, want %d", i, got, want)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,359 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"shortener/internal/entities/redirect"
+	"time"
+
+	"github.com/lib/pq"
+	"github.com/wb-go/wbf/zlog"
+)
+
+const (
+	DefaultRollupInterval = 10 * time.Minute
+
+	// rollupDelay redirects are written in batches, so days are rolled up a
+	// while after their end
+	rollupDelay = 5 * time.Minute
+	// rollupStep max period rolled up in one transaction
+	rollupStep = 31 * 24 * time.Hour
+)
+
+// dailyColumns aggregates redirects grouped by day, in order of columns of
+// DailyTable after alias.
+const dailyColumns = `date_trunc('day', dt)::date, count(*), count(distinct user_agent),
+	mode() within group (order by user_agent),
+	coalesce(mode() within group (order by utm_source) filter (where utm_source <> ''), '')`
+
+// rolledUntil selects time before which redirects are served by rollups.
+var rolledUntil = fmt.Sprintf(
+	"coalesce((select until from %s), '-infinity'::timestamp)", RollupStateTable,
+)
+
+// linkClicks selects clicks of link of outer query t, rolled up days are read
+// from rollups, later ones from redirects.
+func linkClicks(t string) string {
+	return fmt.Sprintf(
+		`(select coalesce(sum(d.clicks), 0) from %[1]s d
+			where d.domain = %[3]s.domain and d.alias = %[3]s.alias and d.day < %[4]s
+		)::bigint + (select count(*) from %[2]s r
+			where r.domain = %[3]s.domain and r.alias = %[3]s.alias and r.dt >= %[4]s
+		)`,
+		DailyTable, RedirectsTable, t, rolledUntil,
+	)
+}
+
+// allClicks selects domain, alias and clicks of every link with redirects.
+var allClicks = fmt.Sprintf(
+	`select domain, alias, sum(clicks)::bigint as clicks from (
+		select domain, alias, clicks from %[1]s where day < %[3]s
+		union all
+		select domain, alias, count(*) from %[2]s where dt >= %[3]s
+		group by domain, alias
+	) c group by domain, alias`,
+	DailyTable, RedirectsTable, rolledUntil,
+)
+
+func dayOf(t time.Time) time.Time {
+	t = t.UTC()
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+}
+
+// RollupRedirects aggregates redirects of finished days into daily rollups,
+// the first call rolls up all existing redirects. It returns time before
+// which redirects are rolled up.
+func (p *Postgres) RollupRedirects(ctx context.Context, now time.Time) (time.Time, error) {
+	p.semaphore <- struct{}{}
+	defer func() { <-p.semaphore }()
+
+	const op = "internal.storage.postgres.rollups.Rollup"
+
+	target := dayOf(now.Add(-rollupDelay))
+	for {
+		until, err := p.rollupStep(ctx, target)
+		if err != nil {
+			return until, fmt.Errorf("%s: %w", op, err)
+		}
+		if !until.Before(target) {
+			return until, nil
+		}
+	}
+}
+
+// rollupStep rolls up next days before target in one transaction.
+func (p *Postgres) rollupStep(ctx context.Context, target time.Time) (time.Time, error) {
+	tx, err := p.db.Master.BeginTx(ctx, nil)
+	if err != nil {
+		return time.Time{}, err
+	}
+	defer func() {
+		_ = tx.Rollback()
+	}()
+
+	// lock serializes rollups of all instances
+	var until sql.NullTime
+	err = tx.QueryRowContext(
+		ctx, fmt.Sprintf("select until from %s for update;", RollupStateTable),
+	).Scan(&until)
+	if err != nil {
+		return time.Time{}, err
+	}
+	err = rollupLateDays(ctx, tx)
+	if err != nil {
+		return time.Time{}, err
+	}
+	from := until.Time
+	if !until.Valid {
+		var first sql.NullTime
+		err = tx.QueryRowContext(
+			ctx, fmt.Sprintf("select min(dt) from %s;", RedirectsTable),
+		).Scan(&first)
+		if err != nil {
+			return time.Time{}, err
+		}
+		from = target
+		if first.Valid && first.Time.Before(target) {
+			from = first.Time
+		}
+	}
+	from = dayOf(from)
+
+	to := from
+	if from.Before(target) {
+		to = from.Add(rollupStep)
+		if to.After(target) {
+			to = target
+		}
+		err = rollupDays(ctx, tx, from, to)
+		if err != nil {
+			return time.Time{}, err
+		}
+	}
+	_, err = tx.ExecContext(
+		ctx, fmt.Sprintf("update %s set until = $1;", RollupStateTable), to,
+	)
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	return to, tx.Commit()
+}
+
+// markLateDays remembers days of redirects which are already rolled up, so
+// they are rolled up again. Shared lock of state waits for running rollup,
+// the next one sees redirects of tx.
+func markLateDays(ctx context.Context, tx *sql.Tx, redirects []redirect.Redirect) error {
+	seen := make(map[string]struct{})
+	days := make([]string, 0, 1)
+	for _, r := range redirects {
+		day := dayOf(r.Date).Format(time.DateOnly)
+		if _, ok := seen[day]; !ok {
+			seen[day] = struct{}{}
+			days = append(days, day)
+		}
+	}
+
+	_, err := tx.ExecContext(
+		ctx,
+		fmt.Sprintf(
+			`insert into %s (day)
+			select d from unnest($1::date[]) d
+			where d < (select until from %s for share)
+			on conflict do nothing;`,
+			LateDaysTable, RollupStateTable,
+		),
+		pq.Array(days),
+	)
+	return err
+}
+
+// rollupLateDays rolls up again days which received redirects after their
+// rollup. Days before the oldest partition are skipped, their redirects are
+// dropped by retention and rollups are kept.
+func rollupLateDays(ctx context.Context, tx *sql.Tx) error {
+	rows, err := tx.QueryContext(
+		ctx, fmt.Sprintf("delete from %s returning day;", LateDaysTable),
+	)
+	if err != nil {
+		return err
+	}
+	var days []time.Time
+	for rows.Next() {
+		var day time.Time
+		err = rows.Scan(&day)
+		if err != nil {
+			_ = rows.Close()
+			return err
+		}
+		days = append(days, day)
+	}
+	_ = rows.Close()
+	if rows.Err() != nil {
+		return rows.Err()
+	}
+	if len(days) == 0 {
+		return nil
+	}
+
+	oldest, err := oldestPartition(ctx, tx)
+	if err != nil {
+		return err
+	}
+	for _, day := range days {
+		day = dayOf(day)
+		if day.Before(oldest) {
+			continue
+		}
+		err = rollupDays(ctx, tx, day, day.AddDate(0, 0, 1))
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// oldestPartition returns month of the oldest partition of redirects.
+func oldestPartition(ctx context.Context, tx *sql.Tx) (time.Time, error) {
+	rows, err := tx.QueryContext(ctx, partitionsQuery, RedirectsTable)
+	if err != nil {
+		return time.Time{}, err
+	}
+	defer func() {
+		_ = rows.Close()
+	}()
+
+	var oldest time.Time
+	for rows.Next() {
+		var name string
+		err = rows.Scan(&name)
+		if err != nil {
+			return time.Time{}, err
+		}
+		if month, ok := parsePartition(name); ok && (oldest.IsZero() || month.Before(oldest)) {
+			oldest = month
+		}
+	}
+	return oldest, rows.Err()
+}
+
+// rollupDays replaces rollups of days in [from, to) with aggregates of
+// redirects.
+func rollupDays(ctx context.Context, tx *sql.Tx, from, to time.Time) error {
+	_, err := tx.ExecContext(
+		ctx, fmt.Sprintf("delete from %s where day >= $1::date and day < $2::date;", DailyTable),
+		from, to,
+	)
+	if err != nil {
+		return err
+	}
+	_, err = tx.ExecContext(
+		ctx,
+		fmt.Sprintf(
+			`insert into %s (
+				domain, alias, day, clicks, uniques, top_user_agent, top_utm_source
+			) select domain, alias, %s
+			from %s where dt >= $1 and dt < $2
+			group by domain, alias, date_trunc('day', dt)::date;`,
+			DailyTable, dailyColumns, RedirectsTable,
+		),
+		from, to,
+	)
+	return err
+}
+
+// RebuildRollups aggregates again rolled up days starting from day of from,
+// zero from means the first day with redirects. Rollups of days which
+// redirects were dropped by retention are kept.
+func (p *Postgres) RebuildRollups(ctx context.Context, from time.Time) error {
+	p.semaphore <- struct{}{}
+	defer func() { <-p.semaphore }()
+
+	const op = "internal.storage.postgres.rollups.Rebuild"
+
+	var first sql.NullTime
+	err := p.db.Master.QueryRowContext(
+		ctx, fmt.Sprintf("select min(dt) from %s;", RedirectsTable),
+	).Scan(&first)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	} else if !first.Valid {
+		return nil
+	}
+	if from.Before(first.Time) {
+		from = first.Time
+	}
+	from = dayOf(from)
+
+	for {
+		done, err := p.rebuildStep(ctx, from)
+		if err != nil {
+			return fmt.Errorf("%s: %w", op, err)
+		} else if done {
+			return nil
+		}
+		from = from.Add(rollupStep)
+	}
+}
+
+func (p *Postgres) rebuildStep(ctx context.Context, from time.Time) (bool, error) {
+	tx, err := p.db.Master.BeginTx(ctx, nil)
+	if err != nil {
+		return false, err
+	}
+	defer func() {
+		_ = tx.Rollback()
+	}()
+
+	var until sql.NullTime
+	err = tx.QueryRowContext(
+		ctx, fmt.Sprintf("select until from %s for update;", RollupStateTable),
+	).Scan(&until)
+	if err != nil {
+		return false, err
+	}
+	if !until.Valid || !from.Before(until.Time) {
+		return true, nil
+	}
+
+	to := from.Add(rollupStep)
+	if to.After(until.Time) {
+		to = until.Time
+	}
+	err = rollupDays(ctx, tx, from, to)
+	if err != nil {
+		return false, err
+	}
+
+	return !to.Before(until.Time), tx.Commit()
+}
+
+// MaintainRollups rolls up redirects of finished days right away and then
+// every interval until Shutdown.
+func (p *Postgres) MaintainRollups(interval time.Duration) {
+	const op = "internal.storage.postgres.MaintainRollups"
+
+	if interval <= 0 {
+		interval = DefaultRollupInterval
+	}
+
+	p.wg.Add(1)
+	go func() {
+		defer p.wg.Done()
+
+		t := time.NewTicker(interval)
+		defer t.Stop()
+		for {
+			_, err := p.RollupRedirects(p.ctx, time.Now())
+			if err != nil && p.ctx.Err() == nil {
+				zlog.Logger.Error().Err(err).Msg(op)
+			}
+			select {
+			case <-p.ctx.Done():
+				return
+			case <-t.C:
+			}
+		}
+	}()
+}
@@ -0,0 +1,138 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"shortener/internal/entities/redirect"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+// setTags replaces tags of link, unknown tags are created.
+func setTags(ctx context.Context, tx *sql.Tx, urlID int64, tags []string) error {
+	_, err := tx.ExecContext(
+		ctx,
+		fmt.Sprintf("delete from %s where url_id = $1;", URLTagsTable),
+		urlID,
+	)
+	if err != nil || len(tags) == 0 {
+		return err
+	}
+
+	_, err = tx.ExecContext(
+		ctx,
+		fmt.Sprintf(
+			"insert into %s (name) select unnest($1::text[]) on conflict (name) do nothing;",
+			TagsTable,
+		),
+		pq.Array(tags),
+	)
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.ExecContext(
+		ctx,
+		fmt.Sprintf(
+			"insert into %s (url_id, tag_id) select $1, id from %s where name = any($2);",
+			URLTagsTable, TagsTable,
+		),
+		urlID, pq.Array(tags),
+	)
+	return err
+}
+
+// TagStats counts redirects of every link with tag, links without redirects
+// are included with zero clicks. Rolled up days within range are read from
+// rollups, redirects of days cut by range are counted from redirects.
+func (p *Postgres) TagStats(ctx context.Context, opts redirect.TagOpts) (redirect.TagStats, error) {
+	p.semaphore <- struct{}{}
+	defer func() { <-p.semaphore }()
+
+	const op = "internal.storage.postgres.tags.Stats"
+
+	res := redirect.TagStats{Tag: opts.Tag, Links: make([]redirect.LinkClicks, 0)}
+
+	q, args, err := generateTagStatsReq(opts)
+	if err != nil {
+		return res, fmt.Errorf("%s: %w", op, err)
+	}
+	rows, err := p.queryRead(ctx, q, args...)
+	if err != nil {
+		return res, fmt.Errorf("%s: %w", op, err)
+	}
+
+	defer func() {
+		_ = rows.Close()
+	}()
+
+	for rows.Next() {
+		var l redirect.LinkClicks
+		err := rows.Scan(&l.Alias, &l.Domain, &l.Clicks)
+		if err != nil {
+			return res, fmt.Errorf("%s: %w", op, err)
+		}
+		res.Total += l.Clicks
+		res.Links = append(res.Links, l)
+	}
+
+	return res, rows.Err()
+}
+
+// generateTagStatsReq builds query of clicks of links with tag within
+// optional bounds, whole days between them are read from rollups.
+func generateTagStatsReq(opts redirect.TagOpts) (q string, args []any, err error) {
+	args = []any{opts.Tag}
+	rollups, raw, cut := "", "", ""
+	if opts.StartDate != "" {
+		var from time.Time
+		from, err = time.Parse(time.DateTime, opts.StartDate)
+		if err != nil {
+			return
+		}
+		args = append(args, from)
+		raw += fmt.Sprintf(" and r.dt >= $%d", len(args))
+		// days starting before bound are counted from redirects
+		if day := dayOf(from); day.Before(from) {
+			from = day.AddDate(0, 0, 1)
+		}
+		args = append(args, from, from)
+		rollups += fmt.Sprintf(" and d.day >= $%d::date", len(args)-1)
+		cut += fmt.Sprintf(" or r.dt < $%d", len(args))
+	}
+	if opts.EndDate != "" {
+		var to time.Time
+		to, err = time.Parse(time.DateTime, opts.EndDate)
+		if err != nil {
+			return
+		}
+		args = append(args, to)
+		raw += fmt.Sprintf(" and r.dt <= $%d", len(args))
+		// end is inclusive, day is whole if it ends within bound
+		to = dayOf(to.Add(time.Second))
+		args = append(args, to, to)
+		rollups += fmt.Sprintf(" and d.day < $%d::date", len(args)-1)
+		cut += fmt.Sprintf(" or r.dt >= $%d", len(args))
+	}
+
+	q = fmt.Sprintf(
+		`select u.alias, u.domain, (
+			select coalesce(sum(d.clicks), 0) from %[5]s d
+			where d.domain = u.domain and d.alias = u.alias and d.day < %[6]s%[7]s
+		)::bigint + (
+			select count(*) from %[4]s r
+			where r.domain = u.domain and r.alias = u.alias%[8]s
+				and (r.dt >= %[6]s%[9]s)
+		) as clicks
+		from %[1]s t
+			join %[2]s ut on ut.tag_id = t.id
+			join %[3]s u on u.id = ut.url_id
+		where t.name = $1
+		order by clicks desc, u.id;`,
+		TagsTable, URLTagsTable, URLTable, RedirectsTable, DailyTable,
+		rolledUntil, rollups, raw, cut,
+	)
+	return
+}
@@ -0,0 +1,46 @@
+package postgres
+
+import (
+	"shortener/internal/entities/redirect"
+	"testing"
+	"time"
+)
+
+func TestGenerateTagStatsReq_WholeDays(t *testing.T) {
+	day := func(d int) time.Time { return time.Date(2025, 3, d, 0, 0, 0, 0, time.UTC) }
+	tests := []struct {
+		name     string
+		opts     redirect.TagOpts
+		from, to time.Time
+	}{
+		{
+			name: "bounds at midnight",
+			opts: redirect.TagOpts{StartDate: "2025-03-01 00:00:00", EndDate: "2025-03-04 23:59:59"},
+			from: day(1), to: day(5),
+		},
+		{
+			name: "days cut by bounds",
+			opts: redirect.TagOpts{StartDate: "2025-03-01 10:00:00", EndDate: "2025-03-04 12:00:00"},
+			from: day(2), to: day(4),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, args, err := generateTagStatsReq(tt.opts)
+			if err != nil {
+				t.Fatal(err)
+			}
+			// tag, start, whole days from twice, end, whole days to twice
+			if len(args) != 7 {
+				t.Fatalf("args = %v", args)
+			}
+			if from := args[2].(time.Time); !from.Equal(tt.from) {
+				t.Errorf("from = %v, want %v", from, tt.from)
+			}
+			if to := args[5].(time.Time); !to.Equal(tt.to) {
+				t.Errorf("to = %v, want %v", to, tt.to)
+			}
+		})
+	}
+}
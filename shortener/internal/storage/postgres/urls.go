@@ -2,19 +2,60 @@ package postgres
 
 import (
 	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
 	"fmt"
 	"shortener/internal/entities/url"
+	"strings"
+
+	"github.com/lib/pq"
 )
 
-func (p *Postgres) CreateURL(u url.URL) (string, error) {
+func (p *Postgres) CreateURL(ctx context.Context, u url.URL) (string, error) {
 	p.semaphore <- struct{}{}
 	defer func() { <-p.semaphore }()
 
 	const op = "internal.storage.postgres.url.Create"
 
-	q := fmt.Sprintf("insert into %s (alias, original) values ($1, $2);", URLTable)
+	geo, err := marshalGeoTargets(u.GeoTargets)
+	if err != nil {
+		return "", fmt.Errorf("%s: %w", op, err)
+	}
+
+	tx, err := p.db.Master.BeginTx(ctx, nil)
+	if err != nil {
+		return "", fmt.Errorf("%s: %w", op, err)
+	}
+	defer func() {
+		_ = tx.Rollback()
+	}()
+
+	q := fmt.Sprintf(
+		`insert into %s (
+			alias, domain, original, owner, original_hash, geo_targets, forward_query,
+			utm_source, utm_medium, utm_campaign, utm_term, utm_content, folder,
+			title, description
+		) values ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15)
+		returning id;`,
+		URLTable,
+	)
 
-	_, err := p.db.ExecContext(context.Background(), q, u.Alias, u.Original)
+	var id int64
+	err = tx.QueryRowContext(
+		ctx, q,
+		u.Alias, u.Domain, u.Original, u.Owner, u.OriginalHash, geo, u.ForwardQuery,
+		u.UTM.Source, u.UTM.Medium, u.UTM.Campaign, u.UTM.Term, u.UTM.Content,
+		u.Folder, u.Title, u.Description,
+	).Scan(&id)
+	if err != nil {
+		return "", fmt.Errorf("%s: %w", op, err)
+	}
+	err = setTags(ctx, tx, id, u.Tags)
+	if err != nil {
+		return "", fmt.Errorf("%s: %w", op, err)
+	}
+	err = tx.Commit()
 	if err != nil {
 		return "", fmt.Errorf("%s: %w", op, err)
 	}
@@ -22,23 +63,289 @@ func (p *Postgres) CreateURL(u url.URL) (string, error) {
 	return u.Alias, nil
 }
 
-func (p *Postgres) URL(alias string) (string, error) {
+// UpdateURL saves editable fields, hash and tags of link, it returns false
+// when there is no such link.
+func (p *Postgres) UpdateURL(ctx context.Context, u url.URL) (bool, error) {
+	p.semaphore <- struct{}{}
+	defer func() { <-p.semaphore }()
+
+	const op = "internal.storage.postgres.url.Update"
+
+	tx, err := p.db.Master.BeginTx(ctx, nil)
+	if err != nil {
+		return false, fmt.Errorf("%s: %w", op, err)
+	}
+	defer func() {
+		_ = tx.Rollback()
+	}()
+
+	q := fmt.Sprintf(
+		`update %s set folder = $1, title = $2, description = $3,
+		original_hash = $4, updated_at = now()
+		where domain = $5 and alias = $6 returning id;`,
+		URLTable,
+	)
+	var id int64
+	err = tx.QueryRowContext(
+		ctx, q, u.Folder, u.Title, u.Description, u.OriginalHash, u.Domain, u.Alias,
+	).Scan(&id)
+	if errors.Is(err, sql.ErrNoRows) {
+		return false, nil
+	} else if err != nil {
+		return false, fmt.Errorf("%s: %w", op, err)
+	}
+	err = setTags(ctx, tx, id, u.Tags)
+	if err != nil {
+		return false, fmt.Errorf("%s: %w", op, err)
+	}
+	err = tx.Commit()
+	if err != nil {
+		return false, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return true, nil
+}
+
+var likeEscaper = strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`)
+
+// generateURLsReq builds links listing query, column names are never taken
+// from filter, only values are passed as arguments. Page of links is picked
+// first and clicks are counted only for it, sorting by clicks joins clicks
+// aggregated per link once instead of counting them for every link. Clicks
+// of rolled up days are read from rollups, like in analytics.
+func generateURLsReq(f url.Filter) (q string, args []any) {
+	base := fmt.Sprintf("select * from %s where true", URLTable)
+
+	args = make([]any, 0, 4)
+	if f.Owner != "" {
+		args = append(args, f.Owner)
+		base += fmt.Sprintf(" and owner = $%d", len(args))
+	}
+	if f.Folder != "" {
+		args = append(args, f.Folder)
+		base += fmt.Sprintf(" and folder = $%d", len(args))
+	}
+	if f.Tag != "" {
+		args = append(args, f.Tag)
+		base += fmt.Sprintf(
+			` and exists (
+				select 1 from %s ut join %s t on t.id = ut.tag_id
+				where ut.url_id = %s.id and t.name = $%d
+			)`,
+			URLTagsTable, TagsTable, URLTable, len(args),
+		)
+	}
+	if f.Query != "" {
+		// ilike is served by trigram indexes
+		args = append(args, "%"+likeEscaper.Replace(f.Query)+"%")
+		base += fmt.Sprintf(
+			" and (alias ilike $%d or original ilike $%d)", len(args), len(args),
+		)
+	}
+	switch f.Status {
+	case url.StatusOK:
+		base += fmt.Sprintf(
+			` and exists (select 1 from %s c where c.url_id = %s.id
+				and c.status > 0 and c.status < 400)`,
+			ChecksTable, URLTable,
+		)
+	case url.StatusBroken:
+		base += fmt.Sprintf(
+			` and exists (select 1 from %s c where c.url_id = %s.id
+				and (c.status = 0 or c.status >= 400))`,
+			ChecksTable, URLTable,
+		)
+	case url.StatusUnchecked:
+		base += fmt.Sprintf(
+			" and not exists (select 1 from %s c where c.url_id = %s.id)",
+			ChecksTable, URLTable,
+		)
+	}
+
+	order := "desc"
+	if f.Asc {
+		order = "asc"
+	}
+	if f.Page == 0 {
+		f.Page++
+	}
+	page := fmt.Sprintf("offset %d limit %d", (f.Page-1)*url.PageSize, url.PageSize)
+
+	// page subquery is named as table, so urlColumns can refer to it
+	if f.Sort == url.SortClicks {
+		q = fmt.Sprintf(
+			`select %[1]s, clicks from (
+				select f.*, coalesce(c.clicks, 0) as clicks
+				from (%[2]s) f left join (%[3]s) c
+				on c.domain = f.domain and c.alias = f.alias
+				order by clicks %[4]s, f.id %[4]s %[5]s
+			) %[6]s
+			order by clicks %[4]s, id %[4]s;`,
+			urlColumns, base, allClicks, order, page, URLTable,
+		)
+		return
+	}
+	q = fmt.Sprintf(
+		`select %[1]s, %[3]s as clicks
+		from (
+			%[2]s order by created_at %[4]s, id %[4]s %[5]s
+		) %[6]s
+		order by created_at %[4]s, id %[4]s;`,
+		urlColumns, base, linkClicks(URLTable), order, page, URLTable,
+	)
+	return
+}
+
+// URLs returns page of links matching filter with their click counts.
+func (p *Postgres) URLs(ctx context.Context, f url.Filter) ([]url.Item, error) {
 	p.semaphore <- struct{}{}
 	defer func() { <-p.semaphore }()
-	
+
+	const op = "internal.storage.postgres.url.List"
+
+	q, args := generateURLsReq(f)
+	rows, err := p.db.Master.QueryContext(ctx, q, args...)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	defer func() {
+		_ = rows.Close()
+	}()
+
+	res := make([]url.Item, 0)
+	for rows.Next() {
+		var clicks int64
+		u, err := scanURL(rows, &clicks)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+		res = append(res, url.Item{URL: u, Clicks: clicks})
+	}
+
+	return res, rows.Err()
+}
+
+func (p *Postgres) URL(ctx context.Context, domain, alias string) (url.URL, error) {
+	p.semaphore <- struct{}{}
+	defer func() { <-p.semaphore }()
+
 	const op = "internal.storage.postgres.url.Get"
 
-	var u string
+	q := fmt.Sprintf(
+		`select %s from %s where domain = $1 and alias = $2;`,
+		urlColumns, URLTable,
+	)
+	if r := p.reader(); r != nil {
+		u, err := scanURL(r.db.QueryRowContext(ctx, q, domain, alias))
+		if err == nil {
+			return u, nil
+		} else if ctx.Err() != nil {
+			return u, fmt.Errorf("%s: %w", op, err)
+		}
+		// fresh link may be not replicated yet, so misses are also
+		// repeated on master
+		if !errors.Is(err, sql.ErrNoRows) {
+			p.replicaFailed(ctx, r, err)
+		}
+	}
+
+	u, err := scanURL(p.db.Master.QueryRowContext(ctx, q, domain, alias))
+	if err != nil {
+		return u, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return u, nil
+}
+
+// URLByHash returns owner's link on domain with given hash of normalized
+// original and settings.
+func (p *Postgres) URLByHash(ctx context.Context, owner, domain, hash string) (url.URL, error) {
+	p.semaphore <- struct{}{}
+	defer func() { <-p.semaphore }()
+
+	const op = "internal.storage.postgres.url.GetByHash"
 
-	q := fmt.Sprintf("select original from %s where alias = $1;", URLTable)
-	rows := p.db.Master.QueryRow(q, alias)
-	if rows.Err() != nil {
-		return u, fmt.Errorf("%s: %w", op, rows.Err())
+	q := fmt.Sprintf(
+		`select %s from %s
+		where original_hash = $1 and owner = $2 and domain = $3
+		order by id limit 1;`,
+		urlColumns, URLTable,
+	)
+	row := p.db.Master.QueryRowContext(ctx, q, hash, owner, domain)
+	if row.Err() != nil {
+		return url.URL{}, fmt.Errorf("%s: %w", op, row.Err())
 	}
-	err := rows.Scan(&u)
+	u, err := scanURL(row)
 	if err != nil {
 		return u, fmt.Errorf("%s: %w", op, err)
 	}
 
 	return u, nil
 }
+
+var urlColumns = fmt.Sprintf(
+	`id, alias, domain, original, owner, original_hash,
+	geo_targets, forward_query,
+	utm_source, utm_medium, utm_campaign, utm_term, utm_content, folder,
+	title, description, page_title, created_at, updated_at,
+	coalesce((
+		select array_agg(t.name order by t.name)
+		from %[1]s ut join %[2]s t on t.id = ut.tag_id
+		where ut.url_id = %[3]s.id
+	), '{}')`,
+	URLTagsTable, TagsTable, URLTable,
+)
+
+type scanner interface {
+	Scan(dest ...any) error
+}
+
+// scanURL scans urlColumns and extra columns selected after them.
+func scanURL(row scanner, extra ...any) (url.URL, error) {
+	var u url.URL
+	var geo []byte
+
+	dest := []any{
+		&u.ID, &u.Alias, &u.Domain, &u.Original, &u.Owner, &u.OriginalHash,
+		&geo, &u.ForwardQuery,
+		&u.UTM.Source, &u.UTM.Medium, &u.UTM.Campaign, &u.UTM.Term, &u.UTM.Content,
+		&u.Folder, &u.Title, &u.Description, &u.PageTitle, &u.CreatedAt, &u.UpdatedAt,
+		pq.Array(&u.Tags),
+	}
+	err := row.Scan(append(dest, extra...)...)
+	if err != nil {
+		return u, err
+	}
+	err = json.Unmarshal(geo, &u.GeoTargets)
+	if err != nil {
+		return u, err
+	}
+
+	return u, nil
+}
+
+func marshalGeoTargets(targets map[string]string) ([]byte, error) {
+	if targets == nil {
+		targets = map[string]string{}
+	}
+	return json.Marshal(targets)
+}
+
+// NextAliasID returns next value of sequence used by alias generators.
+func (p *Postgres) NextAliasID(ctx context.Context) (int64, error) {
+	p.semaphore <- struct{}{}
+	defer func() { <-p.semaphore }()
+
+	const op = "internal.storage.postgres.url.NextAliasID"
+
+	var id int64
+	err := p.db.Master.QueryRowContext(
+		ctx, fmt.Sprintf("select nextval('%s');", AliasSequence),
+	).Scan(&id)
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return id, nil
+}
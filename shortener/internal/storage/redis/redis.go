@@ -2,7 +2,11 @@ package redis
 
 import (
 	"context"
+	"encoding/json"
 	"errors"
+	"shortener/internal/entities/url"
+	"shortener/internal/storage"
+	"time"
 
 	"github.com/go-redis/redis/v8"
 	wbfRedis "github.com/wb-go/wbf/redis"
@@ -32,6 +36,14 @@ func New(addr, password string, db int) *Redis {
 	}
 }
 
+// key of link in cache, links of default domain are stored by alias
+func key(domain, alias string) string {
+	if domain == "" {
+		return alias
+	}
+	return domain + "/" + alias
+}
+
 func (r *Redis) Shutdown() {
 	const op = "internal.storage.redis.shutdown"
 
@@ -41,10 +53,16 @@ func (r *Redis) Shutdown() {
 	}
 }
 
-func (r *Redis) AddURL(alias, original string) error {
+func (r *Redis) AddURL(ctx context.Context, u url.URL) error {
 	const op = "internal.storage.redis.AddNotification"
 
-	err := r.rd.Set(context.Background(), alias, original)
+	b, err := json.Marshal(u)
+	if err != nil {
+		zlog.Logger.Error().AnErr("err", err).Msg(op)
+		return err
+	}
+
+	err = r.rd.Set(ctx, key(u.Domain, u.Alias), b)
 	if err != nil {
 		zlog.Logger.Error().AnErr("err", err).Msg(op)
 		return err
@@ -53,25 +71,124 @@ func (r *Redis) AddURL(alias, original string) error {
 	return nil
 }
 
-func (r *Redis) URL(alias string) (string, error) {
+// missing value of link known to be missing, it isn't valid json.
+const missing = "-"
+
+// AddMissing caches missing mark of link for ttl.
+func (r *Redis) AddMissing(ctx context.Context, domain, alias string, ttl time.Duration) error {
+	const op = "internal.storage.redis.AddMissing"
+
+	err := r.rd.Client.Set(ctx, key(domain, alias), missing, ttl).Err()
+	if err != nil {
+		zlog.Logger.Error().AnErr("err", err).Msg(op)
+		return err
+	}
+
+	return nil
+}
+
+func (r *Redis) URL(ctx context.Context, domain, alias string) (url.URL, error) {
 	const op = "internal.storage.redis.Get"
 
-	c, err := r.rd.Get(context.Background(), alias)
-	if err != nil && !errors.Is(err, redis.Nil) {
+	var u url.URL
+	c, err := r.rd.Get(ctx, key(domain, alias))
+	if errors.Is(err, redis.Nil) {
+		return u, nil
+	} else if err != nil {
 		zlog.Logger.Error().AnErr("err", err).Msg(op)
-		return "", err
+		return u, err
 	}
-	return string(c), nil
+	if c == missing {
+		return u, storage.ErrNotFound
+	}
+
+	err = json.Unmarshal([]byte(c), &u)
+	if err != nil {
+		// old versions stored plain original link, treat it as a miss
+		// so value gets overwritten from db
+		return url.URL{}, nil
+	}
+	return u, nil
 }
 
-func (r *Redis) DeleteNotification(alias string) (int64, error) {
-	const op = "internal.storage.redis.DeleteNotification"
+// DeleteURL drops cached link, so changes are read from db on next lookup.
+func (r *Redis) DeleteURL(ctx context.Context, domain, alias string) error {
+	const op = "internal.storage.redis.DeleteURL"
 
-	res, err := r.rd.Del(context.Background(), alias).Result()
+	err := r.rd.Del(ctx, key(domain, alias)).Err()
 	if err != nil {
 		zlog.Logger.Error().AnErr("err", err).Msg(op)
-		return 0, err
+		return err
 	}
 
-	return res, nil
+	return nil
+}
+
+// InvalidationsChannel channel changes of links are published to.
+const InvalidationsChannel = "shortener:invalidations"
+
+type invalidation struct {
+	Domain string `json:"domain"`
+	Alias  string `json:"alias"`
+}
+
+// PublishInvalidation tells all instances that link was changed.
+func (r *Redis) PublishInvalidation(ctx context.Context, domain, alias string) error {
+	const op = "internal.storage.redis.PublishInvalidation"
+
+	b, err := json.Marshal(invalidation{Domain: domain, Alias: alias})
+	if err != nil {
+		return err
+	}
+
+	err = r.rd.Client.Publish(ctx, InvalidationsChannel, b).Err()
+	if err != nil {
+		zlog.Logger.Error().AnErr("err", err).Msg(op)
+		return err
+	}
+
+	return nil
+}
+
+// Invalidations receives changes of links until ctx is done. Client
+// resubscribes after lost connection, purge is called then as messages
+// published meanwhile aren't delivered.
+func (r *Redis) Invalidations(ctx context.Context, drop func(domain, alias string), purge func()) {
+	const op = "internal.storage.redis.Invalidations"
+
+	ps := r.rd.Client.Subscribe(ctx, InvalidationsChannel)
+	defer func() {
+		_ = ps.Close()
+	}()
+
+	subscribed := false
+	ch := ps.ChannelWithSubscriptions(ctx, 100)
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case m, ok := <-ch:
+			if !ok {
+				return
+			}
+			switch m := m.(type) {
+			case *redis.Subscription:
+				if m.Kind != "subscribe" {
+					continue
+				}
+				if subscribed {
+					purge()
+				}
+				subscribed = true
+			case *redis.Message:
+				var inv invalidation
+				err := json.Unmarshal([]byte(m.Payload), &inv)
+				if err != nil {
+					zlog.Logger.Error().AnErr("err", err).Msg(op)
+					continue
+				}
+				drop(inv.Domain, inv.Alias)
+			}
+		}
+	}
 }
@@ -0,0 +1,130 @@
+package sqlite
+
+import (
+	"context"
+	"fmt"
+	"shortener/internal/entities/health"
+	"shortener/internal/entities/url"
+	"time"
+)
+
+// TryLockChecks always succeeds, storage serves single instance.
+func (s *SQLite) TryLockChecks(_ context.Context) (func(), bool, error) {
+	return func() {}, true, nil
+}
+
+// URLsToCheck returns links never checked or checked before given time,
+// least recently checked first.
+func (s *SQLite) URLsToCheck(ctx context.Context, before time.Time, limit int) ([]url.URL, error) {
+	const op = "internal.storage.sqlite.checks.URLsToCheck"
+
+	q := fmt.Sprintf(
+		`select u.id, u.alias, u.domain, u.original, u.page_title
+		from %s u left join %s c on c.url_id = u.id
+		where c.checked_at is null or c.checked_at < ?
+		order by c.checked_at nulls first, u.id
+		limit ?;`,
+		URLTable, ChecksTable,
+	)
+	rows, err := s.db.QueryContext(ctx, q, formatTime(before), limit)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	defer func() {
+		_ = rows.Close()
+	}()
+
+	res := make([]url.URL, 0, limit)
+	for rows.Next() {
+		var u url.URL
+		err := rows.Scan(&u.ID, &u.Alias, &u.Domain, &u.Original, &u.PageTitle)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+		res = append(res, u)
+	}
+
+	return res, rows.Err()
+}
+
+func (s *SQLite) SaveCheck(ctx context.Context, c health.Check) error {
+	const op = "internal.storage.sqlite.checks.Save"
+
+	q := fmt.Sprintf(
+		`insert into %s (url_id, status, latency_ms, error, checked_at)
+		values (?, ?, ?, ?, ?)
+		on conflict (url_id) do update set
+			status = excluded.status, latency_ms = excluded.latency_ms,
+			error = excluded.error, checked_at = excluded.checked_at;`,
+		ChecksTable,
+	)
+	_, err := s.db.ExecContext(
+		ctx, q,
+		c.URLID, c.Status, c.Latency.Milliseconds(), c.Error, formatTime(c.CheckedAt),
+	)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+// SetPageTitle saves title of destination page fetched by health checker.
+func (s *SQLite) SetPageTitle(ctx context.Context, urlID int64, title string) error {
+	const op = "internal.storage.sqlite.checks.SetPageTitle"
+
+	q := fmt.Sprintf("update %s set page_title = ? where id = ?;", URLTable)
+	_, err := s.db.ExecContext(ctx, q, title, urlID)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+// BrokenLinks returns links which destination failed last check, recently
+// checked first.
+func (s *SQLite) BrokenLinks(ctx context.Context, page int) ([]health.Check, error) {
+	const op = "internal.storage.sqlite.checks.Broken"
+
+	if page < 1 {
+		page = 1
+	}
+	q := fmt.Sprintf(
+		`select u.id, u.alias, u.domain, u.original,
+			c.status, c.latency_ms, c.error, c.checked_at
+		from %s c join %s u on u.id = c.url_id
+		where c.status = 0 or c.status >= 400
+		order by c.checked_at desc
+		limit ? offset ?;`,
+		ChecksTable, URLTable,
+	)
+	rows, err := s.db.QueryContext(
+		ctx, q, health.PageSize, (page-1)*health.PageSize,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	defer func() {
+		_ = rows.Close()
+	}()
+
+	res := make([]health.Check, 0)
+	for rows.Next() {
+		var c health.Check
+		var latency int64
+		err := rows.Scan(
+			&c.URLID, &c.Alias, &c.Domain, &c.Original,
+			&c.Status, &latency, &c.Error, &c.CheckedAt,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+		c.Latency = time.Duration(latency) * time.Millisecond
+		res = append(res, c)
+	}
+
+	return res, rows.Err()
+}
@@ -0,0 +1,68 @@
+package sqlite
+
+import (
+	"context"
+	"fmt"
+	"shortener/internal/entities/domain"
+	"time"
+)
+
+func (s *SQLite) CreateDomain(ctx context.Context, host string) (domain.Domain, error) {
+	const op = "internal.storage.sqlite.domain.Create"
+
+	d := domain.Domain{Host: host}
+	q := fmt.Sprintf(
+		"insert into %s (host, created_at) values (?, ?) returning created_at;",
+		DomainsTable,
+	)
+	err := s.db.QueryRowContext(
+		ctx, q, host, formatTime(time.Now()),
+	).Scan(&d.CreatedAt)
+	if err != nil {
+		return d, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return d, nil
+}
+
+func (s *SQLite) Domains(ctx context.Context) ([]domain.Domain, error) {
+	const op = "internal.storage.sqlite.domain.Get"
+
+	q := fmt.Sprintf("select host, created_at from %s order by host;", DomainsTable)
+	rows, err := s.db.QueryContext(ctx, q)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	defer func() {
+		_ = rows.Close()
+	}()
+
+	res := make([]domain.Domain, 0)
+	for rows.Next() {
+		var d domain.Domain
+		err := rows.Scan(&d.Host, &d.CreatedAt)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+		res = append(res, d)
+	}
+
+	return res, rows.Err()
+}
+
+func (s *SQLite) DeleteDomain(ctx context.Context, host string) (bool, error) {
+	const op = "internal.storage.sqlite.domain.Delete"
+
+	q := fmt.Sprintf("delete from %s where host = ?;", DomainsTable)
+	res, err := s.db.ExecContext(ctx, q, host)
+	if err != nil {
+		return false, fmt.Errorf("%s: %w", op, err)
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return n != 0, nil
+}
@@ -0,0 +1,195 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"shortener/internal/entities/redirect"
+	"strings"
+)
+
+const (
+	utmColumns      = "utm_source, utm_medium, utm_campaign, utm_term, utm_content"
+	redirectColumns = "id, alias, domain, dt, user_agent, " + utmColumns
+)
+
+func scanRedirect(rows *sql.Rows) (redirect.Redirect, error) {
+	var r redirect.Redirect
+	err := rows.Scan(
+		&r.ID, &r.Alias, &r.Domain, &r.Date, &r.UserAgent,
+		&r.UTM.Source, &r.UTM.Medium, &r.UTM.Campaign, &r.UTM.Term, &r.UTM.Content,
+	)
+	return r, err
+}
+
+// CreateRedirects inserts batch with a single statement, batches of service
+// are far below sqlite limit of statement variables.
+func (s *SQLite) CreateRedirects(ctx context.Context, tmp []redirect.Redirect) error {
+	const op = "internal.storage.sqlite.redirect.CreateBatch"
+
+	if len(tmp) == 0 {
+		return nil
+	}
+
+	vals := make([]any, 0, len(tmp)*9)
+	q := strings.Builder{}
+
+	q.Grow(len(tmp)*32 + 128)
+	q.WriteString(
+		fmt.Sprintf(
+			"insert into %s (alias, domain, dt, user_agent, %s) values",
+			RedirectsTable, utmColumns,
+		),
+	)
+	for i, r := range tmp {
+		if i != 0 {
+			q.WriteString(",")
+		}
+		q.WriteString(" (?, ?, ?, ?, ?, ?, ?, ?, ?)")
+		vals = append(
+			vals, r.Alias, r.Domain, formatTime(r.Date), r.UserAgent,
+			r.UTM.Source, r.UTM.Medium, r.UTM.Campaign, r.UTM.Term, r.UTM.Content,
+		)
+	}
+	_, err := s.db.ExecContext(ctx, q.String(), vals...)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+func (s *SQLite) Redirects(ctx context.Context, alias string) ([]redirect.Redirect, error) {
+	const op = "internal.storage.sqlite.redirect.Get"
+
+	q := fmt.Sprintf(
+		"select %s from %s where alias = ?;", redirectColumns, RedirectsTable,
+	)
+	rows, err := s.db.QueryContext(ctx, q, alias)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	defer func() {
+		_ = rows.Close()
+	}()
+
+	var res []redirect.Redirect
+	for rows.Next() {
+		tmp, err := scanRedirect(rows)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+		res = append(res, tmp)
+	}
+
+	return res, rows.Err()
+}
+
+// generateAgrigatedReq builds page query of redirects, dates are compared as
+// strings, which works as they are stored in UTC in timeFormat.
+func generateAgrigatedReq(opts redirect.AgrigateOpts) (q string, args []any) {
+	q = fmt.Sprintf(
+		"select %s from %s where alias = ? and domain = ?",
+		redirectColumns, RedirectsTable,
+	)
+
+	args = make([]any, 0, 5)
+	args = append(args, opts.Alias, opts.Domain)
+	if opts.StartDate != "" {
+		q += " and dt >= ?"
+		args = append(args, opts.StartDate)
+	}
+	if opts.EndDate != "" {
+		q += " and dt <= ?"
+		args = append(args, opts.EndDate)
+	}
+	// column name is safe to format, it is checked against known filters
+	if redirect.ValidFilter(opts.FilterColumn) && opts.ValueForFilter != "" {
+		q += fmt.Sprintf(" and instr(%s, ?) > 0", opts.FilterColumn)
+		args = append(args, opts.ValueForFilter)
+	}
+	if opts.Page == 0 {
+		opts.Page++
+	}
+	offset := (opts.Page - 1) * redirect.PageSize
+	limit := redirect.PageSize
+
+	q += fmt.Sprintf(" order by dt limit %d offset %d", limit, offset)
+	return
+}
+
+func (s *SQLite) AgrigatedRedirects(ctx context.Context, opts redirect.AgrigateOpts) (redirect.Agrigated, error) {
+	const op = "internal.storage.sqlite.agrigatedRedirects"
+
+	r := redirect.Agrigated{Alias: opts.Alias, Domain: opts.Domain}
+
+	var err error
+	if !opts.Summary {
+		q, args := generateAgrigatedReq(opts)
+		r.Redirects, err = s.queryRedirects(ctx, q, args...)
+		if err != nil {
+			return redirect.Agrigated{}, fmt.Errorf("%s: %w", op, err)
+		}
+	}
+
+	// there are no rollups, days are aggregated from redirects
+	from, to, err := opts.DayRange()
+	if err != nil {
+		return redirect.Agrigated{}, fmt.Errorf("%s: %w", op, err)
+	}
+	q := fmt.Sprintf(
+		"select %s from %s where alias = ? and domain = ?",
+		redirectColumns, RedirectsTable,
+	)
+	args := []any{opts.Alias, opts.Domain}
+	if !from.IsZero() {
+		q += " and dt >= ?"
+		args = append(args, formatTime(from))
+	}
+	if !to.IsZero() {
+		q += " and dt < ?"
+		args = append(args, formatTime(to))
+	}
+	days, err := s.queryRedirects(ctx, q, args...)
+	if err != nil {
+		return redirect.Agrigated{}, fmt.Errorf("%s: %w", op, err)
+	}
+	r.Daily = redirect.Daily(days)
+
+	countQ := fmt.Sprintf(
+		"select count(*) from %s where alias = ? and domain = ?", RedirectsTable,
+	)
+	err = s.db.QueryRowContext(
+		ctx, countQ, opts.Alias, opts.Domain,
+	).Scan(&r.Total)
+	if err != nil {
+		return redirect.Agrigated{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return r, nil
+}
+
+// queryRedirects reads all rows before returning, pool has a single
+// connection, so rows must be closed before next query.
+func (s *SQLite) queryRedirects(ctx context.Context, q string, args ...any) ([]redirect.Redirect, error) {
+	rows, err := s.db.QueryContext(ctx, q, args...)
+	if err != nil {
+		return nil, err
+	}
+
+	defer func() {
+		_ = rows.Close()
+	}()
+
+	var res []redirect.Redirect
+	for rows.Next() {
+		tmp, err := scanRedirect(rows)
+		if err != nil {
+			return nil, err
+		}
+		res = append(res, tmp)
+	}
+
+	return res, rows.Err()
+}
@@ -0,0 +1,90 @@
+package sqlite
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"io/fs"
+	"shortener/internal/storage/postgres"
+	"shortener/migrations"
+	"time"
+
+	"github.com/pressly/goose/v3"
+	"github.com/wb-go/wbf/zlog"
+	"modernc.org/sqlite"
+	sqlite3 "modernc.org/sqlite/lib"
+)
+
+const (
+	URLTable       = "urls"
+	RedirectsTable = "redirects"
+	DomainsTable   = "domains"
+	AliasSequence  = "alias_seq"
+	ChecksTable    = "link_checks"
+	TagsTable      = "tags"
+	URLTagsTable   = "url_tags"
+)
+
+// timeFormat of stored times, they are always in UTC, so they are ordered
+// as strings.
+const timeFormat = "2006-01-02 15:04:05.999999999"
+
+// SQLite storage in a single file, it is meant for small deployments
+// without postgres server. Queries mirror postgres.Postgres ones.
+type SQLite struct {
+	db *sql.DB
+}
+
+// New opens database file, creating it if needed, schema is created by
+// Migrations.
+func New(path string) *SQLite {
+	dsn := fmt.Sprintf(
+		"file:%s?_pragma=foreign_keys(1)&_pragma=journal_mode(WAL)&_pragma=busy_timeout(5000)",
+		path,
+	)
+	db, err := sql.Open("sqlite", dsn)
+	if err != nil {
+		panic(err)
+	}
+	// sqlite has a single writer, so requests are queued by pool instead of
+	// failing with busy errors
+	db.SetMaxOpenConns(1)
+
+	return &SQLite{db: db}
+}
+
+// Migrations returns provider of embedded migrations, database file is
+// owned by a single instance, so no lock is needed.
+func (s *SQLite) Migrations() (*goose.Provider, error) {
+	fsys, err := fs.Sub(migrations.SQLite, "sqlite")
+	if err != nil {
+		return nil, err
+	}
+
+	return goose.NewProvider(goose.DialectSQLite3, s.db, fsys)
+}
+
+func formatTime(t time.Time) string {
+	return t.UTC().Format(timeFormat)
+}
+
+func (s *SQLite) Shutdown() {
+	const op = "internal.storage.sqlite.shutdown"
+
+	err := s.db.Close()
+	if err != nil {
+		zlog.Logger.Error().AnErr("err", err).Msg(op)
+	}
+}
+
+func (s *SQLite) HandleError(err error) error {
+	var sqliteErr *sqlite.Error
+	if errors.As(err, &sqliteErr) {
+		switch sqliteErr.Code() {
+		case sqlite3.SQLITE_CONSTRAINT_UNIQUE, sqlite3.SQLITE_CONSTRAINT_PRIMARYKEY:
+			return postgres.ErrNotUnique
+		}
+	}
+
+	return err
+}
@@ -0,0 +1,25 @@
+package sqlite_test
+
+import (
+	"context"
+	"path/filepath"
+	"shortener/internal/storage"
+	"shortener/internal/storage/memory"
+	"shortener/internal/storage/sqlite"
+	"shortener/internal/storage/storagetest"
+	"testing"
+)
+
+func TestSQLite(t *testing.T) {
+	db := sqlite.New(filepath.Join(t.TempDir(), "shortener.db"))
+	migrations, err := db.Migrations()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := migrations.Up(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	storagetest.Run(t, storage.New(db, memory.NewCache()))
+	db.Shutdown()
+}
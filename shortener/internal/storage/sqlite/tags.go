@@ -0,0 +1,100 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"shortener/internal/entities/redirect"
+	"strings"
+)
+
+// setTags replaces tags of link, unknown tags are created.
+func setTags(ctx context.Context, tx *sql.Tx, urlID int64, tags []string) error {
+	_, err := tx.ExecContext(
+		ctx,
+		fmt.Sprintf("delete from %s where url_id = ?;", URLTagsTable),
+		urlID,
+	)
+	if err != nil || len(tags) == 0 {
+		return err
+	}
+
+	names := make([]any, 0, len(tags))
+	for _, t := range tags {
+		names = append(names, t)
+	}
+	values := strings.TrimSuffix(strings.Repeat("(?), ", len(tags)), ", ")
+	_, err = tx.ExecContext(
+		ctx,
+		fmt.Sprintf(
+			"insert into %s (name) values %s on conflict (name) do nothing;",
+			TagsTable, values,
+		),
+		names...,
+	)
+	if err != nil {
+		return err
+	}
+
+	in := strings.TrimSuffix(strings.Repeat("?, ", len(tags)), ", ")
+	_, err = tx.ExecContext(
+		ctx,
+		fmt.Sprintf(
+			"insert into %s (url_id, tag_id) select ?, id from %s where name in (%s);",
+			URLTagsTable, TagsTable, in,
+		),
+		append([]any{urlID}, names...)...,
+	)
+	return err
+}
+
+// TagStats counts redirects of every link with tag, links without redirects
+// are included with zero clicks.
+func (s *SQLite) TagStats(ctx context.Context, opts redirect.TagOpts) (redirect.TagStats, error) {
+	const op = "internal.storage.sqlite.tags.Stats"
+
+	res := redirect.TagStats{Tag: opts.Tag, Links: make([]redirect.LinkClicks, 0)}
+
+	join := "r.alias = u.alias and r.domain = u.domain"
+	args := []any{}
+	if opts.StartDate != "" {
+		args = append(args, opts.StartDate)
+		join += " and r.dt >= ?"
+	}
+	if opts.EndDate != "" {
+		args = append(args, opts.EndDate)
+		join += " and r.dt <= ?"
+	}
+	args = append(args, opts.Tag)
+	q := fmt.Sprintf(
+		`select u.alias, u.domain, count(r.id) as clicks
+		from %s t
+			join %s ut on ut.tag_id = t.id
+			join %s u on u.id = ut.url_id
+			left join %s r on %s
+		where t.name = ?
+		group by u.id, u.alias, u.domain
+		order by clicks desc, u.id;`,
+		TagsTable, URLTagsTable, URLTable, RedirectsTable, join,
+	)
+	rows, err := s.db.QueryContext(ctx, q, args...)
+	if err != nil {
+		return res, fmt.Errorf("%s: %w", op, err)
+	}
+
+	defer func() {
+		_ = rows.Close()
+	}()
+
+	for rows.Next() {
+		var l redirect.LinkClicks
+		err := rows.Scan(&l.Alias, &l.Domain, &l.Clicks)
+		if err != nil {
+			return res, fmt.Errorf("%s: %w", op, err)
+		}
+		res.Total += l.Clicks
+		res.Links = append(res.Links, l)
+	}
+
+	return res, rows.Err()
+}
@@ -0,0 +1,314 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"shortener/internal/entities/url"
+	"strings"
+	"time"
+)
+
+func (s *SQLite) CreateURL(ctx context.Context, u url.URL) (string, error) {
+	const op = "internal.storage.sqlite.url.Create"
+
+	geo, err := marshalGeoTargets(u.GeoTargets)
+	if err != nil {
+		return "", fmt.Errorf("%s: %w", op, err)
+	}
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return "", fmt.Errorf("%s: %w", op, err)
+	}
+	defer func() {
+		_ = tx.Rollback()
+	}()
+
+	now := formatTime(time.Now())
+	q := fmt.Sprintf(
+		`insert into %s (
+			alias, domain, original, owner, original_hash, geo_targets, forward_query,
+			utm_source, utm_medium, utm_campaign, utm_term, utm_content, folder,
+			title, description, created_at, updated_at
+		) values (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+		returning id;`,
+		URLTable,
+	)
+
+	var id int64
+	err = tx.QueryRowContext(
+		ctx, q,
+		u.Alias, u.Domain, u.Original, u.Owner, u.OriginalHash, string(geo), u.ForwardQuery,
+		u.UTM.Source, u.UTM.Medium, u.UTM.Campaign, u.UTM.Term, u.UTM.Content,
+		u.Folder, u.Title, u.Description, now, now,
+	).Scan(&id)
+	if err != nil {
+		return "", fmt.Errorf("%s: %w", op, err)
+	}
+	err = setTags(ctx, tx, id, u.Tags)
+	if err != nil {
+		return "", fmt.Errorf("%s: %w", op, err)
+	}
+	err = tx.Commit()
+	if err != nil {
+		return "", fmt.Errorf("%s: %w", op, err)
+	}
+
+	return u.Alias, nil
+}
+
+// UpdateURL saves editable fields, hash and tags of link, it returns false
+// when there is no such link.
+func (s *SQLite) UpdateURL(ctx context.Context, u url.URL) (bool, error) {
+	const op = "internal.storage.sqlite.url.Update"
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return false, fmt.Errorf("%s: %w", op, err)
+	}
+	defer func() {
+		_ = tx.Rollback()
+	}()
+
+	q := fmt.Sprintf(
+		`update %s set folder = ?, title = ?, description = ?,
+		original_hash = ?, updated_at = ?
+		where domain = ? and alias = ? returning id;`,
+		URLTable,
+	)
+	var id int64
+	err = tx.QueryRowContext(
+		ctx, q,
+		u.Folder, u.Title, u.Description, u.OriginalHash, formatTime(time.Now()),
+		u.Domain, u.Alias,
+	).Scan(&id)
+	if errors.Is(err, sql.ErrNoRows) {
+		return false, nil
+	} else if err != nil {
+		return false, fmt.Errorf("%s: %w", op, err)
+	}
+	err = setTags(ctx, tx, id, u.Tags)
+	if err != nil {
+		return false, fmt.Errorf("%s: %w", op, err)
+	}
+	err = tx.Commit()
+	if err != nil {
+		return false, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return true, nil
+}
+
+var likeEscaper = strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`)
+
+// generateURLsReq builds links listing query, column names are never taken
+// from filter, only values are passed as arguments.
+func generateURLsReq(f url.Filter) (q string, args []any) {
+	q = fmt.Sprintf(
+		`select %s, (
+			select count(*) from %s r
+			where r.domain = %s.domain and r.alias = %s.alias
+		) as clicks
+		from %s where true`,
+		urlColumns, RedirectsTable, URLTable, URLTable, URLTable,
+	)
+
+	args = make([]any, 0, 4)
+	if f.Owner != "" {
+		args = append(args, f.Owner)
+		q += " and owner = ?"
+	}
+	if f.Folder != "" {
+		args = append(args, f.Folder)
+		q += " and folder = ?"
+	}
+	if f.Tag != "" {
+		args = append(args, f.Tag)
+		q += fmt.Sprintf(
+			` and exists (
+				select 1 from %s ut join %s t on t.id = ut.tag_id
+				where ut.url_id = %s.id and t.name = ?
+			)`,
+			URLTagsTable, TagsTable, URLTable,
+		)
+	}
+	if f.Query != "" {
+		// like is case insensitive for ascii only, lower makes it so for
+		// the rest of letters
+		like := "%" + likeEscaper.Replace(strings.ToLower(f.Query)) + "%"
+		args = append(args, like, like)
+		q += ` and (lower(alias) like ? escape '\' or lower(original) like ? escape '\')`
+	}
+	switch f.Status {
+	case url.StatusOK:
+		q += fmt.Sprintf(
+			` and exists (select 1 from %s c where c.url_id = %s.id
+				and c.status > 0 and c.status < 400)`,
+			ChecksTable, URLTable,
+		)
+	case url.StatusBroken:
+		q += fmt.Sprintf(
+			` and exists (select 1 from %s c where c.url_id = %s.id
+				and (c.status = 0 or c.status >= 400))`,
+			ChecksTable, URLTable,
+		)
+	case url.StatusUnchecked:
+		q += fmt.Sprintf(
+			" and not exists (select 1 from %s c where c.url_id = %s.id)",
+			ChecksTable, URLTable,
+		)
+	}
+
+	order := "desc"
+	if f.Asc {
+		order = "asc"
+	}
+	if f.Sort == url.SortClicks {
+		q += fmt.Sprintf(" order by clicks %s, id %s", order, order)
+	} else {
+		q += fmt.Sprintf(" order by created_at %s, id %s", order, order)
+	}
+	if f.Page == 0 {
+		f.Page++
+	}
+	q += fmt.Sprintf(
+		" limit %d offset %d", url.PageSize, (f.Page-1)*url.PageSize,
+	)
+	return
+}
+
+// URLs returns page of links matching filter with their click counts.
+func (s *SQLite) URLs(ctx context.Context, f url.Filter) ([]url.Item, error) {
+	const op = "internal.storage.sqlite.url.List"
+
+	q, args := generateURLsReq(f)
+	rows, err := s.db.QueryContext(ctx, q, args...)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	defer func() {
+		_ = rows.Close()
+	}()
+
+	res := make([]url.Item, 0)
+	for rows.Next() {
+		var clicks int64
+		u, err := scanURL(rows, &clicks)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+		res = append(res, url.Item{URL: u, Clicks: clicks})
+	}
+
+	return res, rows.Err()
+}
+
+func (s *SQLite) URL(ctx context.Context, domain, alias string) (url.URL, error) {
+	const op = "internal.storage.sqlite.url.Get"
+
+	q := fmt.Sprintf(
+		`select %s from %s where domain = ? and alias = ?;`,
+		urlColumns, URLTable,
+	)
+	u, err := scanURL(s.db.QueryRowContext(ctx, q, domain, alias))
+	if err != nil {
+		return u, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return u, nil
+}
+
+// URLByHash returns owner's link on domain with given hash of normalized
+// original and settings.
+func (s *SQLite) URLByHash(ctx context.Context, owner, domain, hash string) (url.URL, error) {
+	const op = "internal.storage.sqlite.url.GetByHash"
+
+	q := fmt.Sprintf(
+		`select %s from %s
+		where original_hash = ? and owner = ? and domain = ?
+		order by id limit 1;`,
+		urlColumns, URLTable,
+	)
+	u, err := scanURL(
+		s.db.QueryRowContext(ctx, q, hash, owner, domain),
+	)
+	if err != nil {
+		return u, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return u, nil
+}
+
+// urlColumns selects tags as comma separated string, tags never contain
+// commas.
+var urlColumns = fmt.Sprintf(
+	`id, alias, domain, original, owner, original_hash,
+	geo_targets, forward_query,
+	utm_source, utm_medium, utm_campaign, utm_term, utm_content, folder,
+	title, description, page_title, created_at, updated_at,
+	coalesce((
+		select group_concat(t.name, ',' order by t.name)
+		from %[1]s ut join %[2]s t on t.id = ut.tag_id
+		where ut.url_id = %[3]s.id
+	), '')`,
+	URLTagsTable, TagsTable, URLTable,
+)
+
+type scanner interface {
+	Scan(dest ...any) error
+}
+
+// scanURL scans urlColumns and extra columns selected after them.
+func scanURL(row scanner, extra ...any) (url.URL, error) {
+	var u url.URL
+	var geo, tags string
+
+	dest := []any{
+		&u.ID, &u.Alias, &u.Domain, &u.Original, &u.Owner, &u.OriginalHash,
+		&geo, &u.ForwardQuery,
+		&u.UTM.Source, &u.UTM.Medium, &u.UTM.Campaign, &u.UTM.Term, &u.UTM.Content,
+		&u.Folder, &u.Title, &u.Description, &u.PageTitle, &u.CreatedAt, &u.UpdatedAt,
+		&tags,
+	}
+	err := row.Scan(append(dest, extra...)...)
+	if err != nil {
+		return u, err
+	}
+	err = json.Unmarshal([]byte(geo), &u.GeoTargets)
+	if err != nil {
+		return u, err
+	}
+	u.Tags = []string{}
+	if tags != "" {
+		u.Tags = strings.Split(tags, ",")
+	}
+
+	return u, nil
+}
+
+func marshalGeoTargets(targets map[string]string) ([]byte, error) {
+	if targets == nil {
+		targets = map[string]string{}
+	}
+	return json.Marshal(targets)
+}
+
+// NextAliasID returns next value of counter used by alias generators.
+func (s *SQLite) NextAliasID(ctx context.Context) (int64, error) {
+	const op = "internal.storage.sqlite.url.NextAliasID"
+
+	var id int64
+	err := s.db.QueryRowContext(
+		ctx,
+		fmt.Sprintf("update %s set value = value + 1 returning value;", AliasSequence),
+	).Scan(&id)
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return id, nil
+}
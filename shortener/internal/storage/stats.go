@@ -0,0 +1,46 @@
+package storage
+
+import "sync/atomic"
+
+type cacheStats struct {
+	localHits    atomic.Int64
+	hits         atomic.Int64
+	misses       atomic.Int64
+	negativeHits atomic.Int64
+	coalesced    atomic.Int64
+}
+
+// CacheStats counters of link lookups since start, ratios are shares of all
+// lookups.
+type CacheStats struct {
+	// LocalHits lookups served by in-process cache, Hits by shared one
+	LocalHits    int64 `json:"local_hits"`
+	Hits         int64 `json:"hits"`
+	Misses       int64 `json:"misses"`
+	NegativeHits int64 `json:"negative_hits"`
+	// Coalesced misses which shared db query with concurrent ones, the one
+	// which made query is counted too
+	Coalesced int64 `json:"coalesced"`
+
+	LocalHitRatio    float64 `json:"local_hit_ratio"`
+	HitRatio         float64 `json:"hit_ratio"`
+	MissRatio        float64 `json:"miss_ratio"`
+	NegativeHitRatio float64 `json:"negative_hit_ratio"`
+}
+
+func (s *Storage) CacheStats() CacheStats {
+	res := CacheStats{
+		LocalHits:    s.stats.localHits.Load(),
+		Hits:         s.stats.hits.Load(),
+		Misses:       s.stats.misses.Load(),
+		NegativeHits: s.stats.negativeHits.Load(),
+		Coalesced:    s.stats.coalesced.Load(),
+	}
+	if total := res.LocalHits + res.Hits + res.Misses + res.NegativeHits; total != 0 {
+		res.LocalHitRatio = float64(res.LocalHits) / float64(total)
+		res.HitRatio = float64(res.Hits) / float64(total)
+		res.MissRatio = float64(res.Misses) / float64(total)
+		res.NegativeHitRatio = float64(res.NegativeHits) / float64(total)
+	}
+	return res
+}
@@ -1,14 +1,21 @@
 package storage
 
 import (
+	"context"
 	"database/sql"
 	"errors"
 	"fmt"
+	"shortener/internal/entities/domain"
+	"shortener/internal/entities/health"
 	"shortener/internal/entities/redirect"
 	"shortener/internal/entities/url"
 	"shortener/internal/storage/postgres"
+	"sync"
+	"time"
 
+	"github.com/hashicorp/golang-lru/v2/expirable"
 	"github.com/wb-go/wbf/zlog"
+	"golang.org/x/sync/singleflight"
 )
 
 var (
@@ -16,38 +23,147 @@ var (
 	ErrNotFound  = errors.New("not found row")
 )
 
-type db interface {
-	CreateURL(u url.URL) (string, error)
-	URL(alias string) (string, error)
-	CreateRedirects(redirects []redirect.Redirect)
-	Redirects(alias string) ([]redirect.Redirect, error)
-	AgrigatedRedirects(opts redirect.AgrigateOpts) (redirect.Agrigated, error)
+// DB persistent storage of links, redirects and domains. Missing rows are
+// reported with sql.ErrNoRows, HandleError maps uniqueness violations to
+// postgres.ErrNotUnique.
+type DB interface {
+	CreateURL(ctx context.Context, u url.URL) (string, error)
+	URL(ctx context.Context, domain, alias string) (url.URL, error)
+	URLByHash(ctx context.Context, owner, domain, hash string) (url.URL, error)
+	UpdateURL(ctx context.Context, u url.URL) (bool, error)
+	URLs(ctx context.Context, f url.Filter) ([]url.Item, error)
+	NextAliasID(ctx context.Context) (int64, error)
+	CreateRedirects(ctx context.Context, redirects []redirect.Redirect) error
+	Redirects(ctx context.Context, alias string) ([]redirect.Redirect, error)
+	AgrigatedRedirects(ctx context.Context, opts redirect.AgrigateOpts) (redirect.Agrigated, error)
+	TagStats(ctx context.Context, opts redirect.TagOpts) (redirect.TagStats, error)
+	CreateDomain(ctx context.Context, host string) (domain.Domain, error)
+	Domains(ctx context.Context) ([]domain.Domain, error)
+	DeleteDomain(ctx context.Context, host string) (bool, error)
+	// TryLockChecks takes lock of health checks round shared by instances,
+	// ok is false when another instance holds it.
+	TryLockChecks(ctx context.Context) (release func(), ok bool, err error)
+	URLsToCheck(ctx context.Context, before time.Time, limit int) ([]url.URL, error)
+	SaveCheck(ctx context.Context, c health.Check) error
+	SetPageTitle(ctx context.Context, urlID int64, title string) error
+	BrokenLinks(ctx context.Context, page int) ([]health.Check, error)
 
 	HandleError(err error) error
 	Shutdown()
 }
 
-type cache interface {
-	AddURL(alias, original string) error
-	URL(alias string) (string, error)
+// Cache of links for redirects, miss is reported with empty link, link
+// known to be missing with ErrNotFound.
+type Cache interface {
+	AddURL(ctx context.Context, u url.URL) error
+	// AddMissing remembers that there is no such link for ttl.
+	AddMissing(ctx context.Context, domain, alias string, ttl time.Duration) error
+	URL(ctx context.Context, domain, alias string) (url.URL, error)
+	// DeleteURL drops cached link or its missing mark.
+	DeleteURL(ctx context.Context, domain, alias string) error
 	Shutdown()
 }
 
+const (
+	DefaultReadTimeout      = 3 * time.Second
+	DefaultWriteTimeout     = 3 * time.Second
+	DefaultAnalyticsTimeout = 8 * time.Second
+	DefaultCacheTimeout     = 500 * time.Millisecond
+
+	DefaultNegativeTTL = 30 * time.Second
+)
+
+// Timeouts deadlines of single storage operation, they are applied on top of
+// deadline of caller's context. Zero disables timeout of its kind.
+type Timeouts struct {
+	// Read lookups and listings of links and domains
+	Read time.Duration
+	// Write changes of links, domains, checks and redirects batches
+	Write time.Duration
+	// Analytics queries over redirects
+	Analytics time.Duration
+	// Cache every request to cache
+	Cache time.Duration
+}
+
+func DefaultTimeouts() Timeouts {
+	return Timeouts{
+		Read:      DefaultReadTimeout,
+		Write:     DefaultWriteTimeout,
+		Analytics: DefaultAnalyticsTimeout,
+		Cache:     DefaultCacheTimeout,
+	}
+}
+
 type Storage struct {
-	db db
-	c  cache
+	db DB
+	c  Cache
+
+	timeouts    Timeouts
+	negativeTTL time.Duration
+	// lookups concurrently missing cache for the same link share one query
+	lookups *singleflight.Group
+	stats   cacheStats
+
+	// local found links kept in process in front of cache, nil if disabled
+	local *expirable.LRU[string, url.URL]
+	// ctx of invalidations subscription, it's canceled on Shutdown
+	ctx    context.Context
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
 }
 
-func New(db db, c cache) *Storage {
-	return &Storage{
-		db: db,
-		c:  c,
+type Option func(s *Storage)
+
+// WithTimeouts overrides DefaultTimeouts.
+func WithTimeouts(t Timeouts) Option {
+	return func(s *Storage) {
+		s.timeouts = t
+	}
+}
+
+// WithNegativeTTL overrides DefaultNegativeTTL of cached missing links,
+// zero disables caching of missing links.
+func WithNegativeTTL(ttl time.Duration) Option {
+	return func(s *Storage) {
+		s.negativeTTL = ttl
+	}
+}
+
+func New(db DB, c Cache, opts ...Option) *Storage {
+	ctx, cancel := context.WithCancel(context.Background())
+	s := &Storage{
+		db:          db,
+		c:           c,
+		timeouts:    DefaultTimeouts(),
+		negativeTTL: DefaultNegativeTTL,
+		lookups:     new(singleflight.Group),
+		ctx:         ctx,
+		cancel:      cancel,
+	}
+	for _, opt := range opts {
+		opt(s)
 	}
+	if inv, ok := c.(Invalidator); ok && s.local != nil {
+		s.runInvalidations(inv)
+	}
+	return s
 }
 
-func (s *Storage) CreateURL(u url.URL) (string, error) {
+func withTimeout(ctx context.Context, d time.Duration) (context.Context, context.CancelFunc) {
+	if d <= 0 {
+		return context.WithCancel(ctx)
+	}
+	return context.WithTimeout(ctx, d)
+}
+
+func (s *Storage) CreateURL(ctx context.Context, u url.URL) (string, error) {
 	const op = "internal.storage.createURL"
-	alias, err := s.db.CreateURL(u)
+
+	ctx, cancel := withTimeout(ctx, s.timeouts.Write)
+	defer cancel()
+
+	alias, err := s.db.CreateURL(ctx, u)
 	DBErr := s.db.HandleError(err)
 	if errors.Is(DBErr, postgres.ErrNotUnique) {
 		return "", ErrNotUnique
@@ -57,43 +173,220 @@ func (s *Storage) CreateURL(u url.URL) (string, error) {
 		return "", fmt.Errorf("%s: %w", op, err)
 	}
 
+	// alias may be cached as missing
+	cacheCtx, cancel := withTimeout(ctx, s.timeouts.Cache)
+	err = s.c.DeleteURL(cacheCtx, u.Domain, u.Alias)
+	cancel()
+	if err != nil {
+		zlog.Logger.Error().Err(err).Fields(map[string]any{"op": op}).Send()
+	}
+	s.dropMissingLater(u.Domain, u.Alias)
+
 	return alias, nil
 }
 
-func (s *Storage) URL(alias string) (string, error) {
+// dropMissingLater deletes missing mark of created link once more: lookup
+// which read db before link was committed may cache it as missing after the
+// first delete. Such lookup is done within read and cache timeouts.
+func (s *Storage) dropMissingLater(domain, alias string) {
+	const op = "internal.storage.dropMissingLater"
+
+	if s.negativeTTL <= 0 {
+		return
+	}
+	delay := s.timeouts.Read + s.timeouts.Cache
+	if s.timeouts.Read <= 0 || s.timeouts.Cache <= 0 {
+		delay = DefaultReadTimeout + DefaultCacheTimeout
+	}
+
+	s.wg.Add(1)
+	go func() {
+		defer s.wg.Done()
+
+		t := time.NewTimer(delay)
+		defer t.Stop()
+		select {
+		case <-s.ctx.Done():
+			return
+		case <-t.C:
+		}
+
+		ctx, cancel := withTimeout(s.ctx, s.timeouts.Cache)
+		defer cancel()
+		err := s.c.DeleteURL(ctx, domain, alias)
+		if err != nil {
+			zlog.Logger.Error().Err(err).Fields(map[string]any{"op": op}).Send()
+		}
+	}()
+}
+
+func (s *Storage) URL(ctx context.Context, domain, alias string) (url.URL, error) {
 	const op = "internal.storage.GetURL"
 
-	original, err := s.c.URL(alias)
-	if err != nil {
-		return original, fmt.Errorf("%s: %w", op, err)
+	if s.local != nil {
+		if u, ok := s.local.Get(localKey(domain, alias)); ok {
+			s.stats.localHits.Add(1)
+			return u, nil
+		}
+	}
+
+	cacheCtx, cancel := withTimeout(ctx, s.timeouts.Cache)
+	u, err := s.c.URL(cacheCtx, domain, alias)
+	cancel()
+	if errors.Is(err, ErrNotFound) {
+		s.stats.negativeHits.Add(1)
+		return u, ErrNotFound
+	} else if err != nil {
+		return u, fmt.Errorf("%s: %w", op, err)
+	}
+	if u.Original != "" {
+		s.stats.hits.Add(1)
+		s.addLocal(u)
+		return u, nil
+	}
+	s.stats.misses.Add(1)
+
+	// shared lookup isn't canceled with request which started it
+	res, err, shared := s.lookups.Do(domain+"/"+alias, func() (any, error) {
+		return s.lookup(context.WithoutCancel(ctx), domain, alias)
+	})
+	if shared {
+		s.stats.coalesced.Add(1)
+	}
+	if errors.Is(err, ErrNotFound) {
+		return url.URL{}, ErrNotFound
+	} else if err != nil {
+		return url.URL{}, fmt.Errorf("%s: %w", op, err)
 	}
-	if original != "" {
-		return original, nil
+	u = res.(url.URL)
+	s.addLocal(u)
+
+	return u, nil
+}
+
+func (s *Storage) addLocal(u url.URL) {
+	if s.local != nil {
+		s.local.Add(localKey(u.Domain, u.Alias), u)
 	}
+}
 
-	original, err = s.db.URL(alias)
+// lookup reads link from db and caches it, missing link is cached for
+// negative ttl.
+func (s *Storage) lookup(ctx context.Context, domain, alias string) (url.URL, error) {
+	const op = "internal.storage.lookup"
+
+	dbCtx, cancel := withTimeout(ctx, s.timeouts.Read)
+	u, err := s.db.URL(dbCtx, domain, alias)
+	cancel()
 	if errors.Is(err, sql.ErrNoRows) {
-		return original, ErrNotFound
+		if s.negativeTTL > 0 {
+			cacheCtx, cancel := withTimeout(ctx, s.timeouts.Cache)
+			err = s.c.AddMissing(cacheCtx, domain, alias, s.negativeTTL)
+			cancel()
+			if err != nil {
+				zlog.Logger.Error().Err(err).Fields(map[string]any{"op": op}).Send()
+			}
+		}
+		return u, ErrNotFound
 	} else if err != nil {
-		return original, fmt.Errorf("%s: %w", op, err)
+		return u, err
 	}
 
-	err = s.c.AddURL(alias, original)
+	cacheCtx, cancel := withTimeout(ctx, s.timeouts.Cache)
+	err = s.c.AddURL(cacheCtx, u)
+	cancel()
 	if err != nil {
 		zlog.Logger.Error().Err(err).Fields(map[string]any{"op": op}).Send()
 	}
 
-	return original, nil
+	return u, nil
 }
 
-func (s *Storage) CreateRedirects(redirects []redirect.Redirect) {
-	s.db.CreateRedirects(redirects)
+// URLByHash returns owner's link on domain with given hash of normalized
+// original, it isn't cached as it's used only on creation.
+func (s *Storage) URLByHash(ctx context.Context, owner, domain, hash string) (url.URL, error) {
+	const op = "internal.storage.URLByHash"
+
+	ctx, cancel := withTimeout(ctx, s.timeouts.Read)
+	defer cancel()
+
+	u, err := s.db.URLByHash(ctx, owner, domain, hash)
+	if errors.Is(err, sql.ErrNoRows) {
+		return u, ErrNotFound
+	} else if err != nil {
+		return u, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return u, nil
 }
 
-func (s *Storage) Redirects(alias string) ([]redirect.Redirect, error) {
+// UpdateURL saves changes of link and drops its cached versions on all
+// instances.
+func (s *Storage) UpdateURL(ctx context.Context, u url.URL) error {
+	const op = "internal.storage.UpdateURL"
+
+	dbCtx, cancel := withTimeout(ctx, s.timeouts.Write)
+	updated, err := s.db.UpdateURL(dbCtx, u)
+	cancel()
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	} else if !updated {
+		return ErrNotFound
+	}
+	s.invalidate(ctx, op, u.Domain, u.Alias)
+
+	return nil
+}
+
+func (s *Storage) URLs(ctx context.Context, f url.Filter) ([]url.Item, error) {
+	const op = "internal.storage.URLs"
+
+	ctx, cancel := withTimeout(ctx, s.timeouts.Read)
+	defer cancel()
+
+	res, err := s.db.URLs(ctx, f)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return res, nil
+}
+
+func (s *Storage) NextAliasID(ctx context.Context) (int64, error) {
+	const op = "internal.storage.NextAliasID"
+
+	ctx, cancel := withTimeout(ctx, s.timeouts.Read)
+	defer cancel()
+
+	id, err := s.db.NextAliasID(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return id, nil
+}
+
+func (s *Storage) CreateRedirects(ctx context.Context, redirects []redirect.Redirect) error {
+	const op = "internal.storage.CreateRedirects"
+
+	ctx, cancel := withTimeout(ctx, s.timeouts.Write)
+	defer cancel()
+
+	err := s.db.CreateRedirects(ctx, redirects)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+func (s *Storage) Redirects(ctx context.Context, alias string) ([]redirect.Redirect, error) {
 	const op = "internal.storage.GetRedirects"
 
-	redirects, err := s.db.Redirects(alias)
+	ctx, cancel := withTimeout(ctx, s.timeouts.Analytics)
+	defer cancel()
+
+	redirects, err := s.db.Redirects(ctx, alias)
 	if errors.Is(err, sql.ErrNoRows) {
 		return redirects, ErrNotFound
 	} else if err != nil {
@@ -103,20 +396,161 @@ func (s *Storage) Redirects(alias string) ([]redirect.Redirect, error) {
 	return redirects, nil
 }
 
-func (s *Storage) AgrigatedRedirects(opts redirect.AgrigateOpts) (redirect.Agrigated, error) {
+func (s *Storage) AgrigatedRedirects(ctx context.Context, opts redirect.AgrigateOpts) (redirect.Agrigated, error) {
 	const op = "internal.storage.AgrigatedRedirects"
 
-	res, err := s.db.AgrigatedRedirects(opts)
-	if errors.Is(err, sql.ErrNoRows) || res.Total == 0 {
+	ctx, cancel := withTimeout(ctx, s.timeouts.Analytics)
+	defer cancel()
+
+	res, err := s.db.AgrigatedRedirects(ctx, opts)
+	if errors.Is(err, sql.ErrNoRows) {
 		return redirect.Agrigated{}, ErrNotFound
 	} else if err != nil {
 		return redirect.Agrigated{}, fmt.Errorf("%s: %w", op, err)
+	} else if res.Total == 0 {
+		return redirect.Agrigated{}, ErrNotFound
+	}
+
+	return res, nil
+}
+
+func (s *Storage) TagStats(ctx context.Context, opts redirect.TagOpts) (redirect.TagStats, error) {
+	const op = "internal.storage.TagStats"
+
+	ctx, cancel := withTimeout(ctx, s.timeouts.Analytics)
+	defer cancel()
+
+	res, err := s.db.TagStats(ctx, opts)
+	if err != nil {
+		return res, fmt.Errorf("%s: %w", op, err)
+	} else if len(res.Links) == 0 {
+		return res, ErrNotFound
+	}
+
+	return res, nil
+}
+
+func (s *Storage) CreateDomain(ctx context.Context, host string) (domain.Domain, error) {
+	const op = "internal.storage.CreateDomain"
+
+	ctx, cancel := withTimeout(ctx, s.timeouts.Write)
+	defer cancel()
+
+	d, err := s.db.CreateDomain(ctx, host)
+	if errors.Is(s.db.HandleError(err), postgres.ErrNotUnique) {
+		return d, ErrNotUnique
+	} else if err != nil {
+		return d, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return d, nil
+}
+
+func (s *Storage) Domains(ctx context.Context) ([]domain.Domain, error) {
+	const op = "internal.storage.Domains"
+
+	ctx, cancel := withTimeout(ctx, s.timeouts.Read)
+	defer cancel()
+
+	res, err := s.db.Domains(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return res, nil
+}
+
+func (s *Storage) DeleteDomain(ctx context.Context, host string) error {
+	const op = "internal.storage.DeleteDomain"
+
+	ctx, cancel := withTimeout(ctx, s.timeouts.Write)
+	defer cancel()
+
+	deleted, err := s.db.DeleteDomain(ctx, host)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	} else if !deleted {
+		return ErrNotFound
+	}
+
+	return nil
+}
+
+func (s *Storage) TryLockChecks(ctx context.Context) (func(), bool, error) {
+	const op = "internal.storage.TryLockChecks"
+
+	ctx, cancel := withTimeout(ctx, s.timeouts.Write)
+	defer cancel()
+
+	release, ok, err := s.db.TryLockChecks(ctx)
+	if err != nil {
+		return nil, false, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return release, ok, nil
+}
+
+func (s *Storage) URLsToCheck(ctx context.Context, before time.Time, limit int) ([]url.URL, error) {
+	const op = "internal.storage.URLsToCheck"
+
+	ctx, cancel := withTimeout(ctx, s.timeouts.Read)
+	defer cancel()
+
+	res, err := s.db.URLsToCheck(ctx, before, limit)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return res, nil
+}
+
+func (s *Storage) SaveCheck(ctx context.Context, c health.Check) error {
+	const op = "internal.storage.SaveCheck"
+
+	ctx, cancel := withTimeout(ctx, s.timeouts.Write)
+	defer cancel()
+
+	err := s.db.SaveCheck(ctx, c)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+// SetPageTitle saves fetched title of destination, cached link isn't dropped
+// as title isn't used in redirects.
+func (s *Storage) SetPageTitle(ctx context.Context, urlID int64, title string) error {
+	const op = "internal.storage.SetPageTitle"
+
+	ctx, cancel := withTimeout(ctx, s.timeouts.Write)
+	defer cancel()
+
+	err := s.db.SetPageTitle(ctx, urlID, title)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+func (s *Storage) BrokenLinks(ctx context.Context, page int) ([]health.Check, error) {
+	const op = "internal.storage.BrokenLinks"
+
+	ctx, cancel := withTimeout(ctx, s.timeouts.Read)
+	defer cancel()
+
+	res, err := s.db.BrokenLinks(ctx, page)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
 	}
 
 	return res, nil
 }
 
 func (s *Storage) Shutdown() {
+	s.cancel()
+	s.wg.Wait()
 	s.c.Shutdown()
 	s.db.Shutdown()
 }
@@ -0,0 +1,210 @@
+package storage_test
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"runtime"
+	"shortener/internal/entities/redirect"
+	"shortener/internal/entities/url"
+	"shortener/internal/storage"
+	"shortener/internal/storage/memory"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// slowDB serves links only after release, so lookups pile up.
+type slowDB struct {
+	storage.DB
+	calls   atomic.Int64
+	release chan struct{}
+}
+
+func (db *slowDB) URL(_ context.Context, domain, alias string) (url.URL, error) {
+	db.calls.Add(1)
+	<-db.release
+	return url.URL{Alias: alias, Domain: domain, Original: "https://example.com"}, nil
+}
+
+func TestURLCoalescing(t *testing.T) {
+	db := &slowDB{release: make(chan struct{})}
+	s := storage.New(db, memory.NewCache())
+
+	const lookups = 10
+	wg := new(sync.WaitGroup)
+	started := make(chan struct{}, lookups)
+	for i := 0; i < lookups; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			started <- struct{}{}
+			u, err := s.URL(t.Context(), "", "viral")
+			if err != nil || u.Original != "https://example.com" {
+				t.Errorf("URL() = %+v, %v", u, err)
+			}
+		}()
+	}
+	for i := 0; i < lookups; i++ {
+		<-started
+	}
+	// let lookups join query before db answers
+	for s.CacheStats().Misses != lookups {
+		runtime.Gosched()
+	}
+	time.Sleep(50 * time.Millisecond)
+	close(db.release)
+	wg.Wait()
+
+	if calls := db.calls.Load(); calls != 1 {
+		t.Errorf("db queries = %d, want 1", calls)
+	}
+	stats := s.CacheStats()
+	if stats.Coalesced != lookups {
+		t.Errorf("coalesced = %d, want %d", stats.Coalesced, lookups)
+	}
+
+	// the next lookup is served by cache
+	if _, err := s.URL(t.Context(), "", "viral"); err != nil || s.CacheStats().Hits != 1 {
+		t.Errorf("cached lookup: %v, stats %+v", err, s.CacheStats())
+	}
+}
+
+// bus delivers invalidations between storages sharing cache, like redis
+// channel does between instances.
+type bus struct {
+	mu    sync.Mutex
+	drops []func(domain, alias string)
+}
+
+func (b *bus) subscribers() int {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return len(b.drops)
+}
+
+type busCache struct {
+	*memory.Cache
+	bus *bus
+}
+
+func (c busCache) PublishInvalidation(_ context.Context, domain, alias string) error {
+	c.bus.mu.Lock()
+	defer c.bus.mu.Unlock()
+	for _, drop := range c.bus.drops {
+		drop(domain, alias)
+	}
+	return nil
+}
+
+func (c busCache) Invalidations(ctx context.Context, drop func(domain, alias string), _ func()) {
+	c.bus.mu.Lock()
+	c.bus.drops = append(c.bus.drops, drop)
+	c.bus.mu.Unlock()
+	<-ctx.Done()
+}
+
+func TestURLLocalCache(t *testing.T) {
+	db := memory.New()
+	u := url.URL{Alias: "top", Original: "https://example.com", Title: "old"}
+	if _, err := db.CreateURL(t.Context(), u); err != nil {
+		t.Fatal(err)
+	}
+
+	shared := memory.NewCache()
+	b := new(bus)
+	s1 := storage.New(db, busCache{shared, b}, storage.WithLocalCache(10, time.Minute))
+	defer s1.Shutdown()
+	s2 := storage.New(db, busCache{shared, b}, storage.WithLocalCache(10, time.Minute))
+	defer s2.Shutdown()
+	for b.subscribers() != 2 {
+		runtime.Gosched()
+	}
+
+	for i := 0; i < 2; i++ {
+		got, err := s2.URL(t.Context(), "", "top")
+		if err != nil || got.Title != u.Title {
+			t.Fatalf("URL() = %+v, %v", got, err)
+		}
+	}
+	if stats := s2.CacheStats(); stats.LocalHits != 1 || stats.Misses != 1 {
+		t.Errorf("stats = %+v, want 1 local hit and 1 miss", stats)
+	}
+
+	// other instance changes link, local copy is dropped
+	u.Title = "new"
+	if err := s1.UpdateURL(t.Context(), u); err != nil {
+		t.Fatal(err)
+	}
+	got, err := s2.URL(t.Context(), "", "top")
+	if err != nil || got.Title != u.Title {
+		t.Errorf("URL() after update = %+v, %v", got, err)
+	}
+}
+
+func TestCreateURLDropsLateMissingMark(t *testing.T) {
+	c := memory.NewCache()
+	s := storage.New(memory.New(), c, storage.WithTimeouts(storage.Timeouts{
+		Read: 10 * time.Millisecond, Cache: 10 * time.Millisecond,
+	}))
+	defer s.Shutdown()
+
+	_, err := s.CreateURL(t.Context(), url.URL{Alias: "fresh", Original: "https://example.com"})
+	if err != nil {
+		t.Fatalf("CreateURL() = %v", err)
+	}
+	// lookup which read db before commit caches link as missing afterwards
+	err = c.AddMissing(t.Context(), "", "fresh", time.Minute)
+	if err != nil {
+		t.Fatalf("AddMissing() = %v", err)
+	}
+
+	deadline := time.Now().Add(time.Second)
+	for {
+		u, err := s.URL(t.Context(), "", "fresh")
+		if err == nil && u.Original == "https://example.com" {
+			return
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("URL() = %+v, %v, missing mark isn't dropped", u, err)
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+// failingDB fails analytics queries with err.
+type failingDB struct {
+	storage.DB
+	err error
+}
+
+func (db failingDB) AgrigatedRedirects(context.Context, redirect.AgrigateOpts) (redirect.Agrigated, error) {
+	return redirect.Agrigated{}, db.err
+}
+
+func TestAgrigatedRedirectsErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		err     error
+		wantErr error
+	}{
+		{name: "deadline", err: context.DeadlineExceeded, wantErr: context.DeadlineExceeded},
+		{name: "canceled", err: context.Canceled, wantErr: context.Canceled},
+		{name: "no rows", err: sql.ErrNoRows, wantErr: storage.ErrNotFound},
+		{name: "no redirects", wantErr: storage.ErrNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := storage.New(failingDB{err: tt.err}, memory.NewCache())
+			_, err := s.AgrigatedRedirects(t.Context(), redirect.AgrigateOpts{Alias: "a"})
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("AgrigatedRedirects() = %v, want %v", err, tt.wantErr)
+			}
+			if tt.wantErr != storage.ErrNotFound && errors.Is(err, storage.ErrNotFound) {
+				t.Errorf("AgrigatedRedirects() = %v, failure is reported as not found", err)
+			}
+		})
+	}
+}
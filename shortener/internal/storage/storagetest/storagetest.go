@@ -0,0 +1,435 @@
+// Package storagetest checks that storage backends behave the same way, every
+// implementation of storage.DB and storage.Cache should pass Run.
+package storagetest
+
+import (
+	"errors"
+	"shortener/internal/entities/health"
+	"shortener/internal/entities/redirect"
+	"shortener/internal/entities/url"
+	"shortener/internal/storage"
+	"slices"
+	"testing"
+	"time"
+)
+
+// Run checks storage built on top of empty backends.
+func Run(t *testing.T, s *storage.Storage) {
+	t.Run("urls", func(t *testing.T) { testURLs(t, s) })
+	t.Run("listing", func(t *testing.T) { testListing(t, s) })
+	t.Run("redirects", func(t *testing.T) { testRedirects(t, s) })
+	t.Run("tags", func(t *testing.T) { testTags(t, s) })
+	t.Run("domains", func(t *testing.T) { testDomains(t, s) })
+	t.Run("checks", func(t *testing.T) { testChecks(t, s) })
+}
+
+func create(t *testing.T, s *storage.Storage, u url.URL) url.URL {
+	t.Helper()
+
+	if _, err := s.CreateURL(t.Context(), u); err != nil {
+		t.Fatalf("create %q: %v", u.Alias, err)
+	}
+	res, err := s.URL(t.Context(), u.Domain, u.Alias)
+	if err != nil {
+		t.Fatalf("get %q: %v", u.Alias, err)
+	}
+	return res
+}
+
+func aliases(items []url.Item) []string {
+	res := make([]string, 0, len(items))
+	for _, i := range items {
+		res = append(res, i.Alias)
+	}
+	return res
+}
+
+func testURLs(t *testing.T, s *storage.Storage) {
+	u := create(t, s, url.URL{
+		Alias: "st-url", Original: "https://example.com/a", Owner: "st-urls",
+		OriginalHash: "hash-a", GeoTargets: map[string]string{"DE": "https://example.de"},
+		ForwardQuery: true, UTM: url.UTM{Source: "news"},
+		Tags: []string{"b", "a"}, Folder: "f", Title: "Title", Description: "Desc",
+	})
+	if u.ID == 0 || u.Original != "https://example.com/a" || u.Owner != "st-urls" ||
+		u.GeoTargets["DE"] != "https://example.de" || !u.ForwardQuery ||
+		u.UTM.Source != "news" || u.Folder != "f" || u.Title != "Title" ||
+		u.Description != "Desc" || u.CreatedAt.IsZero() {
+		t.Fatalf("unexpected link %+v", u)
+	}
+	if !slices.Equal(u.Tags, []string{"a", "b"}) {
+		t.Fatalf("tags aren't sorted: %v", u.Tags)
+	}
+
+	if _, err := s.CreateURL(t.Context(), url.URL{Alias: "st-url", Original: "https://example.com/b"}); !errors.Is(err, storage.ErrNotUnique) {
+		t.Fatalf("duplicate alias: expected ErrNotUnique, got %v", err)
+	}
+	// the same alias on another domain is fine
+	create(t, s, url.URL{Alias: "st-url", Domain: "st.example", Original: "https://example.com/b"})
+
+	if _, err := s.URL(t.Context(), "", "st-missing"); !errors.Is(err, storage.ErrNotFound) {
+		t.Fatalf("missing link: expected ErrNotFound, got %v", err)
+	}
+	before := s.CacheStats()
+	if _, err := s.URL(t.Context(), "", "st-missing"); !errors.Is(err, storage.ErrNotFound) {
+		t.Fatalf("cached missing link: expected ErrNotFound, got %v", err)
+	}
+	if hits := s.CacheStats().NegativeHits - before.NegativeHits; hits != 1 {
+		t.Fatalf("missing link isn't cached, negative hits %d", hits)
+	}
+	// creation drops missing mark, create looks link up
+	if _, err := s.URL(t.Context(), "", "st-missing-created"); !errors.Is(err, storage.ErrNotFound) {
+		t.Fatalf("missing link: expected ErrNotFound, got %v", err)
+	}
+	create(t, s, url.URL{Alias: "st-missing-created", Original: "https://example.com"})
+
+	byHash, err := s.URLByHash(t.Context(), "st-urls", "", "hash-a")
+	if err != nil || byHash.Alias != "st-url" {
+		t.Fatalf("by hash: %+v, %v", byHash, err)
+	}
+	if _, err := s.URLByHash(t.Context(), "st-other", "", "hash-a"); !errors.Is(err, storage.ErrNotFound) {
+		t.Fatalf("by hash of other owner: expected ErrNotFound, got %v", err)
+	}
+	if _, err := s.CreateURL(t.Context(), url.URL{
+		Alias: "st-url-same-hash", Original: "https://example.com/a", Owner: "st-urls", OriginalHash: "hash-a",
+	}); !errors.Is(err, storage.ErrNotUnique) {
+		t.Fatalf("duplicate hash: expected ErrNotUnique, got %v", err)
+	}
+	// links without hash aren't reused, so they may repeat
+	create(t, s, url.URL{Alias: "st-url-no-hash-1", Original: "https://example.com/a", Owner: "st-urls"})
+	create(t, s, url.URL{Alias: "st-url-no-hash-2", Original: "https://example.com/a", Owner: "st-urls"})
+
+	u.Tags = []string{"c"}
+	u.Folder, u.Title, u.Description = "g", "New", ""
+	if err := s.UpdateURL(t.Context(), u); err != nil {
+		t.Fatalf("update: %v", err)
+	}
+	updated, err := s.URL(t.Context(), "", "st-url")
+	if err != nil {
+		t.Fatalf("get updated: %v", err)
+	}
+	if updated.Folder != "g" || updated.Title != "New" || updated.Description != "" ||
+		!slices.Equal(updated.Tags, []string{"c"}) || updated.UpdatedAt.Before(u.UpdatedAt) {
+		t.Fatalf("update isn't visible: %+v", updated)
+	}
+	if err := s.UpdateURL(t.Context(), url.URL{Alias: "st-missing"}); !errors.Is(err, storage.ErrNotFound) {
+		t.Fatalf("update missing: expected ErrNotFound, got %v", err)
+	}
+
+	first, err := s.NextAliasID(t.Context())
+	if err != nil {
+		t.Fatalf("next alias id: %v", err)
+	}
+	second, err := s.NextAliasID(t.Context())
+	if err != nil || second <= first {
+		t.Fatalf("next alias id isn't increasing: %d, %d, %v", first, second, err)
+	}
+}
+
+func testListing(t *testing.T, s *storage.Storage) {
+	const owner = "st-listing"
+	for _, u := range []url.URL{
+		{Alias: "st-l1", Original: "https://one.example/Path", Tags: []string{"x"}, Folder: "docs"},
+		{Alias: "st-l2", Original: "https://two.example", Tags: []string{"x", "y"}},
+		{Alias: "st-l3", Original: "https://three.example", Folder: "docs"},
+	} {
+		u.Owner = owner
+		create(t, s, u)
+	}
+	err := s.CreateRedirects(t.Context(), []redirect.Redirect{
+		{Alias: "st-l3", Date: time.Now().UTC()},
+		{Alias: "st-l3", Date: time.Now().UTC()},
+		{Alias: "st-l1", Date: time.Now().UTC()},
+	})
+	if err != nil {
+		t.Fatalf("create redirects: %v", err)
+	}
+
+	tests := []struct {
+		name   string
+		filter url.Filter
+		want   []string
+	}{
+		{"newest first", url.Filter{}, []string{"st-l3", "st-l2", "st-l1"}},
+		{"oldest first", url.Filter{Asc: true}, []string{"st-l1", "st-l2", "st-l3"}},
+		{"by clicks", url.Filter{Sort: url.SortClicks}, []string{"st-l3", "st-l1", "st-l2"}},
+		{"by tag", url.Filter{Tag: "x", Asc: true}, []string{"st-l1", "st-l2"}},
+		{"by folder", url.Filter{Folder: "docs", Asc: true}, []string{"st-l1", "st-l3"}},
+		{"search is case insensitive", url.Filter{Query: "path"}, []string{"st-l1"}},
+		{"search by alias", url.Filter{Query: "ST-L2"}, []string{"st-l2"}},
+		{"search escapes wildcards", url.Filter{Query: "%"}, []string{}},
+		{"unchecked", url.Filter{Status: url.StatusUnchecked, Asc: true}, []string{"st-l1", "st-l2", "st-l3"}},
+		{"second page", url.Filter{Page: 2}, []string{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.filter.Owner = owner
+			res, err := s.URLs(t.Context(), tt.filter)
+			if err != nil {
+				t.Fatalf("list: %v", err)
+			}
+			if got := aliases(res); !slices.Equal(got, tt.want) {
+				t.Fatalf("expected %v, got %v", tt.want, got)
+			}
+		})
+	}
+
+	res, err := s.URLs(t.Context(), url.Filter{Owner: owner, Sort: url.SortClicks})
+	if err != nil || len(res) == 0 || res[0].Clicks != 2 {
+		t.Fatalf("clicks aren't counted: %+v, %v", res, err)
+	}
+}
+
+func testRedirects(t *testing.T, s *storage.Storage) {
+	day := time.Date(2025, 3, 1, 0, 0, 0, 0, time.UTC)
+	batch := []redirect.Redirect{
+		{Alias: "st-r", Date: day, UserAgent: "Mozilla", UTM: url.UTM{Source: "mail"}},
+		{Alias: "st-r", Date: day.Add(time.Hour), UserAgent: "curl"},
+		{Alias: "st-r", Date: day.Add(48 * time.Hour), UserAgent: "Mozilla"},
+		{Alias: "st-r", Domain: "st.example", Date: day, UserAgent: "Mozilla"},
+	}
+	for i := 0; i < redirect.PageSize; i++ {
+		batch = append(batch, redirect.Redirect{Alias: "st-rp", Date: day.Add(time.Duration(i) * time.Minute)})
+	}
+	batch = append(batch, redirect.Redirect{Alias: "st-rp", Date: day.Add(-time.Minute)})
+	if err := s.CreateRedirects(t.Context(), batch); err != nil {
+		t.Fatalf("create redirects: %v", err)
+	}
+
+	all, err := s.Redirects(t.Context(), "st-r")
+	if err != nil || len(all) != 4 {
+		t.Fatalf("redirects of alias on every domain: %d, %v", len(all), err)
+	}
+
+	tests := []struct {
+		name  string
+		opts  redirect.AgrigateOpts
+		total int64
+		want  []string
+	}{
+		{
+			name:  "all of domain",
+			opts:  redirect.AgrigateOpts{Alias: "st-r"},
+			total: 3, want: []string{"Mozilla", "curl", "Mozilla"},
+		},
+		{
+			name: "inclusive dates",
+			opts: redirect.AgrigateOpts{
+				Alias: "st-r", StartDate: "2025-03-01 01:00:00", EndDate: "2025-03-03 00:00:00",
+			},
+			total: 3, want: []string{"curl", "Mozilla"},
+		},
+		{
+			name: "filter by substring",
+			opts: redirect.AgrigateOpts{
+				Alias: "st-r", FilterColumn: redirect.FilterUserAgent, ValueForFilter: "zill",
+			},
+			total: 3, want: []string{"Mozilla", "Mozilla"},
+		},
+		{
+			name: "filter by utm",
+			opts: redirect.AgrigateOpts{
+				Alias: "st-r", FilterColumn: redirect.FilterUTMSource, ValueForFilter: "mail",
+			},
+			total: 3, want: []string{"Mozilla"},
+		},
+		{
+			name:  "custom domain",
+			opts:  redirect.AgrigateOpts{Alias: "st-r", Domain: "st.example"},
+			total: 1, want: []string{"Mozilla"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			res, err := s.AgrigatedRedirects(t.Context(), tt.opts)
+			if err != nil {
+				t.Fatalf("agrigate: %v", err)
+			}
+			got := make([]string, 0, len(res.Redirects))
+			for _, r := range res.Redirects {
+				got = append(got, r.UserAgent)
+			}
+			if res.Total != tt.total || !slices.Equal(got, tt.want) {
+				t.Fatalf("expected %d %v, got %d %v", tt.total, tt.want, res.Total, got)
+			}
+		})
+	}
+
+	// filter isn't applied to days, boundary days are counted whole
+	summary, err := s.AgrigatedRedirects(t.Context(), redirect.AgrigateOpts{
+		Alias: "st-r", StartDate: "2025-03-01 01:00:00", EndDate: "2025-03-02 00:00:00",
+		FilterColumn: redirect.FilterUserAgent, ValueForFilter: "curl", Summary: true,
+	})
+	wantDaily := []redirect.DayClicks{
+		{Day: day, Clicks: 2, Uniques: 2, TopUserAgent: "Mozilla", TopUTMSource: "mail"},
+	}
+	if err != nil || summary.Total != 3 || summary.Redirects != nil || !DailyEqual(summary.Daily, wantDaily) {
+		t.Fatalf("summary: expected %+v, got %+v, %v", wantDaily, summary, err)
+	}
+	full, err := s.AgrigatedRedirects(t.Context(), redirect.AgrigateOpts{Alias: "st-r"})
+	wantDaily = append(wantDaily, redirect.DayClicks{
+		Day: day.Add(48 * time.Hour), Clicks: 1, Uniques: 1, TopUserAgent: "Mozilla",
+	})
+	if err != nil || !DailyEqual(full.Daily, wantDaily) {
+		t.Fatalf("daily: expected %+v, got %+v, %v", wantDaily, full.Daily, err)
+	}
+
+	page, err := s.AgrigatedRedirects(t.Context(), redirect.AgrigateOpts{Alias: "st-rp", Page: 2})
+	if err != nil || len(page.Redirects) != 1 || page.Total != redirect.PageSize+1 {
+		t.Fatalf("second page: %+v, %v", page, err)
+	}
+	if !page.Redirects[0].Date.Equal(day.Add((redirect.PageSize - 1) * time.Minute)) {
+		t.Fatalf("redirects aren't ordered by date: %v", page.Redirects[0].Date)
+	}
+
+	if _, err := s.AgrigatedRedirects(t.Context(), redirect.AgrigateOpts{Alias: "st-missing"}); !errors.Is(err, storage.ErrNotFound) {
+		t.Fatalf("missing alias: expected ErrNotFound, got %v", err)
+	}
+}
+
+// DailyEqual compares days by time instant, drivers return them in
+// different locations.
+func DailyEqual(a, b []redirect.DayClicks) bool {
+	return slices.EqualFunc(a, b, func(a, b redirect.DayClicks) bool {
+		a.Day, b.Day = a.Day.UTC(), b.Day.UTC()
+		return a == b
+	})
+}
+
+func testTags(t *testing.T, s *storage.Storage) {
+	create(t, s, url.URL{Alias: "st-t1", Original: "https://example.com", Tags: []string{"st-promo"}})
+	create(t, s, url.URL{Alias: "st-t2", Original: "https://example.com", Tags: []string{"st-promo", "st-other"}})
+	create(t, s, url.URL{Alias: "st-t3", Original: "https://example.com", Tags: []string{"st-promo"}})
+
+	day := time.Date(2025, 3, 1, 0, 0, 0, 0, time.UTC)
+	err := s.CreateRedirects(t.Context(), []redirect.Redirect{
+		{Alias: "st-t2", Date: day},
+		{Alias: "st-t2", Date: day.Add(time.Hour)},
+		{Alias: "st-t1", Date: day.Add(24 * time.Hour)},
+	})
+	if err != nil {
+		t.Fatalf("create redirects: %v", err)
+	}
+
+	res, err := s.TagStats(t.Context(), redirect.TagOpts{Tag: "st-promo"})
+	if err != nil {
+		t.Fatalf("tag stats: %v", err)
+	}
+	want := []redirect.LinkClicks{
+		{Alias: "st-t2", Clicks: 2}, {Alias: "st-t1", Clicks: 1}, {Alias: "st-t3"},
+	}
+	if res.Total != 3 || !slices.Equal(res.Links, want) {
+		t.Fatalf("expected %v, got %d %v", want, res.Total, res.Links)
+	}
+
+	res, err = s.TagStats(t.Context(), redirect.TagOpts{Tag: "st-promo", EndDate: "2025-03-01 01:00:00"})
+	if err != nil || res.Total != 2 || len(res.Links) != 3 {
+		t.Fatalf("tag stats in range: %+v, %v", res, err)
+	}
+
+	if _, err := s.TagStats(t.Context(), redirect.TagOpts{Tag: "st-missing"}); !errors.Is(err, storage.ErrNotFound) {
+		t.Fatalf("missing tag: expected ErrNotFound, got %v", err)
+	}
+}
+
+func testDomains(t *testing.T, s *storage.Storage) {
+	for _, host := range []string{"b.st.example", "a.st.example"} {
+		d, err := s.CreateDomain(t.Context(), host)
+		if err != nil || d.Host != host || d.CreatedAt.IsZero() {
+			t.Fatalf("create domain: %+v, %v", d, err)
+		}
+	}
+	if _, err := s.CreateDomain(t.Context(), "a.st.example"); !errors.Is(err, storage.ErrNotUnique) {
+		t.Fatalf("duplicate domain: expected ErrNotUnique, got %v", err)
+	}
+
+	res, err := s.Domains(t.Context())
+	if err != nil {
+		t.Fatalf("domains: %v", err)
+	}
+	hosts := make([]string, 0, len(res))
+	for _, d := range res {
+		hosts = append(hosts, d.Host)
+	}
+	if !slices.Equal(hosts, []string{"a.st.example", "b.st.example"}) {
+		t.Fatalf("domains aren't sorted by host: %v", hosts)
+	}
+
+	if err := s.DeleteDomain(t.Context(), "a.st.example"); err != nil {
+		t.Fatalf("delete domain: %v", err)
+	}
+	if err := s.DeleteDomain(t.Context(), "a.st.example"); !errors.Is(err, storage.ErrNotFound) {
+		t.Fatalf("delete missing domain: expected ErrNotFound, got %v", err)
+	}
+}
+
+// testChecks runs last, as it checks every link created before.
+func testChecks(t *testing.T, s *storage.Storage) {
+	now := time.Now().UTC().Truncate(time.Second)
+
+	todo, err := s.URLsToCheck(t.Context(), now, 1000)
+	if err != nil || len(todo) < 3 {
+		t.Fatalf("links to check: %d, %v", len(todo), err)
+	}
+	for i, u := range todo {
+		c := health.Check{URLID: u.ID, Status: 200, CheckedAt: now.Add(-time.Hour)}
+		switch i {
+		case 0:
+			c = health.Check{URLID: u.ID, Error: "timeout", CheckedAt: now.Add(-2 * time.Hour)}
+		case 1:
+			c = health.Check{URLID: u.ID, Status: 404, Latency: 15 * time.Millisecond, CheckedAt: now.Add(-3 * time.Hour)}
+		}
+		if err := s.SaveCheck(t.Context(), c); err != nil {
+			t.Fatalf("save check: %v", err)
+		}
+	}
+
+	left, err := s.URLsToCheck(t.Context(), now.Add(-90*time.Minute), 1000)
+	if err != nil || len(left) != 2 || left[0].ID != todo[1].ID || left[1].ID != todo[0].ID {
+		t.Fatalf("links checked before time, least recent first: %+v, %v", left, err)
+	}
+	limited, err := s.URLsToCheck(t.Context(), now, 1)
+	if err != nil || len(limited) != 1 {
+		t.Fatalf("limit isn't applied: %d, %v", len(limited), err)
+	}
+
+	broken, err := s.BrokenLinks(t.Context(), 0)
+	if err != nil || len(broken) != 2 {
+		t.Fatalf("broken links: %+v, %v", broken, err)
+	}
+	if broken[0].URLID != todo[0].ID || broken[0].Error != "timeout" || broken[0].Alias != todo[0].Alias ||
+		broken[1].Status != 404 || broken[1].Latency != 15*time.Millisecond || broken[1].Original != todo[1].Original {
+		t.Fatalf("broken links aren't recent first: %+v", broken)
+	}
+
+	// a newer check replaces the previous one
+	if err := s.SaveCheck(t.Context(), health.Check{URLID: todo[0].ID, Status: 200, CheckedAt: now}); err != nil {
+		t.Fatalf("save check: %v", err)
+	}
+	broken, err = s.BrokenLinks(t.Context(), 1)
+	if err != nil || len(broken) != 1 {
+		t.Fatalf("fixed link is still broken: %+v, %v", broken, err)
+	}
+
+	if err := s.SetPageTitle(t.Context(), todo[0].ID, "Page"); err != nil {
+		t.Fatalf("set page title: %v", err)
+	}
+	titled, err := s.URLsToCheck(t.Context(), now.Add(time.Second), 1000)
+	if err != nil {
+		t.Fatalf("links to check: %v", err)
+	}
+	i := slices.IndexFunc(titled, func(u url.URL) bool { return u.ID == todo[0].ID })
+	if i < 0 || titled[i].PageTitle != "Page" {
+		t.Fatalf("page title isn't saved: %+v", titled)
+	}
+
+	ok, err := s.URLs(t.Context(), url.Filter{Owner: "st-listing", Status: url.StatusOK})
+	if err != nil {
+		t.Fatalf("list checked: %v", err)
+	}
+	for _, u := range ok {
+		if u.ID == todo[1].ID {
+			t.Fatalf("broken link is listed as ok")
+		}
+	}
+}
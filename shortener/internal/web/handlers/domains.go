@@ -0,0 +1,156 @@
+package handlers
+
+import (
+	"crypto/subtle"
+	"errors"
+	"net/http"
+	"shortener/internal/entities/request"
+	"shortener/internal/entities/response"
+	"shortener/internal/service"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/wb-go/wbf/ginext"
+	"github.com/wb-go/wbf/zlog"
+)
+
+// AdminAuth allows request only with "Authorization: Bearer <token>" header.
+// Empty token disables admin API.
+func AdminAuth(token string) gin.HandlerFunc {
+	return func(ctx *ginext.Context) {
+		got, ok := strings.CutPrefix(ctx.GetHeader("Authorization"), "Bearer ")
+		if token == "" || !ok ||
+			subtle.ConstantTimeCompare([]byte(got), []byte(token)) != 1 {
+			ctx.AbortWithStatusJSON(http.StatusForbidden, response.Error(
+				"forbidden",
+			))
+			return
+		}
+
+		ctx.Next()
+	}
+}
+
+// CreateDomain registers custom domain.
+// @Summary Register custom domain
+// @Description Registers branded host with its own alias namespace.
+// @Tags Domains
+// @Accept json
+// @Produce json
+// @Security AdminToken
+// @Param request body request.NewDomain true "Domain"
+// @Success 200 {object} response.Response{result=domain.Domain}
+// @Failure 400 {object} response.Response
+// @Failure 403 {object} response.Response
+// @Failure 500 {object} response.Response
+// @Failure 503 {object} response.Response
+// @Router /api/v1/domains [post]
+func CreateDomain(s servicer) gin.HandlerFunc {
+	return func(ctx *ginext.Context) {
+		const op = "internal.handlers.CreateDomain"
+
+		var nd request.NewDomain
+		if err := ctx.BindJSON(&nd); err != nil {
+			ctx.JSONP(http.StatusBadRequest, response.Error(
+				"wrong json values (type)",
+			))
+			return
+		}
+
+		d, err := s.CreateDomain(ctx.Request.Context(), nd.Host)
+		if errors.Is(err, service.ErrNotValidData) {
+			ctx.JSONP(http.StatusServiceUnavailable, response.Error(
+				err.Error(),
+			))
+			return
+		} else if errors.Is(err, service.ErrNotUnique) {
+			ctx.JSONP(http.StatusServiceUnavailable, response.Error(
+				"domain already registered",
+			))
+			return
+		} else if errors.Is(err, service.ErrDisabled) {
+			ctx.JSONP(http.StatusNotFound, response.Error(
+				"custom domains are disabled",
+			))
+			return
+		} else if err != nil {
+			zlog.Logger.Error().Err(err).Msg("op: " + op)
+			ctx.JSONP(http.StatusInternalServerError, response.Error(
+				"internal server error on our service",
+			))
+			return
+		}
+
+		ctx.JSONP(http.StatusOK, response.OK(d))
+	}
+}
+
+// Domains returns registered custom domains.
+// @Summary List custom domains
+// @Tags Domains
+// @Produce json
+// @Security AdminToken
+// @Success 200 {object} response.Response{result=[]domain.Domain}
+// @Failure 403 {object} response.Response
+// @Failure 500 {object} response.Response
+// @Router /api/v1/domains [get]
+func Domains(s servicer) gin.HandlerFunc {
+	return func(ctx *ginext.Context) {
+		const op = "internal.handlers.Domains"
+
+		res, err := s.Domains(ctx.Request.Context())
+		if errors.Is(err, service.ErrDisabled) {
+			ctx.JSONP(http.StatusNotFound, response.Error(
+				"custom domains are disabled",
+			))
+			return
+		} else if err != nil {
+			zlog.Logger.Error().Err(err).Msg("op: " + op)
+			ctx.JSONP(http.StatusInternalServerError, response.Error(
+				"internal server error on our service",
+			))
+			return
+		}
+
+		ctx.JSONP(http.StatusOK, response.OK(res))
+	}
+}
+
+// DeleteDomain unregisters custom domain.
+// @Summary Unregister custom domain
+// @Description Links of domain are kept, but not served until domain is registered again.
+// @Tags Domains
+// @Produce json
+// @Security AdminToken
+// @Param host path string true "Domain host"
+// @Success 200 {object} response.Response
+// @Failure 403 {object} response.Response
+// @Failure 404 {object} response.Response
+// @Failure 500 {object} response.Response
+// @Router /api/v1/domains/{host} [delete]
+func DeleteDomain(s servicer) gin.HandlerFunc {
+	return func(ctx *ginext.Context) {
+		const op = "internal.handlers.DeleteDomain"
+
+		err := s.DeleteDomain(ctx.Request.Context(), ctx.Param("host"))
+		if errors.Is(err, service.ErrNotFound) {
+			ctx.JSONP(http.StatusNotFound, response.Error(
+				"not found domain",
+			))
+			return
+		} else if errors.Is(err, service.ErrDisabled) {
+			ctx.JSONP(http.StatusNotFound, response.Error(
+				"custom domains are disabled",
+			))
+			return
+		} else if err != nil {
+			zlog.Logger.Error().Err(err).Msg("op: " + op)
+			ctx.JSONP(http.StatusInternalServerError, response.Error(
+				"internal server error on our service",
+			))
+			return
+		}
+
+		ctx.JSONP(http.StatusOK, response.OK(nil))
+	}
+}
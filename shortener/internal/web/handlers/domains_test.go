@@ -0,0 +1,178 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"shortener/internal/entities/domain"
+	"shortener/internal/service"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestAdminAuth(t *testing.T) {
+	tests := []struct {
+		name   string
+		token  string
+		header string
+		want   int
+	}{
+		{
+			name:   "good",
+			token:  "secret",
+			header: "Bearer secret",
+			want:   http.StatusOK,
+		},
+		{
+			name:   "wrong token",
+			token:  "secret",
+			header: "Bearer nope",
+			want:   http.StatusForbidden,
+		},
+		{
+			name:   "no header",
+			token:  "secret",
+			header: "",
+			want:   http.StatusForbidden,
+		},
+		{
+			name:   "admin disabled",
+			token:  "",
+			header: "Bearer ",
+			want:   http.StatusForbidden,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gin.SetMode(gin.TestMode)
+			rr := httptest.NewRecorder()
+			req := httptest.NewRequest(http.MethodGet, "/endpoint", nil)
+			req.Header.Set("Authorization", tt.header)
+			router := gin.Default()
+			router.GET("/endpoint", AdminAuth(tt.token), func(ctx *gin.Context) {
+				ctx.Status(http.StatusOK)
+			})
+			router.ServeHTTP(rr, req)
+			if rr.Result().StatusCode != tt.want {
+				t.Errorf(
+					"AdminAuth() status code get=%d, want %d",
+					rr.Result().StatusCode, tt.want,
+				)
+			}
+		})
+	}
+}
+
+func TestCreateDomain(t *testing.T) {
+	tests := []struct {
+		name string
+		body string
+		f    func(host string) (domain.Domain, error)
+		want int
+	}{
+		{
+			name: "good",
+			body: `{"host": "go.brand-a.com"}`,
+			f: func(host string) (domain.Domain, error) {
+				return domain.Domain{Host: host}, nil
+			},
+			want: http.StatusOK,
+		},
+		{
+			name: "bad json",
+			body: `{"host": 1}`,
+			want: http.StatusBadRequest,
+		},
+		{
+			name: "not valid host",
+			body: `{"host": "https://go.brand-a.com"}`,
+			f: func(host string) (domain.Domain, error) {
+				return domain.Domain{}, service.ErrNotValidData
+			},
+			want: http.StatusServiceUnavailable,
+		},
+		{
+			name: "not unique",
+			body: `{"host": "go.brand-a.com"}`,
+			f: func(host string) (domain.Domain, error) {
+				return domain.Domain{}, service.ErrNotUnique
+			},
+			want: http.StatusServiceUnavailable,
+		},
+		{
+			name: "storage internal",
+			body: `{"host": "go.brand-a.com"}`,
+			f: func(host string) (domain.Domain, error) {
+				return domain.Domain{}, errors.New("unknown")
+			},
+			want: http.StatusInternalServerError,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gin.SetMode(gin.TestMode)
+			rr := httptest.NewRecorder()
+			req := httptest.NewRequest(
+				http.MethodPost, "/endpoint", strings.NewReader(tt.body),
+			)
+			router := gin.Default()
+			router.POST("/endpoint", CreateDomain(&serviceMock{
+				createDomainF: tt.f,
+			}))
+			router.ServeHTTP(rr, req)
+			if rr.Result().StatusCode != tt.want {
+				t.Errorf(
+					"CreateDomain() status code get=%d, want %d",
+					rr.Result().StatusCode, tt.want,
+				)
+			}
+		})
+	}
+}
+
+func TestDeleteDomain(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want int
+	}{
+		{
+			name: "good",
+			want: http.StatusOK,
+		},
+		{
+			name: "not found",
+			err:  service.ErrNotFound,
+			want: http.StatusNotFound,
+		},
+		{
+			name: "storage internal",
+			err:  errors.New("unknown"),
+			want: http.StatusInternalServerError,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gin.SetMode(gin.TestMode)
+			rr := httptest.NewRecorder()
+			req := httptest.NewRequest(
+				http.MethodDelete, "/endpoint/go.brand-a.com", nil,
+			)
+			router := gin.Default()
+			router.DELETE("/endpoint/:host", DeleteDomain(&serviceMock{
+				deleteDomainF: func(host string) error {
+					return tt.err
+				},
+			}))
+			router.ServeHTTP(rr, req)
+			if rr.Result().StatusCode != tt.want {
+				t.Errorf(
+					"DeleteDomain() status code get=%d, want %d",
+					rr.Result().StatusCode, tt.want,
+				)
+			}
+		})
+	}
+}
@@ -1,9 +1,12 @@
 package handlers
 
 import (
+	"context"
 	"errors"
 	"net/http"
 	urlParser "net/url"
+	"shortener/internal/entities/domain"
+	"shortener/internal/entities/health"
 	"shortener/internal/entities/redirect"
 	"shortener/internal/entities/request"
 	"shortener/internal/entities/response"
@@ -18,13 +21,43 @@ import (
 
 type servicer interface {
 	// for urls
-	CreateURL(u url.URL) (string, error)
-	URL(alias string) (string, error)
+	CreateURL(ctx context.Context, u url.URL) (string, error)
+	URL(ctx context.Context, host, alias string) (url.URL, error)
+	Destination(u url.URL, v url.Visit) string
+	Blocked(dst string) bool
+	UpdateURL(ctx context.Context, host, alias string, p url.Patch) (url.URL, error)
+	URLs(ctx context.Context, f url.Filter) ([]url.Item, error)
 
 	// for redirects
 	CreateRedirect(redirects redirect.Redirect)
-	Redirects(alias string) ([]redirect.Redirect, error)
-	AgrigatedRedirects(opts redirect.AgrigateOpts) (redirect.Agrigated, error)
+	Redirects(ctx context.Context, alias string) ([]redirect.Redirect, error)
+	AgrigatedRedirects(ctx context.Context, opts redirect.AgrigateOpts) (redirect.Agrigated, error)
+	TagStats(ctx context.Context, opts redirect.TagOpts) (redirect.TagStats, error)
+
+	// for custom domains
+	CreateDomain(ctx context.Context, host string) (domain.Domain, error)
+	Domains(ctx context.Context) ([]domain.Domain, error)
+	DeleteDomain(ctx context.Context, host string) error
+
+	// for link health
+	BrokenLinks(ctx context.Context, page int) ([]health.Check, error)
+}
+
+// Timeout limits time of request handling, context of request is also
+// canceled when client disconnects. Zero disables timeout.
+func Timeout(d time.Duration) gin.HandlerFunc {
+	return func(ctx *ginext.Context) {
+		if d <= 0 {
+			ctx.Next()
+			return
+		}
+
+		c, cancel := context.WithTimeout(ctx.Request.Context(), d)
+		defer cancel()
+
+		ctx.Request = ctx.Request.WithContext(c)
+		ctx.Next()
+	}
 }
 
 func MainHandler() gin.HandlerFunc {
@@ -36,6 +69,7 @@ func MainHandler() gin.HandlerFunc {
 // NewShort creates a new URL alias.
 // @Summary Create a new short URL alias
 // @Description Creates a short alias for a given original URL.
+// @Description Destinations pointing at short links of this service are rejected unless routing.max_chain_depth allows them, loops are always rejected.
 // @Tags URLs
 // @Accept json
 // @Produce json
@@ -64,10 +98,10 @@ func NewShort(s servicer) gin.HandlerFunc {
 			return
 		}
 
-		alias, err := s.CreateURL(short)
+		alias, err := s.CreateURL(ctx.Request.Context(), short)
 		if errors.Is(err, service.ErrNotValidData) {
 			ctx.JSONP(http.StatusServiceUnavailable, response.Error(
-				"url format: http://example.com",
+				err.Error(),
 			))
 			return
 		} else if errors.Is(err, service.ErrNotUnique) {
@@ -89,20 +123,26 @@ func NewShort(s servicer) gin.HandlerFunc {
 
 // Redirect to original URL by alias.
 // @Summary Redirect by alias
-// @Description Redirects user to the original URL. On error, returns JSON.
+// @Description Redirects user to the original URL (or to geo target for visitor country).
+// @Description Alias is looked up in namespace of requested host when it is registered custom domain.
+// @Description /{alias} is served only when routing.root_aliases is enabled.
+// @Description Query string is forwarded and stored UTM parameters are attached when link is configured so. On error, returns JSON.
 // @Tags URLs
 // @Param alias path string true "Short URL alias"
+// @Description Destinations which became blocked after link creation get warning page instead of redirect.
 // @Success 307 {string} string "Temporary redirect to original URL"
+// @Failure 403 {string} string "Warning page, destination is blocked"
 // @Failure 404 {object} response.Response
 // @Failure 500 {object} response.Response
 // @Failure 503 {object} response.Response
 // @Router /s/{alias} [get]
+// @Router /{alias} [get]
 func Redirect(s servicer) gin.HandlerFunc {
 	return func(ctx *ginext.Context) {
 		const op = "internal.handlers.Redirect"
 
 		alias := ctx.Param("short_url")
-		original, err := s.URL(alias)
+		u, err := s.URL(ctx.Request.Context(), ctx.Request.Host, alias)
 		if errors.Is(err, service.ErrNotValidData) {
 			ctx.JSONP(http.StatusServiceUnavailable, response.Error(
 				"not valid data for redirecting",
@@ -121,13 +161,10 @@ func Redirect(s servicer) gin.HandlerFunc {
 			return
 		}
 
-		go s.CreateRedirect(redirect.Redirect{
-			Alias:     alias,
-			Date:      time.Now().UTC(),
-			UserAgent: ctx.Request.UserAgent(),
-		})
-
-		url, err := urlParser.Parse(original)
+		dst, err := urlParser.Parse(s.Destination(u, url.Visit{
+			IP:    ctx.ClientIP(),
+			Query: ctx.Request.URL.RawQuery,
+		}))
 		if err != nil {
 			zlog.Logger.Error().Err(err).Msg("op: " + op)
 			ctx.JSONP(http.StatusInternalServerError, response.Error(
@@ -135,11 +172,25 @@ func Redirect(s servicer) gin.HandlerFunc {
 			))
 			return
 		}
-		if url.Scheme == "" {
-			url.Scheme = "https"
+		if dst.Scheme == "" {
+			dst.Scheme = "https"
+		}
+		if s.Blocked(dst.String()) {
+			ctx.HTML(http.StatusForbidden, "blocked.html", struct{ Destination string }{
+				dst.String(),
+			})
+			return
 		}
 
-		ctx.Redirect(http.StatusTemporaryRedirect, url.String())
+		go s.CreateRedirect(redirect.Redirect{
+			Alias:     u.Alias,
+			Domain:    u.Domain,
+			Date:      time.Now().UTC(),
+			UserAgent: ctx.Request.UserAgent(),
+			UTM:       url.UTMFromQuery(dst.Query()),
+		})
+
+		ctx.Redirect(http.StatusTemporaryRedirect, dst.String())
 	}
 }
 
@@ -150,11 +201,13 @@ func Redirect(s servicer) gin.HandlerFunc {
 // @Accept json
 // @Produce json
 // @Param alias path string true "Short URL alias"
+// @Param domain query string false "Custom domain of alias, empty for default"
 // @Param start_date query string true "Start date in ISO8601 format" default(2025-01-01T00:00:00Z)
 // @Param end_date query string true "End date in ISO8601 format" default(2025-12-31T23:59:59Z)
-// @Param filter query string false "Column to filter by (e.g. user_agent)" default(user_agent)
+// @Param filter query string false "Column to filter by (user_agent, utm_source, utm_medium, utm_campaign, utm_term, utm_content)" default(user_agent)
 // @Param value query string false "Value to filter" default(Mozilla/5.0...)
 // @Param page query integer false "Page number" default(1)
+// @Param summary query boolean false "Only total and daily clicks, without redirects"
 // @Success 200 {object} redirect.Agrigated
 // @Failure 400 {object} response.Response
 // @Failure 404 {object} response.Response
@@ -172,7 +225,7 @@ func Analytics(s servicer) gin.HandlerFunc {
 		alias := ctx.Param("short_url")
 		opts.Alias = alias
 
-		redirects, err := s.AgrigatedRedirects(opts)
+		redirects, err := s.AgrigatedRedirects(ctx.Request.Context(), opts)
 		if errors.Is(err, service.ErrNotFound) {
 			ctx.HTML(http.StatusNotFound, "404.html", nil)
 			return
@@ -185,12 +238,19 @@ func Analytics(s servicer) gin.HandlerFunc {
 			return
 		}
 
+		// link metadata for page header, analytics is shown without it
+		link, err := s.URL(ctx.Request.Context(), opts.Domain, alias)
+		if err != nil && !errors.Is(err, service.ErrNotFound) {
+			zlog.Logger.Error().Err(err).Msg("op: " + op)
+		}
+
 		if opts.Page == 0 {
 			opts.Page++
 		}
 		ctx.HTML(http.StatusOK, "redirects.html", gin.H{
 			"Aggregated": redirects,
 			"Opts":       opts,
+			"Link":       link,
 		})
 	}
 }
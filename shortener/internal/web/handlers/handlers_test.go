@@ -1,47 +1,102 @@
 package handlers
 
 import (
+	"context"
 	"errors"
 	"net/http"
 	"net/http/httptest"
 	"os"
 	"path/filepath"
+	"shortener/internal/entities/domain"
+	"shortener/internal/entities/health"
 	"shortener/internal/entities/redirect"
 	"shortener/internal/entities/url"
 	"shortener/internal/service"
+	"shortener/internal/storage"
+	"shortener/internal/storage/memory"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/gin-gonic/gin"
 )
 
 type serviceMock struct {
 	createURLF func(u url.URL) (string, error)
-	getURLF    func(alias string) (string, error)
+	getURLF    func(host, alias string) (url.URL, error)
+	destF      func(u url.URL, v url.Visit) string
+	blockedF   func(dst string) bool
 
 	createRedirectF func(r redirect.Redirect)
 	getRedirectsF   func(alias string) ([]redirect.Redirect, error)
 	agrigatedF      func(opts redirect.AgrigateOpts) (redirect.Agrigated, error)
+
+	createDomainF func(host string) (domain.Domain, error)
+	domainsF      func() ([]domain.Domain, error)
+	deleteDomainF func(host string) error
+
+	brokenLinksF func(page int) ([]health.Check, error)
+	updateURLF   func(host, alias string, p url.Patch) (url.URL, error)
+	urlsF        func(f url.Filter) ([]url.Item, error)
+	tagStatsF    func(opts redirect.TagOpts) (redirect.TagStats, error)
+}
+
+func (sm *serviceMock) UpdateURL(ctx context.Context, host, alias string, p url.Patch) (url.URL, error) {
+	return sm.updateURLF(host, alias, p)
+}
+
+func (sm *serviceMock) URLs(ctx context.Context, f url.Filter) ([]url.Item, error) {
+	return sm.urlsF(f)
+}
+
+func (sm *serviceMock) TagStats(ctx context.Context, opts redirect.TagOpts) (redirect.TagStats, error) {
+	return sm.tagStatsF(opts)
 }
 
-func (sm *serviceMock) CreateURL(u url.URL) (string, error) {
+func (sm *serviceMock) CreateURL(ctx context.Context, u url.URL) (string, error) {
 	return sm.createURLF(u)
 }
-func (sm *serviceMock) URL(alias string) (string, error) {
-	return sm.getURLF(alias)
+func (sm *serviceMock) URL(ctx context.Context, host, alias string) (url.URL, error) {
+	return sm.getURLF(host, alias)
+}
+func (sm *serviceMock) Destination(u url.URL, v url.Visit) string {
+	return sm.destF(u, v)
+}
+
+func (sm *serviceMock) BrokenLinks(ctx context.Context, page int) ([]health.Check, error) {
+	return sm.brokenLinksF(page)
+}
+
+func (sm *serviceMock) Blocked(dst string) bool {
+	if sm.blockedF == nil {
+		return false
+	}
+	return sm.blockedF(dst)
 }
 
 func (sm *serviceMock) CreateRedirect(r redirect.Redirect) {
 	sm.createRedirectF(r)
 }
-func (sm *serviceMock) Redirects(alias string) ([]redirect.Redirect, error) {
+func (sm *serviceMock) Redirects(ctx context.Context, alias string) ([]redirect.Redirect, error) {
 	return sm.getRedirectsF(alias)
 }
 
-func (sm *serviceMock) AgrigatedRedirects(opts redirect.AgrigateOpts) (redirect.Agrigated, error) {
+func (sm *serviceMock) AgrigatedRedirects(ctx context.Context, opts redirect.AgrigateOpts) (redirect.Agrigated, error) {
 	return sm.agrigatedF(opts)
 }
 
+func (sm *serviceMock) CreateDomain(ctx context.Context, host string) (domain.Domain, error) {
+	return sm.createDomainF(host)
+}
+
+func (sm *serviceMock) Domains(ctx context.Context) ([]domain.Domain, error) {
+	return sm.domainsF()
+}
+
+func (sm *serviceMock) DeleteDomain(ctx context.Context, host string) error {
+	return sm.deleteDomainF(host)
+}
+
 func TestNewShort(t *testing.T) {
 	type args struct {
 		servicer servicer
@@ -145,6 +200,26 @@ func TestNewShort(t *testing.T) {
 	}
 }
 
+func TestNewShortErrorMessage(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	rr := httptest.NewRecorder()
+	req := httptest.NewRequest(
+		http.MethodPost, "/endpoint",
+		strings.NewReader(`{"alias": "abc", "original": "http://localhost/s/abc"}`),
+	)
+	router := gin.Default()
+	router.POST("/endpoint", NewShort(&serviceMock{
+		createURLF: func(u url.URL) (string, error) {
+			return "", service.ErrRedirectLoop
+		},
+	}))
+	router.ServeHTTP(rr, req)
+
+	if !strings.Contains(rr.Body.String(), service.ErrRedirectLoop.Error()) {
+		t.Errorf("NewShort() body = %s, want reason of rejection", rr.Body.String())
+	}
+}
+
 func TestRedirect(t *testing.T) {
 	type args struct {
 		servicer servicer
@@ -160,8 +235,11 @@ func TestRedirect(t *testing.T) {
 			alias: "alias",
 			args: args{
 				servicer: &serviceMock{
-					getURLF: func(alias string) (string, error) {
-						return "original", nil
+					getURLF: func(host, alias string) (url.URL, error) {
+						return url.URL{Original: "original"}, nil
+					},
+					destF: func(u url.URL, v url.Visit) string {
+						return u.Original
 					},
 					createRedirectF: func(r redirect.Redirect) {
 					},
@@ -169,13 +247,37 @@ func TestRedirect(t *testing.T) {
 			},
 			want: http.StatusTemporaryRedirect,
 		},
+		{
+			name:  "blocked destination",
+			alias: "alias",
+			args: args{
+				servicer: &serviceMock{
+					getURLF: func(host, alias string) (url.URL, error) {
+						return url.URL{Original: "https://evil.example"}, nil
+					},
+					destF: func(u url.URL, v url.Visit) string {
+						return u.Original
+					},
+					blockedF: func(dst string) bool {
+						return true
+					},
+					createRedirectF: func(r redirect.Redirect) {
+						t.Errorf("redirect to blocked destination is recorded")
+					},
+				},
+			},
+			want: http.StatusForbidden,
+		},
 		{
 			name:  "not valid data",
 			alias: "jhjkhjjkhjkhkj",
 			args: args{
 				servicer: &serviceMock{
-					getURLF: func(alias string) (string, error) {
-						return "original", service.ErrNotValidData
+					getURLF: func(host, alias string) (url.URL, error) {
+						return url.URL{Original: "original"}, service.ErrNotValidData
+					},
+					destF: func(u url.URL, v url.Visit) string {
+						return u.Original
 					},
 					createRedirectF: func(r redirect.Redirect) {
 					},
@@ -188,8 +290,11 @@ func TestRedirect(t *testing.T) {
 			alias: "jhjkhjjkhjkhkj",
 			args: args{
 				servicer: &serviceMock{
-					getURLF: func(alias string) (string, error) {
-						return "original", service.ErrNotFound
+					getURLF: func(host, alias string) (url.URL, error) {
+						return url.URL{Original: "original"}, service.ErrNotFound
+					},
+					destF: func(u url.URL, v url.Visit) string {
+						return u.Original
 					},
 					createRedirectF: func(r redirect.Redirect) {
 					},
@@ -202,8 +307,11 @@ func TestRedirect(t *testing.T) {
 			alias: "jhjkhjjkhjkhkj",
 			args: args{
 				servicer: &serviceMock{
-					getURLF: func(alias string) (string, error) {
-						return "original", errors.New("unknown")
+					getURLF: func(host, alias string) (url.URL, error) {
+						return url.URL{Original: "original"}, errors.New("unknown")
+					},
+					destF: func(u url.URL, v url.Visit) string {
+						return u.Original
 					},
 					createRedirectF: func(r redirect.Redirect) {
 					},
@@ -216,8 +324,11 @@ func TestRedirect(t *testing.T) {
 			alias: "jhjkhjjkhjkhkj",
 			args: args{
 				servicer: &serviceMock{
-					getURLF: func(alias string) (string, error) {
-						return "9*@&(&$%())", nil
+					getURLF: func(host, alias string) (url.URL, error) {
+						return url.URL{Original: "9*@&(&$%())"}, nil
+					},
+					destF: func(u url.URL, v url.Visit) string {
+						return u.Original
 					},
 					createRedirectF: func(r redirect.Redirect) {
 					},
@@ -235,6 +346,7 @@ func TestRedirect(t *testing.T) {
 			)
 			router := gin.Default()
 			router.GET("/endpoint/:short_url", Redirect(tt.args.servicer))
+			router.LoadHTMLFiles(blockedTemplate(t))
 			router.ServeHTTP(rr, req)
 			if rr.Result().StatusCode != tt.want {
 				t.Errorf(
@@ -262,6 +374,9 @@ func TestAnalytics(t *testing.T) {
 			alias: "Test",
 			args: args{
 				servicer: &serviceMock{
+					getURLF: func(host, alias string) (url.URL, error) {
+						return url.URL{Alias: alias, Title: "title"}, nil
+					},
 					agrigatedF: func(opts redirect.AgrigateOpts) (redirect.Agrigated, error) {
 						return redirect.Agrigated{}, nil
 					},
@@ -274,6 +389,9 @@ func TestAnalytics(t *testing.T) {
 			alias: "Test",
 			args: args{
 				servicer: &serviceMock{
+					getURLF: func(host, alias string) (url.URL, error) {
+						return url.URL{Alias: alias, Title: "title"}, nil
+					},
 					agrigatedF: func(opts redirect.AgrigateOpts) (redirect.Agrigated, error) {
 						return redirect.Agrigated{}, nil
 					},
@@ -287,6 +405,9 @@ func TestAnalytics(t *testing.T) {
 			alias: "notfound",
 			args: args{
 				servicer: &serviceMock{
+					getURLF: func(host, alias string) (url.URL, error) {
+						return url.URL{Alias: alias, Title: "title"}, nil
+					},
 					agrigatedF: func(opts redirect.AgrigateOpts) (redirect.Agrigated, error) {
 						return redirect.Agrigated{}, service.ErrNotFound
 					},
@@ -300,6 +421,9 @@ func TestAnalytics(t *testing.T) {
 			alias: "notfound",
 			args: args{
 				servicer: &serviceMock{
+					getURLF: func(host, alias string) (url.URL, error) {
+						return url.URL{Alias: alias, Title: "title"}, nil
+					},
 					agrigatedF: func(opts redirect.AgrigateOpts) (redirect.Agrigated, error) {
 						return redirect.Agrigated{}, service.ErrNotValidData
 					},
@@ -313,6 +437,9 @@ func TestAnalytics(t *testing.T) {
 			alias: "notfound",
 			args: args{
 				servicer: &serviceMock{
+					getURLF: func(host, alias string) (url.URL, error) {
+						return url.URL{Alias: alias, Title: "title"}, nil
+					},
 					agrigatedF: func(opts redirect.AgrigateOpts) (redirect.Agrigated, error) {
 						return redirect.Agrigated{}, errors.New("unknown")
 					},
@@ -354,3 +481,97 @@ func TestAnalytics(t *testing.T) {
 		})
 	}
 }
+
+func blockedTemplate(t *testing.T) string {
+	path := filepath.Join(t.TempDir(), "blocked.html")
+	err := os.WriteFile(path, []byte(`<html>{{ .Destination }}</html>`), 0644)
+	if err != nil {
+		t.Fatalf("can't create template: %v", err)
+	}
+	return path
+}
+
+func TestTimeout(t *testing.T) {
+	tests := []struct {
+		name         string
+		timeout      time.Duration
+		wantDeadline bool
+	}{
+		{name: "deadline is set", timeout: time.Second, wantDeadline: true},
+		{name: "zero disables timeout", timeout: 0, wantDeadline: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var hasDeadline bool
+			router := gin.Default()
+			router.Use(Timeout(tt.timeout))
+			router.GET("/", func(ctx *gin.Context) {
+				_, hasDeadline = ctx.Request.Context().Deadline()
+			})
+
+			router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+			if hasDeadline != tt.wantDeadline {
+				t.Errorf("deadline = %v, want %v", hasDeadline, tt.wantDeadline)
+			}
+		})
+	}
+}
+
+// syncRedirects signals when click of redirect is passed to service.
+type syncRedirects struct {
+	*service.Service
+	done chan struct{}
+}
+
+func (s syncRedirects) CreateRedirect(r redirect.Redirect) {
+	s.Service.CreateRedirect(r)
+	close(s.done)
+}
+
+func TestRedirectCaseFolding(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	policy, err := service.NewAliasPolicy(0, 0, "", nil, "", service.CaseLower)
+	if err != nil {
+		t.Fatal(err)
+	}
+	str := storage.New(memory.New(), memory.NewCache())
+	srv := service.New(str, str, service.WithAliasPolicy(policy))
+	_, err = srv.CreateURL(t.Context(), url.URL{Alias: "abc", Original: "https://example.com"})
+	if err != nil {
+		t.Fatalf("CreateURL() = %v", err)
+	}
+	s := syncRedirects{Service: srv, done: make(chan struct{})}
+
+	tmpl := filepath.Join(t.TempDir(), "redirects.html")
+	err = os.WriteFile(tmpl, []byte(`{{ .Aggregated.Total }}`), 0644)
+	if err != nil {
+		t.Fatalf("can't create template: %v", err)
+	}
+	router := gin.New()
+	router.GET("/s/:short_url", Redirect(s))
+	router.GET("/analytics/:short_url", Analytics(s))
+	router.LoadHTMLFiles(tmpl, blockedTemplate(t))
+
+	rr := httptest.NewRecorder()
+	router.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/s/ABC", nil))
+	if rr.Code != http.StatusTemporaryRedirect {
+		t.Fatalf("Redirect() status code = %d", rr.Code)
+	}
+	select {
+	case <-s.done:
+	case <-time.After(time.Second):
+		t.Fatal("click isn't saved")
+	}
+	// batch of clicks is written on shutdown
+	srv.Shutdown()
+
+	// click is saved under alias of link and found by any spelling
+	for _, alias := range []string{"abc", "ABC"} {
+		rr = httptest.NewRecorder()
+		router.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/analytics/"+alias, nil))
+		if rr.Code != http.StatusOK || rr.Body.String() != "1" {
+			t.Errorf("Analytics(%s) = %d %q, want one click", alias, rr.Code, rr.Body.String())
+		}
+	}
+}
@@ -0,0 +1,223 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"shortener/internal/entities/redirect"
+	"shortener/internal/entities/request"
+	"shortener/internal/entities/response"
+	"shortener/internal/entities/url"
+	"shortener/internal/service"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/wb-go/wbf/ginext"
+	"github.com/wb-go/wbf/zlog"
+)
+
+// BrokenLinks returns links which destinations failed health check.
+// @Summary List links with broken destinations
+// @Description Destinations are checked periodically in background, link is broken when it responded with 4xx/5xx or wasn't reachable on last check.
+// @Tags Links
+// @Produce json
+// @Security AdminToken
+// @Param page query integer false "Page number" default(1)
+// @Success 200 {object} response.Response{result=[]health.Check}
+// @Failure 400 {object} response.Response
+// @Failure 403 {object} response.Response
+// @Failure 404 {object} response.Response
+// @Failure 500 {object} response.Response
+// @Router /api/v1/links/broken [get]
+func BrokenLinks(s servicer) gin.HandlerFunc {
+	return func(ctx *ginext.Context) {
+		const op = "internal.handlers.BrokenLinks"
+
+		page, err := strconv.Atoi(ctx.DefaultQuery("page", "1"))
+		if err != nil {
+			ctx.JSONP(http.StatusBadRequest, response.Error(
+				"page must be integer",
+			))
+			return
+		}
+
+		res, err := s.BrokenLinks(ctx.Request.Context(), page)
+		if errors.Is(err, service.ErrNotValidData) {
+			ctx.JSONP(http.StatusBadRequest, response.Error(
+				err.Error(),
+			))
+			return
+		} else if errors.Is(err, service.ErrDisabled) {
+			ctx.JSONP(http.StatusNotFound, response.Error(
+				"health checks are disabled",
+			))
+			return
+		} else if err != nil {
+			zlog.Logger.Error().Err(err).Msg("op: " + op)
+			ctx.JSONP(http.StatusInternalServerError, response.Error(
+				"internal server error on our service",
+			))
+			return
+		}
+
+		ctx.JSONP(http.StatusOK, response.OK(res))
+	}
+}
+
+// Links returns page of links with their click counts.
+// @Summary List and search links
+// @Description Links are ordered from newest by default, filters are applied when they are set.
+// @Tags Links
+// @Produce json
+// @Security AdminToken
+// @Param q query string false "Substring of alias or original"
+// @Param owner query string false "Owner"
+// @Param tag query string false "Tag"
+// @Param folder query string false "Folder"
+// @Param status query string false "Destination health: ok, broken or unchecked"
+// @Param sort query string false "created or clicks" default(created)
+// @Param asc query boolean false "Ascending order"
+// @Param page query integer false "Page number" default(1)
+// @Success 200 {object} response.Response{result=[]url.Item}
+// @Failure 400 {object} response.Response
+// @Failure 403 {object} response.Response
+// @Failure 500 {object} response.Response
+// @Router /api/v1/links [get]
+func Links(s servicer) gin.HandlerFunc {
+	return func(ctx *ginext.Context) {
+		const op = "internal.handlers.Links"
+
+		var f url.Filter
+		if err := ctx.ShouldBindQuery(&f); err != nil {
+			ctx.JSONP(http.StatusBadRequest, response.Error(
+				"wrong query values (type)",
+			))
+			return
+		}
+
+		res, err := s.URLs(ctx.Request.Context(), f)
+		if errors.Is(err, service.ErrNotValidData) {
+			ctx.JSONP(http.StatusBadRequest, response.Error(
+				err.Error(),
+			))
+			return
+		} else if err != nil {
+			zlog.Logger.Error().Err(err).Msg("op: " + op)
+			ctx.JSONP(http.StatusInternalServerError, response.Error(
+				"internal server error on our service",
+			))
+			return
+		}
+
+		ctx.JSONP(http.StatusOK, response.OK(res))
+	}
+}
+
+// UpdateLink changes tags, folder, title and description of link.
+// @Summary Update link
+// @Description Omitted fields are left as is, tags replace all tags of link.
+// @Tags Links
+// @Accept json
+// @Produce json
+// @Security AdminToken
+// @Param alias path string true "Short URL alias"
+// @Param domain query string false "Custom domain of alias, empty for default"
+// @Param request body request.UpdateLink true "Changes"
+// @Success 200 {object} response.Response{result=url.URL}
+// @Failure 400 {object} response.Response
+// @Failure 403 {object} response.Response
+// @Failure 404 {object} response.Response
+// @Failure 500 {object} response.Response
+// @Failure 503 {object} response.Response
+// @Router /api/v1/links/{alias} [patch]
+func UpdateLink(s servicer) gin.HandlerFunc {
+	return func(ctx *ginext.Context) {
+		const op = "internal.handlers.UpdateLink"
+
+		var ul request.UpdateLink
+		if err := ctx.BindJSON(&ul); err != nil {
+			ctx.JSONP(http.StatusBadRequest, response.Error(
+				"wrong json values (type)",
+			))
+			return
+		}
+		patch, msg := ul.Validate()
+		if msg != "" {
+			ctx.JSONP(http.StatusBadRequest, response.Error(
+				msg,
+			))
+			return
+		}
+
+		u, err := s.UpdateURL(ctx.Request.Context(), ctx.Query("domain"), ctx.Param("alias"), patch)
+		if errors.Is(err, service.ErrNotValidData) {
+			ctx.JSONP(http.StatusServiceUnavailable, response.Error(
+				err.Error(),
+			))
+			return
+		} else if errors.Is(err, service.ErrNotFound) {
+			ctx.JSONP(http.StatusNotFound, response.Error(
+				"not found link",
+			))
+			return
+		} else if err != nil {
+			zlog.Logger.Error().Err(err).Msg("op: " + op)
+			ctx.JSONP(http.StatusInternalServerError, response.Error(
+				"internal server error on our service",
+			))
+			return
+		}
+
+		ctx.JSONP(http.StatusOK, response.OK(u))
+	}
+}
+
+// TagAnalytics returns clicks of all links with tag.
+// @Summary Get tag analytics
+// @Description Total clicks of links with tag, e.g. of campaign, and clicks of every such link.
+// @Tags Analytics
+// @Produce json
+// @Security AdminToken
+// @Param tag path string true "Tag"
+// @Param start_date query string false "Start date, format 2006-01-02 15:04:05"
+// @Param end_date query string false "End date, format 2006-01-02 15:04:05"
+// @Success 200 {object} response.Response{result=redirect.TagStats}
+// @Failure 400 {object} response.Response
+// @Failure 403 {object} response.Response
+// @Failure 404 {object} response.Response
+// @Failure 500 {object} response.Response
+// @Router /api/v1/tags/{tag}/analytics [get]
+func TagAnalytics(s servicer) gin.HandlerFunc {
+	return func(ctx *ginext.Context) {
+		const op = "internal.handlers.TagAnalytics"
+
+		var opts redirect.TagOpts
+		if err := ctx.ShouldBindQuery(&opts); err != nil {
+			ctx.JSONP(http.StatusBadRequest, response.Error(
+				"wrong query values (type)",
+			))
+			return
+		}
+		opts.Tag = ctx.Param("tag")
+
+		res, err := s.TagStats(ctx.Request.Context(), opts)
+		if errors.Is(err, service.ErrNotValidData) {
+			ctx.JSONP(http.StatusBadRequest, response.Error(
+				err.Error(),
+			))
+			return
+		} else if errors.Is(err, service.ErrNotFound) {
+			ctx.JSONP(http.StatusNotFound, response.Error(
+				"no links with tag",
+			))
+			return
+		} else if err != nil {
+			zlog.Logger.Error().Err(err).Msg("op: " + op)
+			ctx.JSONP(http.StatusInternalServerError, response.Error(
+				"internal server error on our service",
+			))
+			return
+		}
+
+		ctx.JSONP(http.StatusOK, response.OK(res))
+	}
+}
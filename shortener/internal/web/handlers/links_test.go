@@ -0,0 +1,227 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"shortener/internal/entities/health"
+	"shortener/internal/entities/redirect"
+	"shortener/internal/entities/url"
+	"shortener/internal/service"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestBrokenLinks(t *testing.T) {
+	tests := []struct {
+		name  string
+		query string
+		err   error
+		want  int
+	}{
+		{
+			name:  "good",
+			query: "?page=2",
+			want:  http.StatusOK,
+		},
+		{
+			name:  "bad page",
+			query: "?page=two",
+			want:  http.StatusBadRequest,
+		},
+		{
+			name: "disabled",
+			err:  service.ErrDisabled,
+			want: http.StatusNotFound,
+		},
+		{
+			name: "internal",
+			err:  errors.New("unknown"),
+			want: http.StatusInternalServerError,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gin.SetMode(gin.TestMode)
+			rr := httptest.NewRecorder()
+			req := httptest.NewRequest(http.MethodGet, "/endpoint"+tt.query, nil)
+			router := gin.Default()
+			router.GET("/endpoint", BrokenLinks(&serviceMock{
+				brokenLinksF: func(page int) ([]health.Check, error) {
+					return []health.Check{{Alias: "abc", Status: 404}}, tt.err
+				},
+			}))
+			router.ServeHTTP(rr, req)
+			if rr.Result().StatusCode != tt.want {
+				t.Errorf(
+					"BrokenLinks() status code get=%d, want %d",
+					rr.Result().StatusCode, tt.want,
+				)
+			}
+		})
+	}
+}
+
+func TestUpdateLink(t *testing.T) {
+	tests := []struct {
+		name string
+		body string
+		err  error
+		want int
+	}{
+		{
+			name: "good",
+			body: `{"tags": ["a", "b"], "folder": "x"}`,
+			want: http.StatusOK,
+		},
+		{
+			name: "nothing to update",
+			body: `{}`,
+			want: http.StatusBadRequest,
+		},
+		{
+			name: "bad json",
+			body: `{"tags": "a"}`,
+			want: http.StatusBadRequest,
+		},
+		{
+			name: "not valid",
+			body: `{"tags": ["a b"]}`,
+			err:  service.ErrNotValidData,
+			want: http.StatusServiceUnavailable,
+		},
+		{
+			name: "not found",
+			body: `{"folder": ""}`,
+			err:  service.ErrNotFound,
+			want: http.StatusNotFound,
+		},
+		{
+			name: "internal",
+			body: `{"folder": ""}`,
+			err:  errors.New("unknown"),
+			want: http.StatusInternalServerError,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gin.SetMode(gin.TestMode)
+			rr := httptest.NewRecorder()
+			req := httptest.NewRequest(
+				http.MethodPatch, "/endpoint/abc?domain=go.example.com", strings.NewReader(tt.body),
+			)
+			router := gin.Default()
+			router.PATCH("/endpoint/:alias", UpdateLink(&serviceMock{
+				updateURLF: func(host, alias string, p url.Patch) (url.URL, error) {
+					if host != "go.example.com" || alias != "abc" {
+						t.Errorf("UpdateLink() got host=%s alias=%s", host, alias)
+					}
+					return url.URL{Alias: alias}, tt.err
+				},
+			}))
+			router.ServeHTTP(rr, req)
+			if rr.Result().StatusCode != tt.want {
+				t.Errorf(
+					"UpdateLink() status code get=%d, want %d",
+					rr.Result().StatusCode, tt.want,
+				)
+			}
+		})
+	}
+}
+
+func TestTagAnalytics(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want int
+	}{
+		{"good", nil, http.StatusOK},
+		{"not valid", service.ErrNotValidData, http.StatusBadRequest},
+		{"not found", service.ErrNotFound, http.StatusNotFound},
+		{"internal", errors.New("unknown"), http.StatusInternalServerError},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gin.SetMode(gin.TestMode)
+			rr := httptest.NewRecorder()
+			req := httptest.NewRequest(
+				http.MethodGet, "/endpoint/spring/analytics?start_date=2026-01-01+00:00:00", nil,
+			)
+			router := gin.Default()
+			router.GET("/endpoint/:tag/analytics", TagAnalytics(&serviceMock{
+				tagStatsF: func(opts redirect.TagOpts) (redirect.TagStats, error) {
+					if opts.Tag != "spring" || opts.StartDate != "2026-01-01 00:00:00" {
+						t.Errorf("TagAnalytics() got opts %+v", opts)
+					}
+					return redirect.TagStats{Tag: opts.Tag}, tt.err
+				},
+			}))
+			router.ServeHTTP(rr, req)
+			if rr.Result().StatusCode != tt.want {
+				t.Errorf(
+					"TagAnalytics() status code get=%d, want %d",
+					rr.Result().StatusCode, tt.want,
+				)
+			}
+		})
+	}
+}
+
+func TestLinks(t *testing.T) {
+	tests := []struct {
+		name  string
+		query string
+		err   error
+		want  int
+	}{
+		{
+			name:  "good",
+			query: "?q=sale&owner=team&tag=ads&status=broken&sort=clicks&asc=true&page=2",
+			want:  http.StatusOK,
+		},
+		{
+			name:  "bad page",
+			query: "?page=two",
+			want:  http.StatusBadRequest,
+		},
+		{
+			name:  "not valid",
+			query: "?sort=name",
+			err:   service.ErrNotValidData,
+			want:  http.StatusBadRequest,
+		},
+		{
+			name: "internal",
+			err:  errors.New("unknown"),
+			want: http.StatusInternalServerError,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gin.SetMode(gin.TestMode)
+			rr := httptest.NewRecorder()
+			req := httptest.NewRequest(http.MethodGet, "/endpoint"+tt.query, nil)
+			router := gin.Default()
+			router.GET("/endpoint", Links(&serviceMock{
+				urlsF: func(f url.Filter) ([]url.Item, error) {
+					if tt.name == "good" && (f.Query != "sale" || f.Owner != "team" ||
+						f.Tag != "ads" || f.Status != url.StatusBroken ||
+						f.Sort != url.SortClicks || !f.Asc || f.Page != 2) {
+						t.Errorf("Links() got filter %+v", f)
+					}
+					return []url.Item{{Clicks: 3}}, tt.err
+				},
+			}))
+			router.ServeHTTP(rr, req)
+			if rr.Result().StatusCode != tt.want {
+				t.Errorf(
+					"Links() status code get=%d, want %d",
+					rr.Result().StatusCode, tt.want,
+				)
+			}
+		})
+	}
+}
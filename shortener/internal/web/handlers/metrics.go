@@ -0,0 +1,24 @@
+package handlers
+
+import (
+	"expvar"
+
+	"github.com/gin-gonic/gin"
+	"github.com/wb-go/wbf/ginext"
+)
+
+// Metrics returns variables published with expvar.
+// @Summary Service metrics
+// @Description Variables published with expvar: url_cache has hits, misses, negative hits and coalesced misses of link lookups with their ratios, memstats and cmdline are runtime ones.
+// @Tags Metrics
+// @Produce json
+// @Security AdminToken
+// @Success 200 {object} map[string]any
+// @Failure 403 {object} response.Response
+// @Router /api/v1/metrics [get]
+func Metrics() gin.HandlerFunc {
+	h := expvar.Handler()
+	return func(ctx *ginext.Context) {
+		h.ServeHTTP(ctx.Writer, ctx.Request)
+	}
+}
@@ -3,17 +3,43 @@ package web
 import (
 	"shortener/internal/service"
 	"shortener/internal/web/handlers"
+	"time"
 
 	f "github.com/swaggo/files"
 	ginSwagger "github.com/swaggo/gin-swagger"
 	"github.com/wb-go/wbf/ginext"
 )
 
-func SetRoutes(r *ginext.Engine, s *service.Service) {
+type Options struct {
+	// AdminToken bearer token of admin API, empty disables it
+	AdminToken string
+	// RootAliases serves links at /:alias in addition to /s/:alias
+	RootAliases bool
+	// RequestTimeout deadline of request handling, it should be less than
+	// write timeout of server, zero disables it
+	RequestTimeout time.Duration
+}
+
+// SetRoutes registers routes of service. First segments of all routes must
+// be listed in service.ReservedAliases, otherwise links with such aliases
+// would be shadowed by route in root aliases mode.
+func SetRoutes(r *ginext.Engine, s *service.Service, opts Options) {
+	r.Use(handlers.Timeout(opts.RequestTimeout))
+
 	r.GET("/s/:short_url", handlers.Redirect(s))
 	r.POST("/shorten", handlers.NewShort(s))
 	r.GET("/analytics/:short_url", handlers.Analytics(s))
 
+	admin := r.Group("/api/v1", handlers.AdminAuth(opts.AdminToken))
+	admin.GET("/domains", handlers.Domains(s))
+	admin.POST("/domains", handlers.CreateDomain(s))
+	admin.DELETE("/domains/:host", handlers.DeleteDomain(s))
+	admin.GET("/links", handlers.Links(s))
+	admin.GET("/links/broken", handlers.BrokenLinks(s))
+	admin.PATCH("/links/:alias", handlers.UpdateLink(s))
+	admin.GET("/tags/:tag/analytics", handlers.TagAnalytics(s))
+	admin.GET("/metrics", handlers.Metrics())
+
 	r.Static("/static", "./templates/static")
 
 	r.GET(
@@ -22,4 +48,7 @@ func SetRoutes(r *ginext.Engine, s *service.Service) {
 
 	r.GET("/", handlers.MainHandler())
 
+	if opts.RootAliases {
+		r.GET("/:short_url", handlers.Redirect(s))
+	}
 }
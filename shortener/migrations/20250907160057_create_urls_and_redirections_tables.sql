@@ -3,13 +3,15 @@
 SELECT 'up SQL query';
 -- +goose StatementEnd
 
-create table urls(
+-- tables may exist already in databases created by docker/init/init.sql
+-- before migrations were applied on start, they are adopted as is
+create table if not exists urls(
     id serial primary key,
     alias text not null unique,
     original text not null
 );
 
-create table redirects(
+create table if not exists redirects(
     id serial primary key,
     alias text not null,
     dt timestamp not null,
@@ -0,0 +1,9 @@
+-- +goose Up
+-- +goose StatementBegin
+alter table urls add column geo_targets jsonb not null default '{}'::jsonb;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+alter table urls drop column geo_targets;
+-- +goose StatementEnd
@@ -0,0 +1,35 @@
+-- +goose Up
+-- +goose StatementBegin
+alter table urls
+    add column forward_query boolean not null default false,
+    add column utm_source text not null default '',
+    add column utm_medium text not null default '',
+    add column utm_campaign text not null default '',
+    add column utm_term text not null default '',
+    add column utm_content text not null default '';
+
+alter table redirects
+    add column utm_source text not null default '',
+    add column utm_medium text not null default '',
+    add column utm_campaign text not null default '',
+    add column utm_term text not null default '',
+    add column utm_content text not null default '';
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+alter table redirects
+    drop column utm_source,
+    drop column utm_medium,
+    drop column utm_campaign,
+    drop column utm_term,
+    drop column utm_content;
+
+alter table urls
+    drop column forward_query,
+    drop column utm_source,
+    drop column utm_medium,
+    drop column utm_campaign,
+    drop column utm_term,
+    drop column utm_content;
+-- +goose StatementEnd
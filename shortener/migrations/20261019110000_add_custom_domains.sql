@@ -0,0 +1,25 @@
+-- +goose Up
+-- +goose StatementBegin
+create table domains(
+    id serial primary key,
+    host text not null unique,
+    created_at timestamp not null default now()
+);
+
+alter table urls add column domain text not null default '';
+alter table urls drop constraint urls_alias_key;
+alter table urls add constraint urls_domain_alias_key unique (domain, alias);
+
+alter table redirects add column domain text not null default '';
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+alter table redirects drop column domain;
+
+alter table urls drop constraint urls_domain_alias_key;
+alter table urls drop column domain;
+alter table urls add constraint urls_alias_key unique (alias);
+
+drop table domains;
+-- +goose StatementEnd
@@ -0,0 +1,9 @@
+-- +goose Up
+-- +goose StatementBegin
+create sequence alias_seq as bigint start with 1;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+drop sequence alias_seq;
+-- +goose StatementEnd
@@ -0,0 +1,25 @@
+-- +goose Up
+-- +goose StatementBegin
+alter table urls add column folder text not null default '';
+create index urls_folder_idx on urls (folder);
+
+create table tags(
+    id serial primary key,
+    name text not null unique
+);
+
+create table url_tags(
+    url_id integer not null references urls(id) on delete cascade,
+    tag_id integer not null references tags(id) on delete cascade,
+    primary key (url_id, tag_id)
+);
+create index url_tags_tag_id_idx on url_tags (tag_id);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+drop table url_tags;
+drop table tags;
+drop index urls_folder_idx;
+alter table urls drop column folder;
+-- +goose StatementEnd
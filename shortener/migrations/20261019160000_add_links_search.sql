@@ -0,0 +1,16 @@
+-- +goose Up
+-- +goose StatementBegin
+create extension if not exists pg_trgm;
+create index urls_alias_trgm_idx on urls using gin (alias gin_trgm_ops);
+create index urls_original_trgm_idx on urls using gin (original gin_trgm_ops);
+create index urls_owner_idx on urls (owner);
+create index redirects_domain_alias_idx on redirects (domain, alias);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+drop index redirects_domain_alias_idx;
+drop index urls_owner_idx;
+drop index urls_original_trgm_idx;
+drop index urls_alias_trgm_idx;
+-- +goose StatementEnd
@@ -0,0 +1,22 @@
+-- +goose Up
+-- +goose StatementBegin
+-- creation time of existing links is unknown, time of migration is used
+alter table urls
+    add column title text not null default '',
+    add column description text not null default '',
+    add column page_title text not null default '',
+    add column created_at timestamp not null default now(),
+    add column updated_at timestamp not null default now();
+create index urls_created_at_idx on urls (created_at);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+drop index urls_created_at_idx;
+alter table urls
+    drop column updated_at,
+    drop column created_at,
+    drop column page_title,
+    drop column description,
+    drop column title;
+-- +goose StatementEnd
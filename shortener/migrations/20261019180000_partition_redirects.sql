@@ -0,0 +1,103 @@
+-- +goose Up
+-- +goose StatementBegin
+alter table redirects rename to redirects_old;
+alter table redirects_old rename constraint redirects_pkey to redirects_old_pkey;
+alter sequence redirects_id_seq owned by none;
+drop index redirects_domain_alias_idx;
+
+-- primary key of partitioned table must include partition key
+create table redirects(
+    id integer not null default nextval('redirects_id_seq'),
+    alias text not null,
+    dt timestamp not null,
+    user_agent text not null,
+    utm_source text not null default '',
+    utm_medium text not null default '',
+    utm_campaign text not null default '',
+    utm_term text not null default '',
+    utm_content text not null default '',
+    domain text not null default '',
+    primary key (id, dt)
+) partition by range (dt);
+create index redirects_domain_alias_dt_idx on redirects (domain, alias, dt);
+create index redirects_alias_dt_idx on redirects (alias, dt);
+
+-- default partition keeps redirects of months without partition, they are
+-- moved out of it when partition of their month is created
+create table redirects_default partition of redirects default;
+
+create function create_redirects_partition(month date) returns void as $$
+declare
+    from_dt timestamp := date_trunc('month', month);
+    to_dt timestamp := date_trunc('month', month) + interval '1 month';
+    part text := 'redirects_p' || to_char(month, 'YYYYMM');
+begin
+    perform pg_advisory_xact_lock(hashtext('create_redirects_partition'));
+    if to_regclass(part) is not null then
+        return;
+    end if;
+
+    execute format('create table %I (like redirects including defaults)', part);
+    execute format(
+        'with moved as (
+            delete from redirects_default where dt >= $1 and dt < $2 returning *
+        ) insert into %I select * from moved',
+        part
+    ) using from_dt, to_dt;
+    execute format(
+        'alter table redirects attach partition %I for values from (%L) to (%L)',
+        part, from_dt, to_dt
+    );
+end;
+$$ language plpgsql;
+
+select create_redirects_partition(m::date) from generate_series(
+    date_trunc('month', least((select min(dt) from redirects_old), now()::timestamp)),
+    date_trunc('month', now()) + interval '2 months',
+    interval '1 month'
+) m;
+
+insert into redirects (
+    id, alias, dt, user_agent,
+    utm_source, utm_medium, utm_campaign, utm_term, utm_content, domain
+) select
+    id, alias, dt, user_agent,
+    utm_source, utm_medium, utm_campaign, utm_term, utm_content, domain
+from redirects_old;
+
+drop table redirects_old;
+alter sequence redirects_id_seq owned by redirects.id;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+alter sequence redirects_id_seq owned by none;
+
+create table redirects_plain(
+    id integer not null default nextval('redirects_id_seq') primary key,
+    alias text not null,
+    dt timestamp not null,
+    user_agent text not null,
+    utm_source text not null default '',
+    utm_medium text not null default '',
+    utm_campaign text not null default '',
+    utm_term text not null default '',
+    utm_content text not null default '',
+    domain text not null default ''
+);
+insert into redirects_plain (
+    id, alias, dt, user_agent,
+    utm_source, utm_medium, utm_campaign, utm_term, utm_content, domain
+) select
+    id, alias, dt, user_agent,
+    utm_source, utm_medium, utm_campaign, utm_term, utm_content, domain
+from redirects;
+
+drop table redirects;
+drop function create_redirects_partition(date);
+
+alter table redirects_plain rename to redirects;
+alter table redirects rename constraint redirects_plain_pkey to redirects_pkey;
+alter sequence redirects_id_seq owned by redirects.id;
+create index redirects_domain_alias_idx on redirects (domain, alias);
+-- +goose StatementEnd
@@ -0,0 +1,31 @@
+-- +goose Up
+-- +goose StatementBegin
+create table redirects_daily(
+    domain text not null,
+    alias text not null,
+    day date not null,
+    clicks bigint not null,
+    uniques bigint not null,
+    top_user_agent text not null,
+    top_utm_source text not null,
+    primary key (domain, alias, day)
+);
+
+-- redirects before until are rolled up, later ones are read from redirects,
+-- null until means nothing is rolled up yet
+create table redirects_rollup_state(
+    id boolean primary key default true check (id),
+    until timestamp
+);
+insert into redirects_rollup_state (until) values (null);
+
+-- rollup job reads all redirects of day
+create index redirects_dt_idx on redirects (dt);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+drop index redirects_dt_idx;
+drop table redirects_rollup_state;
+drop table redirects_daily;
+-- +goose StatementEnd
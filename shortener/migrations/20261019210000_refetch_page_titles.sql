@@ -0,0 +1,9 @@
+-- +goose Up
+-- +goose StatementBegin
+-- titles were fetched without checking addresses of destinations, so they
+-- may come from internal pages; they are fetched again by health checker
+update urls set page_title = '' where page_title <> '';
+-- +goose StatementEnd
+
+-- +goose Down
+-- dropped titles aren't restored, they are fetched again
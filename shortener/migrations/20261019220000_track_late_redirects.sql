@@ -0,0 +1,13 @@
+-- +goose Up
+-- +goose StatementBegin
+-- days which received redirects after they were rolled up, they are rolled
+-- up again by the next rollup
+create table redirects_late_days(
+    day date primary key
+);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+drop table redirects_late_days;
+-- +goose StatementEnd
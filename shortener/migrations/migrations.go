@@ -0,0 +1,16 @@
+// Package migrations embeds goose migrations of storage backends.
+package migrations
+
+import "embed"
+
+// Postgres migrations, they are applied by cmd/web on start.
+//
+//go:embed *.sql
+var Postgres embed.FS
+
+// SQLite migrations of sqlite storage, they are kept in parallel with
+// postgres ones: every postgres migration has sqlite one with the same
+// version, except migrations of postgres only rollups.
+//
+//go:embed sqlite/*.sql
+var SQLite embed.FS
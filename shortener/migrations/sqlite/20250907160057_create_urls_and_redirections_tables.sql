@@ -0,0 +1,24 @@
+-- +goose Up
+-- +goose StatementBegin
+-- times are stored as text in UTC, "2006-01-02 15:04:05.999999999" format
+create table urls(
+    id integer primary key,
+    alias text not null,
+    original text not null
+);
+-- index instead of column constraint, sqlite can't drop constraints
+create unique index urls_alias_key on urls (alias);
+
+create table redirects(
+    id integer primary key,
+    alias text not null,
+    dt timestamp not null,
+    user_agent text not null
+);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+drop table redirects;
+drop table urls;
+-- +goose StatementEnd
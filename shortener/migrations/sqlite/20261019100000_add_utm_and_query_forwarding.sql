@@ -0,0 +1,31 @@
+-- +goose Up
+-- +goose StatementBegin
+alter table urls add column forward_query boolean not null default false;
+alter table urls add column utm_source text not null default '';
+alter table urls add column utm_medium text not null default '';
+alter table urls add column utm_campaign text not null default '';
+alter table urls add column utm_term text not null default '';
+alter table urls add column utm_content text not null default '';
+
+alter table redirects add column utm_source text not null default '';
+alter table redirects add column utm_medium text not null default '';
+alter table redirects add column utm_campaign text not null default '';
+alter table redirects add column utm_term text not null default '';
+alter table redirects add column utm_content text not null default '';
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+alter table redirects drop column utm_source;
+alter table redirects drop column utm_medium;
+alter table redirects drop column utm_campaign;
+alter table redirects drop column utm_term;
+alter table redirects drop column utm_content;
+
+alter table urls drop column forward_query;
+alter table urls drop column utm_source;
+alter table urls drop column utm_medium;
+alter table urls drop column utm_campaign;
+alter table urls drop column utm_term;
+alter table urls drop column utm_content;
+-- +goose StatementEnd
@@ -0,0 +1,25 @@
+-- +goose Up
+-- +goose StatementBegin
+create table domains(
+    id integer primary key,
+    host text not null unique,
+    created_at timestamp not null
+);
+
+alter table urls add column domain text not null default '';
+drop index urls_alias_key;
+create unique index urls_domain_alias_key on urls (domain, alias);
+
+alter table redirects add column domain text not null default '';
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+alter table redirects drop column domain;
+
+drop index urls_domain_alias_key;
+alter table urls drop column domain;
+create unique index urls_alias_key on urls (alias);
+
+drop table domains;
+-- +goose StatementEnd
@@ -0,0 +1,11 @@
+-- +goose Up
+-- +goose StatementBegin
+-- single row counter, sqlite has no sequences
+create table alias_seq(value integer not null);
+insert into alias_seq (value) values (0);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+drop table alias_seq;
+-- +goose StatementEnd
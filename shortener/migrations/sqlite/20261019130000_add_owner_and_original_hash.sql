@@ -0,0 +1,13 @@
+-- +goose Up
+-- +goose StatementBegin
+alter table urls add column owner text not null default '';
+alter table urls add column original_hash text not null default '';
+create index urls_original_hash_idx on urls (original_hash);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+drop index urls_original_hash_idx;
+alter table urls drop column original_hash;
+alter table urls drop column owner;
+-- +goose StatementEnd
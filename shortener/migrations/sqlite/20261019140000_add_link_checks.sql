@@ -0,0 +1,18 @@
+-- +goose Up
+-- +goose StatementBegin
+create table link_checks(
+    url_id integer primary key references urls(id) on delete cascade,
+    status integer not null,
+    latency_ms integer not null,
+    error text not null default '',
+    checked_at timestamp not null
+);
+create index link_checks_checked_at_idx on link_checks (checked_at);
+create index link_checks_broken_idx on link_checks (checked_at)
+    where status = 0 or status >= 400;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+drop table link_checks;
+-- +goose StatementEnd
@@ -0,0 +1,12 @@
+-- +goose Up
+-- +goose StatementBegin
+-- there are no trigram indexes, search by alias and original scans links
+create index urls_owner_idx on urls (owner);
+create index redirects_domain_alias_idx on redirects (domain, alias);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+drop index redirects_domain_alias_idx;
+drop index urls_owner_idx;
+-- +goose StatementEnd
@@ -0,0 +1,23 @@
+-- +goose Up
+-- +goose StatementBegin
+-- creation time of existing links is unknown, time of migration is used
+alter table urls add column title text not null default '';
+alter table urls add column description text not null default '';
+alter table urls add column page_title text not null default '';
+alter table urls add column created_at timestamp not null default '';
+alter table urls add column updated_at timestamp not null default '';
+update urls set
+    created_at = strftime('%Y-%m-%d %H:%M:%f', 'now'),
+    updated_at = strftime('%Y-%m-%d %H:%M:%f', 'now');
+create index urls_created_at_idx on urls (created_at);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+drop index urls_created_at_idx;
+alter table urls drop column updated_at;
+alter table urls drop column created_at;
+alter table urls drop column page_title;
+alter table urls drop column description;
+alter table urls drop column title;
+-- +goose StatementEnd
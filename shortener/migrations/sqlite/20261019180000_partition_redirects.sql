@@ -0,0 +1,14 @@
+-- +goose Up
+-- +goose StatementBegin
+-- sqlite has no partitioning, only indexes for date predicates are added
+drop index redirects_domain_alias_idx;
+create index redirects_domain_alias_dt_idx on redirects (domain, alias, dt);
+create index redirects_alias_dt_idx on redirects (alias, dt);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+drop index redirects_alias_dt_idx;
+drop index redirects_domain_alias_dt_idx;
+create index redirects_domain_alias_idx on redirects (domain, alias);
+-- +goose StatementEnd
@@ -0,0 +1,16 @@
+-- +goose Up
+-- +goose StatementBegin
+-- hash covers settings of link now and is kept only for links created with
+-- reuse_existing, hashes of destination only can't be told apart from them,
+-- so existing links aren't reused
+update urls set original_hash = '' where original_hash <> '';
+drop index urls_original_hash_idx;
+create unique index urls_owner_domain_original_hash_idx
+    on urls (owner, domain, original_hash) where original_hash <> '';
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+drop index urls_owner_domain_original_hash_idx;
+create index urls_original_hash_idx on urls (original_hash);
+-- +goose StatementEnd
@@ -7,13 +7,14 @@ import (
 	"fmt"
 	"net/http"
 	"net/http/httptest"
-	"path/filepath"
-	"runtime"
+	"shortener/internal/entities/redirect"
 	"shortener/internal/service"
 	"shortener/internal/storage"
 	"shortener/internal/storage/postgres"
 	"shortener/internal/storage/redis"
+	"shortener/internal/storage/storagetest"
 	"shortener/internal/web/handlers"
+	"shortener/migrations"
 	"strings"
 	"testing"
 	"time"
@@ -78,12 +79,9 @@ func SetupTestDB(t *testing.T) testcontainers.Container {
 }
 
 func applyMigrations(t *testing.T, db *sql.DB) {
-	_, filename, _, _ := runtime.Caller(0)
-	migrationsDir := filepath.Join(filepath.Dir(filename), "../../migrations")
+	goose.SetBaseFS(migrations.Postgres)
 
-	goose.SetBaseFS(nil)
-
-	if err := goose.Up(db, migrationsDir); err != nil {
+	if err := goose.Up(db, "."); err != nil {
 		t.Fatalf("Failed to apply migrations: %v", err)
 	}
 
@@ -157,9 +155,9 @@ func TestMain(t *testing.T) {
 	err = json.Unmarshal(rr.Body.Bytes(), &alias)
 	require.NoError(t, err)
 
-	short, err := db.URL(alias.Result)
+	short, err := db.URL(context.Background(), "", alias.Result)
 	require.NoError(t, err)
-	require.NotEqual(t, "", short)
+	require.NotEqual(t, "", short.Original)
 	// ---------------------------------------------------
 
 	// --------------------- CHECK GETTING ---------------
@@ -170,11 +168,47 @@ func TestMain(t *testing.T) {
 	g.GET("/getting/:short_url", handlers.Redirect(srv))
 	g.ServeHTTP(rr, req)
 	require.Equal(t, http.StatusTemporaryRedirect, rr.Result().StatusCode)
-	cached, err := rd.URL(alias.Result)
+	cached, err := rd.URL(context.Background(), "", alias.Result)
 	require.NoError(t, err)
-	require.NotEqual(t, "", cached)
+	require.NotEqual(t, "", cached.Original)
 	// ---------------------------------------------------
 
 	srv.Shutdown()
 	str.Shutdown()
 }
+
+func TestStorageConformance(t *testing.T) {
+	dbCont := SetupTestDB(t)
+	defer func() { _ = dbCont.Terminate(context.Background()) }()
+
+	dbHost, err := dbCont.Host(context.Background())
+	require.NoError(t, err)
+	dbPort, err := dbCont.MappedPort(context.Background(), DBMapped)
+	require.NoError(t, err)
+
+	rdCont := SetupTestRedis(t)
+	defer func() { _ = rdCont.Terminate(context.Background()) }()
+
+	rdHost, err := rdCont.Host(context.Background())
+	require.NoError(t, err)
+	rdPort, err := rdCont.MappedPort(context.Background(), RedisMapped)
+	require.NoError(t, err)
+
+	db := postgres.New(dbHost, dbPort.Port(), DBUser, DBPassword, DBName, "disable")
+	str := storage.New(db, redis.New(fmt.Sprintf("%s:%s", rdHost, rdPort.Port()), "", 0))
+	defer str.Shutdown()
+
+	storagetest.Run(t, str)
+
+	// analytics must be the same when it's served by rollups
+	opts := redirect.AgrigateOpts{Alias: "st-r", Summary: true}
+	raw, err := str.AgrigatedRedirects(context.Background(), opts)
+	require.NoError(t, err)
+	_, err = db.RollupRedirects(context.Background(), time.Now())
+	require.NoError(t, err)
+	require.NoError(t, db.RebuildRollups(context.Background(), time.Time{}))
+	rolled, err := str.AgrigatedRedirects(context.Background(), opts)
+	require.NoError(t, err)
+	require.Equal(t, raw.Total, rolled.Total)
+	require.True(t, storagetest.DailyEqual(raw.Daily, rolled.Daily), "%+v != %+v", raw.Daily, rolled.Daily)
+}